@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/streamdp/ip-info/config"
+	"github.com/streamdp/ip-info/pkg/ipcache"
+	"github.com/streamdp/ip-info/pkg/iplocator"
+	"github.com/streamdp/microcache"
+)
+
+var (
+	errUnknownCommand = errors.New("unknown command")
+	errServeUsage     = errors.New("usage: ip-info [flags] serve [flags]")
+)
+
+type commander interface {
+	migrator
+	releaseDiffer
+	releaseImporter
+	datasetStater
+	releaseExporter
+	iplocator.Database
+	ReloadReleases(ctx context.Context) (bool, error)
+}
+
+// serveCommand tells whether the args run the server, the default command, the others are run by runCommand.
+func serveCommand(args []string) (bool, error) {
+	if len(args) == 0 {
+		return true, nil
+	}
+	if args[0] != "serve" {
+		return false, nil
+	}
+	if len(args) > 1 {
+		return true, errServeUsage
+	}
+
+	return true, nil
+}
+
+func runCommand(ctx context.Context, c commander, appCfg *config.App, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return migrate(ctx, c, args[1:], os.Stdout)
+	case "diff":
+		return diff(ctx, c, args[1:], os.Stdout)
+	case "lookup":
+		// the active tables are read on demand since the commands don't run the updater
+		if _, err := c.ReloadReleases(ctx); err != nil {
+			return err
+		}
+
+		return lookup(ctx, iplocator.New(c, nil), args[1:], os.Stdout, os.Stderr)
+	case "enrich":
+		if _, err := c.ReloadReleases(ctx); err != nil {
+			return err
+		}
+
+		// the addresses of logs repeat a lot, so they are cached in memory unless the cache is disabled
+		var (
+			ipInfoCache iplocator.IpCache
+			err         error
+		)
+		if appCfg.Cache.Enabled() {
+			if ipInfoCache, err = ipcache.New(microcache.New(ctx, 60000), appCfg.Cache); err != nil {
+				return err
+			}
+		}
+
+		return runEnrich(ctx, iplocator.New(c, ipInfoCache), args[1:])
+	case "import":
+		return importRelease(ctx, c, args[1:], os.Stdout)
+	case "status":
+		return status(ctx, c, args[1:], os.Stdout)
+	case "export":
+		// the mmdb export resolves the active release from the active tables
+		if _, err := c.ReloadReleases(ctx); err != nil {
+			return err
+		}
+
+		return export(ctx, c, iplocator.New(c, nil), args[1:], os.Stdout, os.Stderr)
+	default:
+		return fmt.Errorf("%w: %s", errUnknownCommand, args[0])
+	}
+}
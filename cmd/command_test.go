@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+var errCommon = errors.New("some_error")
+
+func Test_serveCommand(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantServe bool
+		wantErr   error
+	}{
+		{
+			name:      "no command serves",
+			wantServe: true,
+		},
+		{
+			name:      "serve",
+			args:      []string{"serve"},
+			wantServe: true,
+		},
+		{
+			name:      "serve with arguments",
+			args:      []string{"serve", "8.8.8.8"},
+			wantServe: true,
+			wantErr:   errServeUsage,
+		},
+		{
+			name: "other command",
+			args: []string{"lookup", "8.8.8.8"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serve, err := serveCommand(tt.args)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("serveCommand() error = %v, want %v", err, tt.wantErr)
+			}
+			if serve != tt.wantServe {
+				t.Errorf("serveCommand() = %v, want %v", serve, tt.wantServe)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+var errDiffUsage = errors.New("usage: ip-info diff <version>, e.g. 2024-09")
+
+type releaseDiffer interface {
+	ReleaseDiff(ctx context.Context, version string) (*domain.ReleaseDiff, error)
+}
+
+func diff(ctx context.Context, d releaseDiffer, args []string, stdout io.Writer) error {
+	if len(args) != 1 {
+		return errDiffUsage
+	}
+
+	report, err := d.ReleaseDiff(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	previousVersion := report.PreviousVersion
+	if previousVersion == "" {
+		previousVersion = "none"
+	}
+	// the report is stored before the release is activated, so an accepted release may not be published yet
+	status := "accepted"
+	switch {
+	case report.Rejected:
+		status = "rejected"
+	case report.Activated:
+		status = "activated"
+	}
+
+	_, _ = fmt.Fprintf(stdout, "%s release %s (previous %s) checked at %s, %s\n", report.Dataset, report.Version,
+		previousVersion, report.CreatedAt.UTC().Format(time.RFC3339), status)
+	_, _ = fmt.Fprintf(stdout, "%d ranges added, %d removed, %d changed of %d (%.2f%%)\n\n", report.Added,
+		report.Removed, report.Changed, report.PreviousRanges, report.ChangedPercent())
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "COUNTRY\tADDED\tREMOVED\tCHANGED")
+	for _, c := range report.Countries {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", c.Country, c.Added, c.Removed, c.Changed)
+	}
+
+	return w.Flush()
+}
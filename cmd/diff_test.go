@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+type releaseDifferMock struct {
+	diff *domain.ReleaseDiff
+	err  error
+}
+
+func (d *releaseDifferMock) ReleaseDiff(_ context.Context, _ string) (*domain.ReleaseDiff, error) {
+	return d.diff, d.err
+}
+
+func Test_diff(t *testing.T) {
+	report := &domain.ReleaseDiff{
+		Dataset:        "city",
+		Version:        "2024-09",
+		PreviousRanges: 200,
+		Added:          2,
+		Removed:        1,
+		Changed:        1,
+		Countries:      []*domain.CountryDiff{{Country: "NZ", Added: 2, Removed: 1, Changed: 1}},
+		CreatedAt:      time.Date(2024, 9, 2, 3, 0, 12, 0, time.UTC),
+		Activated:      true,
+	}
+	accepted, rejected := *report, *report
+	accepted.Activated = false
+	rejected.Activated, rejected.Rejected = false, true
+
+	tests := []struct {
+		name    string
+		args    []string
+		d       *releaseDifferMock
+		want    string
+		wantErr error
+	}{
+		{
+			name: "report",
+			args: []string{"2024-09"},
+			d:    &releaseDifferMock{diff: report},
+			want: "city release 2024-09 (previous none) checked at 2024-09-02T03:00:12Z, activated\n" +
+				"2 ranges added, 1 removed, 1 changed of 200 (2.00%)\n\n" +
+				"COUNTRY  ADDED  REMOVED  CHANGED\n" +
+				"NZ       2      1        1\n",
+		},
+		{
+			name: "release not activated yet",
+			args: []string{"2024-09"},
+			d:    &releaseDifferMock{diff: &accepted},
+			want: "city release 2024-09 (previous none) checked at 2024-09-02T03:00:12Z, accepted\n" +
+				"2 ranges added, 1 removed, 1 changed of 200 (2.00%)\n\n" +
+				"COUNTRY  ADDED  REMOVED  CHANGED\n" +
+				"NZ       2      1        1\n",
+		},
+		{
+			name: "rejected release",
+			args: []string{"2024-09"},
+			d:    &releaseDifferMock{diff: &rejected},
+			want: "city release 2024-09 (previous none) checked at 2024-09-02T03:00:12Z, rejected\n" +
+				"2 ranges added, 1 removed, 1 changed of 200 (2.00%)\n\n" +
+				"COUNTRY  ADDED  REMOVED  CHANGED\n" +
+				"NZ       2      1        1\n",
+		},
+		{
+			name:    "no version",
+			d:       &releaseDifferMock{diff: report},
+			wantErr: errDiffUsage,
+		},
+		{
+			name:    "database error",
+			args:    []string{"2024-09"},
+			d:       &releaseDifferMock{err: errCommon},
+			wantErr: errCommon,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			if err := diff(context.Background(), tt.d, tt.args, &stdout); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("diff() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := stdout.String(); got != tt.want {
+				t.Errorf("diff() output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/streamdp/ip-info/pkg/enrich"
+)
+
+var errEnrichUsage = errors.New("usage: ip-info enrich [-input-format csv|tsv] [-column <name>|<index>] " +
+	"[-regex <regex>] [-format csv|tsv|jsonl] [-workers <n>] [-output <file>] [<file>]")
+
+// runEnrich streams the file, or stdin, to the output with the locations appended, the progress and the summary go to
+// stderr.
+func runEnrich(ctx context.Context, l enrich.Locator, args []string) (err error) {
+	fs := flag.NewFlagSet("enrich", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	inputFormat := fs.String("input-format", enrich.FormatCsv, "format of the input with a header: csv, tsv")
+	column := fs.String("column", "ip", "name or 1-based index of the ip column")
+	regex := fs.String("regex", "", "regex of the address in the lines of a log, replaces the column")
+	format := fs.String("format", enrich.FormatCsv, "output format: csv, tsv, jsonl")
+	workers := fs.Int("workers", 8, "addresses located at once")
+	output := fs.String("output", "", "file to write the records to, defaults to stdout")
+	if err = fs.Parse(args); err != nil || fs.NArg() > 1 {
+		return errEnrichUsage
+	}
+
+	opts := enrich.Options{
+		Input:    *inputFormat,
+		Output:   *format,
+		Column:   *column,
+		Workers:  *workers,
+		Progress: os.Stderr,
+	}
+	if *regex != "" {
+		if opts.Regex, err = regexp.Compile(*regex); err != nil {
+			return fmt.Errorf("wrong regex: %w", err)
+		}
+	}
+	e, err := enrich.New(l, opts)
+	if err != nil {
+		return err
+	}
+
+	r := io.Reader(os.Stdin)
+	if file := fs.Arg(0); file != "" && file != "-" {
+		f, errOpen := os.Open(file)
+		if errOpen != nil {
+			return fmt.Errorf("failed to open input file: %w", errOpen)
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		f, errCreate := os.Create(*output)
+		if errCreate != nil {
+			return fmt.Errorf("failed to create output file: %w", errCreate)
+		}
+		defer func() {
+			if errClose := f.Close(); errClose != nil && err == nil {
+				err = fmt.Errorf("failed to close output file: %w", errClose)
+			}
+		}()
+		w = f
+	}
+
+	s, err := e.Run(ctx, r, w)
+	if s != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "enriched %d records, %d located, %d failed\n", s.Records, s.Located, s.Failed())
+		for _, ec := range s.Errors {
+			_, _ = fmt.Fprintf(os.Stderr, "  %d\t%s, first at record %d\n", ec.Count, ec.Error, ec.FirstRecord)
+		}
+	}
+
+	return err
+}
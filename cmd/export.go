@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+const (
+	exportFormatCsv  = "csv"
+	exportFormatMmdb = "mmdb"
+)
+
+var errExportUsage = errors.New(
+	"usage: ip-info export [-dataset city|asn|<provider>] [-format csv|mmdb] [-output <file>]",
+)
+
+type releaseExporter interface {
+	ExportRelease(ctx context.Context, name string, w io.Writer) (int64, error)
+}
+
+type mmdbExporter interface {
+	ExportMmdb(ctx context.Context, dataset string, w io.Writer) (*domain.MmdbExport, error)
+}
+
+// export writes the active release to the file or to stdout, so the summary goes to stderr.
+func export(
+	ctx context.Context, d releaseExporter, m mmdbExporter, args []string, stdout, stderr io.Writer,
+) (err error) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dataset := fs.String("dataset", "city", "dataset to export")
+	format := fs.String("format", exportFormatCsv, "csv or mmdb")
+	output := fs.String("output", "", "file to write the release to, defaults to stdout")
+	if err = fs.Parse(args); err != nil || fs.NArg() != 0 ||
+		*format != exportFormatCsv && *format != exportFormatMmdb {
+		return errExportUsage
+	}
+
+	w := stdout
+	if *output != "" {
+		f, errCreate := os.Create(*output)
+		if errCreate != nil {
+			return fmt.Errorf("failed to create export file: %w", errCreate)
+		}
+		defer func() {
+			if errClose := f.Close(); errClose != nil && err == nil {
+				err = fmt.Errorf("failed to close export file: %w", errClose)
+			}
+		}()
+		w = f
+	}
+
+	if *format == exportFormatMmdb {
+		e, errExport := m.ExportMmdb(ctx, *dataset, w)
+		if errExport != nil {
+			return errExport
+		}
+
+		_, _ = fmt.Fprintf(stderr, "%s release %s exported to mmdb, %d ranges\n", e.Dataset, e.Version, e.Ranges)
+
+		return nil
+	}
+
+	n, err := d.ExportRelease(ctx, *dataset, w)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(stderr, "%s release exported, %d ranges\n", *dataset, n)
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+type exporterMock struct {
+	err error
+}
+
+func (e *exporterMock) ExportRelease(_ context.Context, _ string, w io.Writer) (int64, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	_, _ = io.WriteString(w, "1.0.0.0,1.0.0.255,OC,AU,Queensland,South Brisbane,-27.4767,153.017\n")
+
+	return 1, nil
+}
+
+func (e *exporterMock) ExportMmdb(_ context.Context, dataset string, w io.Writer) (*domain.MmdbExport, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	_, _ = io.WriteString(w, "\xab\xcd\xefMaxMind.com")
+
+	return &domain.MmdbExport{Dataset: dataset, Version: "2024-09", Ranges: 1}, nil
+}
+
+func Test_export(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		e          *exporterMock
+		wantStdout string
+		wantStderr string
+		wantErr    error
+	}{
+		{
+			name:       "csv",
+			e:          &exporterMock{},
+			wantStdout: "1.0.0.0,1.0.0.255,OC,AU,Queensland,South Brisbane,-27.4767,153.017\n",
+			wantStderr: "city release exported, 1 ranges\n",
+		},
+		{
+			name:       "mmdb",
+			args:       []string{"-dataset", "asn", "-format", "mmdb"},
+			e:          &exporterMock{},
+			wantStdout: "\xab\xcd\xefMaxMind.com",
+			wantStderr: "asn release 2024-09 exported to mmdb, 1 ranges\n",
+		},
+		{
+			name:    "wrong format",
+			args:    []string{"-format", "json"},
+			e:       &exporterMock{},
+			wantErr: errExportUsage,
+		},
+		{
+			name:    "failed export",
+			e:       &exporterMock{err: errCommon},
+			wantErr: errCommon,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if err := export(context.Background(), tt.e, tt.e, tt.args, &stdout, &stderr); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("export() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := stdout.String(); got != tt.wantStdout {
+				t.Errorf("export() stdout = %q, want %q", got, tt.wantStdout)
+			}
+			if got := stderr.String(); got != tt.wantStderr {
+				t.Errorf("export() stderr = %q, want %q", got, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func Test_exportOutput(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "city.csv")
+
+	var stdout, stderr bytes.Buffer
+	if err := export(context.Background(), &exporterMock{}, &exporterMock{}, []string{"-output", output}, &stdout,
+		&stderr); err != nil {
+		t.Fatalf("export() error = %v", err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("export() wrote %q to stdout, want the file only", stdout.String())
+	}
+
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read the export: %v", err)
+	}
+	if !bytes.HasPrefix(got, []byte("1.0.0.0,1.0.0.255,OC,AU")) {
+		t.Errorf("export() file = %q, want the csv release", got)
+	}
+}
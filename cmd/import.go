@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+var errImportUsage = errors.New("usage: ip-info import [-dataset city|asn|<provider>] [-source <url>|<file>]")
+
+type releaseImporter interface {
+	ImportRelease(ctx context.Context, name, source string) (*domain.ImportedRelease, error)
+}
+
+func importRelease(ctx context.Context, d releaseImporter, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dataset := fs.String("dataset", "city", "dataset to import")
+	source := fs.String("source", "", "url or absolute file path of the release, defaults to the dataset one")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return errImportUsage
+	}
+
+	release, err := d.ImportRelease(ctx, *dataset, *source)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(stdout, "%s release %s imported, %d ranges\n", release.Dataset, release.Version, release.Rows)
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+type releaseImporterMock struct {
+	name, source string
+	err          error
+}
+
+func (i *releaseImporterMock) ImportRelease(_ context.Context, name, source string) (*domain.ImportedRelease, error) {
+	if i.err != nil {
+		return nil, i.err
+	}
+	i.name, i.source = name, source
+
+	return &domain.ImportedRelease{Dataset: name, Version: "2024-09", Rows: 3245870}, nil
+}
+
+func Test_importRelease(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		i          *releaseImporterMock
+		want       string
+		wantSource string
+		wantErr    error
+	}{
+		{
+			name: "default source",
+			args: []string{"-dataset", "asn"},
+			i:    &releaseImporterMock{},
+			want: "asn release 2024-09 imported, 3245870 ranges\n",
+		},
+		{
+			name:       "file source",
+			args:       []string{"-source", "/var/lib/ip-info/dbip-city-lite-2024-09.csv"},
+			i:          &releaseImporterMock{},
+			want:       "city release 2024-09 imported, 3245870 ranges\n",
+			wantSource: "/var/lib/ip-info/dbip-city-lite-2024-09.csv",
+		},
+		{
+			name:    "unexpected argument",
+			args:    []string{"city"},
+			i:       &releaseImporterMock{},
+			wantErr: errImportUsage,
+		},
+		{
+			name:    "failed import",
+			i:       &releaseImporterMock{err: errCommon},
+			wantErr: errCommon,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			if err := importRelease(context.Background(), tt.i, tt.args, &stdout); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("importRelease() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := stdout.String(); got != tt.want {
+				t.Errorf("importRelease() output = %q, want %q", got, tt.want)
+			}
+			if tt.i.source != tt.wantSource {
+				t.Errorf("importRelease() source = %q, want %q", tt.i.source, tt.wantSource)
+			}
+		})
+	}
+}
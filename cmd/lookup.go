@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+var (
+	errLookupUsage  = errors.New("usage: ip-info lookup [-format json|table] <ip>...")
+	errLookupFailed = errors.New("some addresses weren't located")
+)
+
+type ipLocator interface {
+	GetIpInfo(ctx context.Context, ip string, opts *domain.LookupOptions) (*domain.IpInfo, error)
+}
+
+// lookup locates the addresses one by one, those that fail are reported to stderr and don't stop the others.
+func lookup(ctx context.Context, l ipLocator, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("lookup", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	format := fs.String("format", "json", "output format: json, table")
+	if err := fs.Parse(args); err != nil || fs.NArg() == 0 || *format != "json" && *format != "table" {
+		return errLookupUsage
+	}
+
+	var (
+		out    = newLookupWriter(*format, stdout)
+		failed bool
+	)
+	for _, ip := range fs.Args() {
+		ipInfo, err := l.GetIpInfo(ctx, ip, nil)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "%s: %v\n", ip, err)
+			failed = true
+
+			continue
+		}
+		if err = out.write(ipInfo); err != nil {
+			return err
+		}
+	}
+	if err := out.flush(); err != nil {
+		return err
+	}
+	if failed {
+		return errLookupFailed
+	}
+
+	return nil
+}
+
+// lookupWriter prints the located addresses as json lines or as a table.
+type lookupWriter struct {
+	enc *json.Encoder
+	tw  *tabwriter.Writer
+}
+
+func newLookupWriter(format string, w io.Writer) *lookupWriter {
+	if format == "json" {
+		return &lookupWriter{enc: json.NewEncoder(w)}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "IP\tNETWORK\tCOUNTRY\tSTATE/PROV\tCITY\tLATITUDE\tLONGITUDE\tASN\tAS ORG\tSOURCE")
+
+	return &lookupWriter{tw: tw}
+}
+
+func (lw *lookupWriter) write(i *domain.IpInfo) error {
+	if lw.enc != nil {
+		return lw.enc.Encode(i)
+	}
+
+	_, err := fmt.Fprintf(lw.tw, "%s\t%s\t%s\t%s\t%s\t%g\t%g\t%d\t%s\t%s\n", i.Ip, i.Network, i.Country, i.StateProv,
+		i.City, i.Latitude, i.Longitude, i.Asn, i.AsOrg, i.Source)
+
+	return err
+}
+
+func (lw *lookupWriter) flush() error {
+	if lw.tw == nil {
+		return nil
+	}
+
+	return lw.tw.Flush()
+}
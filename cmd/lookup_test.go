@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+type ipLocatorMock struct {
+	ipInfos map[string]*domain.IpInfo
+}
+
+func (l *ipLocatorMock) GetIpInfo(_ context.Context, ip string, _ *domain.LookupOptions) (*domain.IpInfo, error) {
+	ipInfo, ok := l.ipInfos[ip]
+	if !ok {
+		return nil, errCommon
+	}
+
+	return ipInfo, nil
+}
+
+func Test_lookup(t *testing.T) {
+	l := &ipLocatorMock{ipInfos: map[string]*domain.IpInfo{
+		"8.8.8.8": {
+			Ip:      netip.MustParseAddr("8.8.8.8"),
+			Network: "8.8.8.0/24",
+			Country: "US",
+			City:    "Mountain View",
+			Asn:     15169,
+			AsOrg:   "Google LLC",
+			Source:  domain.SourceDbIp,
+		},
+	}}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantStdout string
+		wantStderr string
+		wantErr    error
+	}{
+		{
+			name:       "json",
+			args:       []string{"8.8.8.8"},
+			wantStdout: `"network":"8.8.8.0/24"`,
+		},
+		{
+			name:       "table",
+			args:       []string{"-format", "table", "8.8.8.8"},
+			wantStdout: "8.8.8.8  8.8.8.0/24  US",
+		},
+		{
+			name:       "failed address doesn't stop the others",
+			args:       []string{"192.0.2.1", "8.8.8.8"},
+			wantStdout: `"ip":"8.8.8.8"`,
+			wantStderr: "192.0.2.1: some_error\n",
+			wantErr:    errLookupFailed,
+		},
+		{
+			name:    "no address",
+			wantErr: errLookupUsage,
+		},
+		{
+			name:    "wrong format",
+			args:    []string{"-format", "xml", "8.8.8.8"},
+			wantErr: errLookupUsage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if err := lookup(context.Background(), l, tt.args, &stdout, &stderr); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("lookup() error = %v, want %v", err, tt.wantErr)
+			}
+			if !bytes.Contains(stdout.Bytes(), []byte(tt.wantStdout)) {
+				t.Errorf("lookup() stdout = %q, want it to contain %q", stdout.String(), tt.wantStdout)
+			}
+			if got := stderr.String(); got != tt.wantStderr {
+				t.Errorf("lookup() stderr = %q, want %q", got, tt.wantStderr)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/streamdp/ip-info/pkg/rediscache"
 	"github.com/streamdp/ip-info/pkg/redisclient"
 	"github.com/streamdp/ip-info/pkg/redislimiter"
+	"github.com/streamdp/ip-info/pkg/webhook"
 	"github.com/streamdp/ip-info/server"
 	"github.com/streamdp/ip-info/server/grpc"
 	"github.com/streamdp/ip-info/server/rest"
@@ -38,12 +39,6 @@ func run(l *log.Logger) error {
 		return err
 	}
 
-	l.Printf("Run mode:\n")
-	l.Printf("\tLimiter enabled=%v\n", appCfg.Limiter.Enabled())
-	l.Printf("\tLimiter=%v\n", appCfg.Limiter.Limiter())
-	l.Printf("\tCaching enabled=%v\n", appCfg.Cache.Enabled())
-	l.Printf("\tCacher=%v\n", appCfg.Cache.Cacher())
-
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
@@ -57,7 +52,44 @@ func run(l *log.Logger) error {
 		}
 	}()
 
-	go updater.New(d, l).PullUpdates(ctx)
+	serve, err := serveCommand(appCfg.Args())
+	if err != nil {
+		return err
+	}
+	if !serve {
+		return runCommand(ctx, d, appCfg, appCfg.Args())
+	}
+
+	l.Printf("Run mode:\n")
+	l.Printf("\tRole=%v\n", appCfg.Role())
+	l.Printf("\tLimiter enabled=%v\n", appCfg.Limiter.Enabled())
+	l.Printf("\tLimiter=%v\n", appCfg.Limiter.Limiter())
+	l.Printf("\tCaching enabled=%v\n", appCfg.Cache.Enabled())
+	l.Printf("\tCacher=%v\n", appCfg.Cache.Cacher())
+	l.Printf("\tSingle port=%v\n", appCfg.Http.SinglePort())
+	l.Printf("\tAuto migrate=%v\n", appCfg.Database.AutoMigrate())
+	l.Printf("\tUpdates disabled=%v\n", appCfg.Updater.Disabled())
+	l.Printf("\tUpdate schedule=%q\n", appCfg.Updater.Schedule())
+	l.Printf("\tWebhooks=%d\n", len(appCfg.Webhook.Urls()))
+
+	if err = d.CheckSchema(ctx, appCfg.Database.AutoMigrate()); err != nil {
+		return err
+	}
+
+	notifier, err := webhook.New(appCfg.Webhook, l)
+	if err != nil {
+		return err
+	}
+	defer notifier.Wait()
+
+	puller := updater.New(d, l, appCfg.Updater).WithNotifier(notifier)
+
+	if appCfg.Role() == config.RoleUpdater {
+		go puller.PullUpdates(ctx)
+		go listenSwaps(ctx, l, d, nil)
+
+		return runUpdater(ctx, l, appCfg, d, puller)
+	}
 
 	var redisClient *redis.Client
 	if appCfg.Limiter.Enabled() && appCfg.Limiter.Limiter() == "redis_rate" ||
@@ -104,7 +136,20 @@ func run(l *log.Logger) error {
 		}
 	}
 
+	// the cached lookups start on the cache generation the instances share, skipping the entries of an earlier run
+	if _, err = d.ReloadReleases(ctx); err != nil {
+		return err
+	}
 	ipLocator := iplocator.New(d, ipInfoCache)
+	if err = ipLocator.FlushCache(ctx); err != nil {
+		return err
+	}
+	go listenSwaps(ctx, l, d, ipLocator.FlushCache)
+	// the polls flush the caches as well in case the notification of a swap was missed
+	go puller.WithSwapHandler(ipLocator.FlushCache).PullUpdates(ctx)
+
+	grpcSrv := grpc.NewServer(ipLocator, l, limiter, appCfg)
+	defer grpcSrv.Close()
 
 	httpSrv := rest.NewServer(ipLocator, l, limiter, appCfg.Http, appCfg.Version())
 	defer func(srv *rest.Server) {
@@ -116,12 +161,60 @@ func run(l *log.Logger) error {
 		}
 	}(httpSrv)
 
+	gateway, err := grpcSrv.Gateway(ctx)
+	if err != nil {
+		return err
+	}
+	httpSrv.WithGateway(gateway).WithMetrics(d).WithAdmin(ipLocator).WithUpdater(puller).
+		WithAdminToken(appCfg.Http.AdminToken())
+	if appCfg.Http.AdminToken() == "" {
+		l.Println("admin endpoints are disabled, set IP_INFO_ADMIN_TOKEN to serve them")
+	}
+
+	if appCfg.Http.SinglePort() {
+		httpSrv.WithGrpc(grpcSrv.Handler())
+	} else {
+		go grpcSrv.Run()
+	}
+
 	go httpSrv.Run()
 
-	grpcSrv := grpc.NewServer(ipLocator, l, limiter, appCfg)
-	defer grpcSrv.Close()
+	<-ctx.Done()
+
+	return nil
+}
 
-	go grpcSrv.Run()
+type swapListener interface {
+	ListenSwaps(ctx context.Context, onSwap func(ctx context.Context) error) error
+}
+
+// listenSwaps keeps the active tables of the instance in sync with the releases published by the updater.
+func listenSwaps(ctx context.Context, l *log.Logger, d swapListener, onSwap func(ctx context.Context) error) {
+	if err := d.ListenSwaps(ctx, onSwap); err != nil {
+		l.Println(err)
+	}
+}
+
+// runUpdater serves only the health, metrics and status endpoints while the puller imports the releases, the lookups
+// are left to the instances of the api role.
+func runUpdater(ctx context.Context, l *log.Logger, appCfg *config.App, pools server.PoolStater,
+	puller server.UpdaterStater,
+) error {
+	httpSrv := rest.NewServer(nil, l, nil, appCfg.Http, appCfg.Version()).WithMetrics(pools).WithUpdater(puller).
+		WithAdminToken(appCfg.Http.AdminToken())
+	if appCfg.Http.AdminToken() == "" {
+		l.Println("admin endpoints are disabled, set IP_INFO_ADMIN_TOKEN to serve them")
+	}
+	defer func() {
+		ctxTimeout, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		if err := httpSrv.Close(ctxTimeout); err != nil {
+			l.Println(err)
+		}
+	}()
+
+	go httpSrv.Run()
 
 	<-ctx.Done()
 
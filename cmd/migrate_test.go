@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+type migratorMock struct {
+	ups, downs int
+}
+
+func (m *migratorMock) MigrateUp(_ context.Context) error {
+	m.ups++
+
+	return nil
+}
+
+func (m *migratorMock) MigrateDown(_ context.Context) error {
+	m.downs++
+
+	return nil
+}
+
+func (m *migratorMock) MigrationStatus(_ context.Context) ([]*domain.Migration, error) {
+	return []*domain.Migration{
+		{Version: 1, Name: "init", AppliedAt: time.Date(2024, 9, 2, 3, 0, 0, 0, time.UTC)},
+		{Version: 2, Name: "asn"},
+	}, nil
+}
+
+func Test_migrate(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		want      string
+		wantUps   int
+		wantDowns int
+		wantErr   error
+	}{
+		{
+			name:    "up",
+			args:    []string{"up"},
+			wantUps: 1,
+		},
+		{
+			name:      "down",
+			args:      []string{"down"},
+			wantDowns: 1,
+		},
+		{
+			name: "status",
+			args: []string{"status"},
+			want: "VERSION  NAME  APPLIED AT\n" +
+				"0001     init  2024-09-02T03:00:00Z\n" +
+				"0002     asn   pending\n",
+		},
+		{
+			name:    "no action",
+			wantErr: errMigrateUsage,
+		},
+		{
+			name:    "unknown action",
+			args:    []string{"redo"},
+			wantErr: errMigrateUsage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				m      = &migratorMock{}
+				stdout bytes.Buffer
+			)
+			if err := migrate(context.Background(), m, tt.args, &stdout); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("migrate() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := stdout.String(); got != tt.want {
+				t.Errorf("migrate() output = %q, want %q", got, tt.want)
+			}
+			if m.ups != tt.wantUps || m.downs != tt.wantDowns {
+				t.Errorf("migrate() ran %d up and %d down, want %d and %d", m.ups, m.downs, tt.wantUps, tt.wantDowns)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+var errStatusUsage = errors.New("usage: ip-info status")
+
+type datasetStater interface {
+	DatasetStatus(ctx context.Context) ([]*domain.DatasetStatus, error)
+}
+
+func status(ctx context.Context, d datasetStater, args []string, stdout io.Writer) error {
+	if len(args) != 0 {
+		return errStatusUsage
+	}
+
+	datasets, err := d.DatasetStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "DATASET\tACTIVE TABLE\tLAST UPDATE\tROWS")
+	for _, s := range datasets {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", s.Dataset, s.ActiveTable, s.LastUpdate.UTC().Format(time.RFC3339),
+			s.Rows)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+type datasetStaterMock struct {
+	datasets []*domain.DatasetStatus
+	err      error
+}
+
+func (d *datasetStaterMock) DatasetStatus(_ context.Context) ([]*domain.DatasetStatus, error) {
+	return d.datasets, d.err
+}
+
+func Test_status(t *testing.T) {
+	datasets := []*domain.DatasetStatus{
+		{
+			Dataset: "city",
+			DatabaseConfig: domain.DatabaseConfig{
+				ActiveTable: "ip_to_city_2024_09",
+				LastUpdate:  time.Date(2024, 9, 2, 3, 0, 12, 0, time.UTC),
+			},
+			Rows: 3245870,
+		},
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		d       *datasetStaterMock
+		want    string
+		wantErr error
+	}{
+		{
+			name: "active releases",
+			d:    &datasetStaterMock{datasets: datasets},
+			want: "DATASET  ACTIVE TABLE        LAST UPDATE           ROWS\n" +
+				"city     ip_to_city_2024_09  2024-09-02T03:00:12Z  3245870\n",
+		},
+		{
+			name:    "unexpected argument",
+			args:    []string{"city"},
+			d:       &datasetStaterMock{datasets: datasets},
+			wantErr: errStatusUsage,
+		},
+		{
+			name:    "database error",
+			d:       &datasetStaterMock{err: errCommon},
+			wantErr: errCommon,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			if err := status(context.Background(), tt.d, tt.args, &stdout); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("status() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := stdout.String(); got != tt.want {
+				t.Errorf("status() output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
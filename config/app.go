@@ -1,7 +1,22 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"slices"
+)
+
+// Roles split a deployment into the instances serving the lookups and a single one importing the releases.
+const (
+	RoleAll     = "all"
+	RoleApi     = "api"
+	RoleUpdater = "updater"
+)
+
+var (
+	errWrongRole           = errors.New("role should be one of all, api, updater")
+	errUpdaterRoleDisabled = errors.New("updates can't be disabled in the updater role")
 )
 
 type App struct {
@@ -11,8 +26,12 @@ type App struct {
 	Cache    *Cache
 	Redis    *Redis
 	Database *Database
+	Updater  *Updater
+	Webhook  *Webhook
 
 	version string
+	role    string
+	args    []string
 }
 
 func newAppConfig() *App {
@@ -23,8 +42,11 @@ func newAppConfig() *App {
 		Cache:    newCacheConfig(),
 		Redis:    newRedisConfig(),
 		Database: newDatabaseConfig(),
+		Updater:  newUpdaterConfig(),
+		Webhook:  newWebhookConfig(),
 
 		version: version,
+		role:    RoleAll,
 	}
 }
 
@@ -32,13 +54,37 @@ func (a *App) Version() string {
 	return a.version
 }
 
+// Role returns what the instance runs: the api role serves the lookups and polls the releases activated by the
+// updater, the updater role imports the releases and serves the status endpoints only, the all role does both.
+func (a *App) Role() string {
+	return a.role
+}
+
+// Args returns the command line arguments left after the flags, like the migrate subcommand.
+func (a *App) Args() []string {
+	return a.args
+}
+
 func (a *App) loadEnvs() error {
 	if err := a.Database.loadEnvs(); err != nil {
 		return fmt.Errorf("failed to load 'IP_INFO_DATABASE_URL' env: %w", err)
 	}
+	if err := a.Database.loadProviders(); err != nil {
+		return err
+	}
 
+	a.Http.loadEnvs()
 	a.Limiter.loadEnvs()
 	a.Cache.loadEnvs()
+	a.Updater.loadEnvs()
+	a.Webhook.loadEnvs()
+	if err := a.Webhook.loadTemplate(); err != nil {
+		return err
+	}
+
+	if role := os.Getenv("IP_INFO_ROLE"); a.role == RoleAll && role != "" {
+		a.role = role
+	}
 
 	return nil
 }
@@ -62,6 +108,23 @@ func (a *App) validate() error {
 	if err := a.Redis.validate(); err != nil {
 		return err
 	}
+	if err := a.Updater.validate(); err != nil {
+		return err
+	}
+	if err := a.Webhook.validate(); err != nil {
+		return err
+	}
+
+	if !slices.Contains([]string{RoleAll, RoleApi, RoleUpdater}, a.role) {
+		return errWrongRole
+	}
+	if a.role == RoleUpdater && a.Updater.disabled {
+		return errUpdaterRoleDisabled
+	}
+	// api instances never import, they only reload the releases the updater activates
+	if a.role == RoleApi {
+		a.Updater.disabled = true
+	}
 
 	return nil
 }
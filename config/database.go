@@ -2,23 +2,61 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/streamdp/ip-info/domain"
 )
 
-const databaseRequestTimeout = 5000
+const (
+	databaseRequestTimeout          = 5000
+	databaseDefaultMaxOpenConns     = 25
+	databaseDefaultMaxIdleConns     = 25
+	databaseDefaultConnMaxLifetime  = 300
+	databaseDefaultReleaseRetention = 12
+)
 
-var errEmptyDatabaseUrl = errors.New("database url cannot be blank")
+var (
+	errEmptyDatabaseUrl   = errors.New("database url cannot be blank")
+	errWrongMaxOpenConns  = errors.New("max open connections shouldn't be negative")
+	errWrongMaxIdleConns  = errors.New("max idle connections shouldn't be negative")
+	errWrongConnLifetime  = errors.New("connection lifetime shouldn't be negative")
+	errWrongReplicaUrl    = errors.New("replica url cannot be blank")
+	errIdleConnsExceedMax = errors.New("max idle connections shouldn't exceed max open connections")
+	errWrongRetention     = errors.New("release retention shouldn't be negative")
+	errWrongReleaseChange = errors.New("max release change shouldn't be negative")
+)
 
 type Database struct {
 	url            string
+	replicaUrls    []string
 	requestTimeout int
+
+	maxOpenConns    int
+	maxIdleConns    int
+	connMaxLifetime int
+
+	autoMigrate      bool
+	releaseRetention int
+	maxReleaseChange float64
+
+	providersFile string
+	providers     []*Provider
+	precedence    map[string][]string
 }
 
 func newDatabaseConfig() *Database {
 	return &Database{
-		url:            "",
-		requestTimeout: databaseRequestTimeout,
+		url:             "",
+		requestTimeout:  databaseRequestTimeout,
+		maxOpenConns:    databaseDefaultMaxOpenConns,
+		maxIdleConns:    databaseDefaultMaxIdleConns,
+		connMaxLifetime: databaseDefaultConnMaxLifetime,
+
+		releaseRetention: databaseDefaultReleaseRetention,
 	}
 }
 
@@ -36,11 +74,114 @@ func (d *Database) Url() string {
 	return d.url
 }
 
+// ReplicaUrls returns the read-only replicas used for lookups, updates always go to the primary.
+func (d *Database) ReplicaUrls() []string {
+	return d.replicaUrls
+}
+
+// MaxOpenConns returns the limit of open connections per pool, zero means unlimited.
+func (d *Database) MaxOpenConns() int {
+	return d.maxOpenConns
+}
+
+func (d *Database) MaxIdleConns() int {
+	return d.maxIdleConns
+}
+
+// ConnMaxLifetime returns how long a connection may be reused, zero means forever.
+func (d *Database) ConnMaxLifetime() time.Duration {
+	return time.Duration(d.connMaxLifetime) * time.Second
+}
+
+// ReleaseRetention returns the number of past monthly releases kept for historical lookups besides the active one,
+// zero keeps the active release only.
+func (d *Database) ReleaseRetention() int {
+	return d.releaseRetention
+}
+
+func (d *Database) SetReleaseRetention(releases int) *Database {
+	d.releaseRetention = releases
+
+	return d
+}
+
+// MaxReleaseChange returns the share of ranges in percent an import may add, remove or change before it's rejected,
+// zero disables the check.
+func (d *Database) MaxReleaseChange() float64 {
+	return d.maxReleaseChange
+}
+
+func (d *Database) SetMaxReleaseChange(percent float64) *Database {
+	d.maxReleaseChange = percent
+
+	return d
+}
+
+// Providers returns the city datasets imported besides db-ip.
+func (d *Database) Providers() []*Provider {
+	return d.providers
+}
+
+// Precedence returns the providers the location field is taken from in order, the configured providers followed by
+// db-ip unless the precedence of the field is set.
+func (d *Database) Precedence(field string) []string {
+	if names, ok := d.precedence[field]; ok {
+		return names
+	}
+
+	names := make([]string, 0, len(d.providers)+1)
+	for _, p := range d.providers {
+		names = append(names, p.Name)
+	}
+
+	return append(names, domain.SourceDbIp)
+}
+
+func (d *Database) SetProviders(providers []*Provider, precedence map[string][]string) *Database {
+	d.providers, d.precedence = providers, precedence
+
+	return d
+}
+
+// AutoMigrate reports whether pending schema migrations are applied at startup.
+func (d *Database) AutoMigrate() bool {
+	return d.autoMigrate
+}
+
 func (d *Database) loadEnvs() error {
 	if d.url = os.Getenv("IP_INFO_DATABASE_URL"); d.url == "" {
 		return errEmptyDatabaseUrl
 	}
 
+	if !d.autoMigrate {
+		d.autoMigrate = strings.ToLower(os.Getenv("IP_INFO_AUTO_MIGRATE")) == "true"
+	}
+
+	if replicaUrls := os.Getenv("IP_INFO_DATABASE_REPLICA_URLS"); replicaUrls != "" {
+		d.replicaUrls = nil
+		for _, url := range strings.Split(replicaUrls, ",") {
+			d.replicaUrls = append(d.replicaUrls, strings.TrimSpace(url))
+		}
+	}
+
+	return nil
+}
+
+// loadProviders reads the providers from the file set by the flag or the IP_INFO_PROVIDERS env.
+func (d *Database) loadProviders() error {
+	if d.providersFile == "" {
+		d.providersFile = os.Getenv("IP_INFO_PROVIDERS")
+	}
+	if d.providersFile == "" {
+		return nil
+	}
+
+	f, err := loadProviders(d.providersFile)
+	if err != nil {
+		return err
+	}
+	d.providers, d.precedence = f.Providers, f.Precedence
+
 	return nil
 }
 
@@ -48,6 +189,40 @@ func (d *Database) validate() error {
 	if d.url == "" {
 		return errEmptyDatabaseUrl
 	}
+	for i, url := range d.replicaUrls {
+		if url == "" {
+			return fmt.Errorf("database: replica %d: %w", i, errWrongReplicaUrl)
+		}
+	}
+	if d.maxOpenConns < 0 {
+		return fmt.Errorf("database: %w", errWrongMaxOpenConns)
+	}
+	if d.maxIdleConns < 0 {
+		return fmt.Errorf("database: %w", errWrongMaxIdleConns)
+	}
+	if d.maxOpenConns > 0 && d.maxIdleConns > d.maxOpenConns {
+		return fmt.Errorf("database: %w", errIdleConnsExceedMax)
+	}
+	if d.connMaxLifetime < 0 {
+		return fmt.Errorf("database: %w", errWrongConnLifetime)
+	}
+	if d.releaseRetention < 0 {
+		return fmt.Errorf("database: %w", errWrongRetention)
+	}
+	if d.maxReleaseChange < 0 {
+		return fmt.Errorf("database: %w", errWrongReleaseChange)
+	}
+	for i, p := range d.providers {
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("database: provider %d: %w", i, err)
+		}
+		if slices.ContainsFunc(d.providers[:i], func(prev *Provider) bool { return prev.Name == p.Name }) {
+			return fmt.Errorf("database: provider %d: %w", i, errDuplicateProvider)
+		}
+	}
+	if err := validatePrecedence(d.precedence, d.providers); err != nil {
+		return fmt.Errorf("database: %w", err)
+	}
 
 	return nil
 }
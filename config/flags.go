@@ -26,9 +26,61 @@ func LoadConfig() (*App, error) {
 		"http server read header timeout")
 	flag.IntVar(&appCfg.Http.serverWriteTimeout, "write-timeout", httpServerDefaultTimeout,
 		"http server write timeout")
+	flag.BoolVar(&appCfg.Http.singlePort, "single-port", false, "serve gRPC, gRPC-Web and REST on the http port")
+	flag.Func("cors-origins", "comma-separated origins of the pages allowed to make gRPC-Web calls, * allows any",
+		func(origins string) error {
+			appCfg.Http.setCorsOrigins(origins)
+
+			return nil
+		},
+	)
+	flag.StringVar(&appCfg.Http.adminToken, "admin-token", "",
+		"bearer token of the /admin endpoints, they are disabled without one, prefer "+
+			"IP_INFO_ADMIN_TOKEN to keep it out of the process list")
 
 	flag.IntVar(&appCfg.Database.requestTimeout, "db-request-timeout", databaseRequestTimeout,
 		"database request timeout in milliseconds")
+	flag.IntVar(&appCfg.Database.maxOpenConns, "db-max-open-conns", databaseDefaultMaxOpenConns,
+		"max open connections per database pool, 0 means unlimited")
+	flag.IntVar(&appCfg.Database.maxIdleConns, "db-max-idle-conns", databaseDefaultMaxIdleConns,
+		"max idle connections per database pool")
+	flag.IntVar(&appCfg.Database.connMaxLifetime, "db-conn-max-lifetime", databaseDefaultConnMaxLifetime,
+		"database connection max lifetime in seconds, 0 means forever")
+	flag.IntVar(&appCfg.Database.releaseRetention, "release-retention", databaseDefaultReleaseRetention,
+		"number of past monthly releases kept for historical lookups, 0 keeps the active release only")
+	flag.Float64Var(&appCfg.Database.maxReleaseChange, "max-release-change", 0,
+		"reject imports changing more than this percent of the city ranges, 0 disables the check")
+	flag.StringVar(&appCfg.Database.providersFile, "providers", "",
+		"json file with the city providers merged with db-ip and the precedence of the location fields")
+	flag.BoolVar(&appCfg.Database.autoMigrate, "auto-migrate", false, "apply pending schema migrations at startup")
+
+	flag.StringVar(&appCfg.Updater.schedule, "update-schedule", "",
+		"cron expression of the update runs in UTC, e.g. \"0 3 * * *\", by default the runs follow the monthly releases")
+	flag.IntVar(&appCfg.Updater.jitter, "update-jitter", updaterDefaultJitter,
+		"max random delay in seconds added to the scheduled update runs")
+	flag.IntVar(&appCfg.Updater.retryBackoff, "update-retry-backoff", updaterDefaultRetryBackoff,
+		"delay in seconds before the first retry of a failed update, doubled on every next attempt")
+	flag.IntVar(&appCfg.Updater.retryMaxBackoff, "update-retry-max-backoff", updaterDefaultRetryMaxBackoff,
+		"max delay in seconds between the retries of a failed update")
+	flag.IntVar(&appCfg.Updater.retryAttempts, "update-retry-attempts", updaterDefaultRetryAttempts,
+		"attempts of a failed update before it waits for the next scheduled run")
+	flag.BoolVar(&appCfg.Updater.disabled, "disable-updates", false,
+		"never import releases, only reload the ones activated by other instances")
+	flag.IntVar(&appCfg.Updater.pollInterval, "config-poll-interval", updaterDefaultPollInterval,
+		"interval in seconds of polling the releases activated by other instances when updates are disabled")
+	flag.Func("webhook-urls", "comma-separated urls notified of the update runs", func(urls string) error {
+		appCfg.Webhook.setUrls(urls)
+
+		return nil
+	})
+	flag.StringVar(&appCfg.Webhook.templateFile, "webhook-template", "",
+		"text/template file the webhook payloads are rendered with, e.g. for Slack")
+	flag.IntVar(&appCfg.Webhook.retryAttempts, "webhook-retry-attempts", webhookDefaultRetryAttempts,
+		"deliveries of a webhook payload before it's dropped")
+	flag.IntVar(&appCfg.Webhook.timeout, "webhook-timeout", webhookDefaultTimeout,
+		"webhook request timeout in milliseconds")
+	flag.StringVar(&appCfg.role, "role", RoleAll,
+		"what the instance runs: all, api to serve lookups only, updater to import releases only")
 
 	flag.StringVar(&appCfg.Redis.host, "redis-host", redisDefaultHost, "redis host")
 	flag.IntVar(&appCfg.Redis.port, "redis-port", redisDefaultPort, "redis port")
@@ -48,8 +100,32 @@ func LoadConfig() (*App, error) {
 
 	flag.Parse()
 
+	// serve takes the flags after the command as well, so both ip-info [flags] serve and ip-info serve [flags] work
+	args := flag.Args()
+	if len(args) > 0 && args[0] == "serve" {
+		if err := flag.CommandLine.Parse(args[1:]); err != nil {
+			return nil, fmt.Errorf("failed to parse serve flags: %w", err)
+		}
+		args = append([]string{"serve"}, flag.Args()...)
+	}
+
 	if showHelp {
 		fmt.Printf("ip-info is a microservice for IP location determination\n\n")
+		fmt.Printf("Usage: ip-info [flags] [command], or ip-info serve [flags]\n\n")
+		fmt.Printf("The flags go before the other commands, which take only their own options after them.\n\n")
+		fmt.Printf("Commands:\n")
+		fmt.Printf("  serve                      run the server, the default\n")
+		fmt.Printf("  lookup [-format json|table] <ip>...\n")
+		fmt.Printf("                             locate the addresses with the database\n")
+		fmt.Printf("  enrich [-column name|-regex regex] [-format csv|tsv|jsonl] [file]\n")
+		fmt.Printf("                             append the locations to the records of a csv or a log\n")
+		fmt.Printf("  import [-dataset name] [-source url|file]\n")
+		fmt.Printf("                             import the release of the current month now\n")
+		fmt.Printf("  status                     show the active releases and their ranges\n")
+		fmt.Printf("  export [-dataset name] [-format csv|mmdb] [-output file]\n")
+		fmt.Printf("                             write the active release as a db-ip csv or an mmdb\n")
+		fmt.Printf("  migrate up|down|status     manage the database schema\n")
+		fmt.Printf("  diff <version>             compare a release with the previous one\n\n")
 		flag.Usage()
 		os.Exit(0)
 	}
@@ -59,6 +135,10 @@ func LoadConfig() (*App, error) {
 		os.Exit(0)
 	}
 
+	if len(args) > 0 {
+		appCfg.args = args
+	}
+
 	if err := appCfg.loadEnvs(); err != nil {
 		return nil, fmt.Errorf("failed to load os envs: %w", err)
 	}
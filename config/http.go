@@ -3,6 +3,9 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/url"
+	"os"
+	"strings"
 	"time"
 )
 
@@ -12,13 +15,19 @@ const (
 	httpClientDefaultTimeout = 5000
 )
 
-var errWrongNetworkPort = errors.New("port must be between 0 and 65535")
+var (
+	errWrongNetworkPort = errors.New("port must be between 0 and 65535")
+	errWrongCorsOrigin  = errors.New("cors origin should be * or a scheme and a host, e.g. https://app.example.com")
+)
 
 type Http struct {
 	port                    int
 	serverReadTimeout       int
 	serverReadHeaderTimeout int
 	serverWriteTimeout      int
+	singlePort              bool
+	corsOrigins             []string
+	adminToken              string
 
 	clientTimeout int
 }
@@ -29,6 +38,7 @@ func newHttpConfig() *Http {
 		serverReadTimeout:       httpServerDefaultTimeout,
 		serverReadHeaderTimeout: httpServerDefaultTimeout,
 		serverWriteTimeout:      httpServerDefaultTimeout,
+		singlePort:              false,
 		clientTimeout:           httpClientDefaultTimeout,
 	}
 }
@@ -53,10 +63,64 @@ func (h *Http) Port() int {
 	return h.port
 }
 
+func (h *Http) SinglePort() bool {
+	return h.singlePort
+}
+
+// CorsOrigins returns the origins of the pages allowed to make gRPC-Web calls, "*" allows any origin.
+func (h *Http) CorsOrigins() []string {
+	return h.corsOrigins
+}
+
+func (h *Http) SetCorsOrigins(origins ...string) *Http {
+	h.corsOrigins = origins
+
+	return h
+}
+
+// AdminToken returns the bearer token of the /admin endpoints, they aren't served without one.
+func (h *Http) AdminToken() string {
+	return h.adminToken
+}
+
+func (h *Http) SetAdminToken(token string) *Http {
+	h.adminToken = token
+
+	return h
+}
+
+func (h *Http) loadEnvs() {
+	if !h.singlePort {
+		h.singlePort = strings.ToLower(os.Getenv("IP_INFO_SINGLE_PORT")) == "true"
+	}
+	if origins := os.Getenv("IP_INFO_CORS_ORIGINS"); len(h.corsOrigins) == 0 && origins != "" {
+		h.setCorsOrigins(origins)
+	}
+	if h.adminToken == "" {
+		h.adminToken = os.Getenv("IP_INFO_ADMIN_TOKEN")
+	}
+}
+
+func (h *Http) setCorsOrigins(origins string) {
+	h.corsOrigins = nil
+	for _, o := range strings.Split(origins, ",") {
+		h.corsOrigins = append(h.corsOrigins, strings.TrimSpace(o))
+	}
+}
+
 func (h *Http) validate() error {
 	if h.port < 0 || h.port > 65535 {
 		return fmt.Errorf("http: %w", errWrongNetworkPort)
 	}
+	for _, origin := range h.corsOrigins {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" ||
+			u.RawQuery != "" || u.Fragment != "" {
+			return fmt.Errorf("http: %s: %w", origin, errWrongCorsOrigin)
+		}
+	}
 
 	return nil
 }
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"errors"
+	"slices"
 	"testing"
 	"time"
 )
@@ -133,3 +135,134 @@ func TestHttp_Port(t *testing.T) {
 		})
 	}
 }
+
+func TestHttp_SinglePort(t *testing.T) {
+	tests := []struct {
+		name string
+		h    *Http
+		env  string
+		want bool
+	}{
+		{
+			name: "single port disabled by default",
+			h:    newHttpConfig(),
+			want: false,
+		},
+		{
+			name: "single port enabled by flag",
+			h: &Http{
+				singlePort: true,
+			},
+			want: true,
+		},
+		{
+			name: "single port enabled by env",
+			h:    newHttpConfig(),
+			env:  "true",
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("IP_INFO_SINGLE_PORT", tt.env)
+			tt.h.loadEnvs()
+			if got := tt.h.SinglePort(); got != tt.want {
+				t.Errorf("SinglePort() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHttp_CorsOrigins(t *testing.T) {
+	tests := []struct {
+		name    string
+		h       *Http
+		env     string
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "no origins by default",
+			h:    newHttpConfig(),
+		},
+		{
+			name: "origins set by env",
+			h:    newHttpConfig(),
+			env:  "https://app.example.com, http://localhost:3000",
+			want: []string{"https://app.example.com", "http://localhost:3000"},
+		},
+		{
+			name: "flag takes precedence over env",
+			h:    newHttpConfig().SetCorsOrigins("https://app.example.com"),
+			env:  "*",
+			want: []string{"https://app.example.com"},
+		},
+		{
+			name: "any origin",
+			h:    newHttpConfig(),
+			env:  "*",
+			want: []string{"*"},
+		},
+		{
+			name:    "origin without scheme",
+			h:       newHttpConfig(),
+			env:     "app.example.com",
+			want:    []string{"app.example.com"},
+			wantErr: errWrongCorsOrigin,
+		},
+		{
+			name:    "origin with path",
+			h:       newHttpConfig(),
+			env:     "https://app.example.com/",
+			want:    []string{"https://app.example.com/"},
+			wantErr: errWrongCorsOrigin,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("IP_INFO_CORS_ORIGINS", tt.env)
+			tt.h.loadEnvs()
+			if got := tt.h.CorsOrigins(); !slices.Equal(got, tt.want) {
+				t.Errorf("CorsOrigins() = %v, want %v", got, tt.want)
+			}
+			if err := tt.h.validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHttp_AdminToken(t *testing.T) {
+	tests := []struct {
+		name string
+		h    *Http
+		env  string
+		want string
+	}{
+		{
+			name: "admin endpoints disabled by default",
+			h:    newHttpConfig(),
+		},
+		{
+			name: "token set by env",
+			h:    newHttpConfig(),
+			env:  "s3cr3t",
+			want: "s3cr3t",
+		},
+		{
+			name: "flag takes precedence over env",
+			h:    newHttpConfig().SetAdminToken("flag-token"),
+			env:  "s3cr3t",
+			want: "flag-token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("IP_INFO_ADMIN_TOKEN", tt.env)
+			tt.h.loadEnvs()
+			if got := tt.h.AdminToken(); got != tt.want {
+				t.Errorf("AdminToken() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
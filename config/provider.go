@@ -0,0 +1,149 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+// Columns of the city tables a provider csv is mapped to.
+const (
+	ColumnIpStart   = "ip_start"
+	ColumnIpEnd     = "ip_end"
+	ColumnContinent = "continent"
+	ColumnCountry   = "country"
+	ColumnStateProv = "state_prov"
+	ColumnCity      = "city"
+	ColumnLatitude  = "latitude"
+	ColumnLongitude = "longitude"
+)
+
+var providerColumns = []string{
+	ColumnIpStart, ColumnIpEnd, ColumnContinent, ColumnCountry, ColumnStateProv, ColumnCity, ColumnLatitude,
+	ColumnLongitude,
+}
+
+// reservedProviders can't be used as the provider names, they name the db-ip datasets and tables or the overrides.
+var reservedProviders = []string{domain.SourceDbIp, domain.SourceOverride, "city", "asn", "template"}
+
+// ErrWrongSource is returned for the import sources that are neither http(s) urls nor absolute file paths.
+var ErrWrongSource = errors.New("source should be an http(s) url or an absolute file path")
+
+var (
+	errWrongProviderName    = errors.New("provider name should be lowercase letters, digits and underscores")
+	errReservedProvider     = errors.New("provider name is reserved")
+	errDuplicateProvider    = errors.New("duplicate provider")
+	errWrongProviderSource  = errors.New("provider should have either an http(s) url or an absolute file path")
+	errWrongProviderColumns = errors.New("provider columns should map ip_start, ip_end and a location field " +
+		"to positive csv column numbers")
+	errWrongPrecedence = errors.New("precedence should list the known providers of the location fields")
+
+	providerNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+	// the source is run by the database server as a shell command, so only the characters urls and paths need pass
+	providerUrlRegexp  = regexp.MustCompile(`^https?://[A-Za-z0-9._~:/?=&%+-]+$`)
+	providerFileRegexp = regexp.MustCompile(`^/[A-Za-z0-9._/-]+$`)
+)
+
+// Provider is a city dataset imported besides db-ip. The url may hold the %d year and %s month placeholders of the
+// release, files are read by the database server. Columns map the table columns to the 1-based csv column numbers.
+type Provider struct {
+	Name        string         `json:"name"`
+	Url         string         `json:"url,omitempty"`
+	File        string         `json:"file,omitempty"`
+	Header      bool           `json:"header,omitempty"`
+	Columns     map[string]int `json:"columns"`
+	Attribution string         `json:"attribution"`
+}
+
+// providersFile is the file the providers are configured in, precedence lists the providers a location field is
+// taken from, the first one having a value wins.
+type providersFile struct {
+	Providers  []*Provider         `json:"providers"`
+	Precedence map[string][]string `json:"precedence"`
+}
+
+func loadProviders(name string) (*providersFile, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read providers: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	// a misspelled key would silently leave a column unmapped
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+
+	f := &providersFile{}
+	if err = decoder.Decode(f); err != nil {
+		return nil, fmt.Errorf("failed to parse providers: %w", err)
+	}
+
+	return f, nil
+}
+
+func (p *Provider) validate() error {
+	if !providerNameRegexp.MatchString(p.Name) {
+		return errWrongProviderName
+	}
+	if slices.Contains(reservedProviders, p.Name) {
+		return errReservedProvider
+	}
+	if (p.Url == "") == (p.File == "") ||
+		p.Url != "" && !providerUrlRegexp.MatchString(p.Url) ||
+		p.File != "" && !providerFileRegexp.MatchString(p.File) {
+		return errWrongProviderSource
+	}
+
+	if p.Columns[ColumnIpStart] == 0 || p.Columns[ColumnIpEnd] == 0 {
+		return errWrongProviderColumns
+	}
+	locations := 0
+	for column, n := range p.Columns {
+		if !slices.Contains(providerColumns, column) || n <= 0 {
+			return errWrongProviderColumns
+		}
+		if column != ColumnIpStart && column != ColumnIpEnd {
+			locations++
+		}
+	}
+	if locations == 0 {
+		return errWrongProviderColumns
+	}
+
+	return nil
+}
+
+// DatasetSource returns the url or the file path of a dataset source, only the characters of urls and paths are let
+// through since the database server reads the source with a shell command.
+func DatasetSource(source string) (string, string, error) {
+	switch {
+	case providerUrlRegexp.MatchString(source):
+		return source, "", nil
+	case providerFileRegexp.MatchString(source):
+		return "", source, nil
+	default:
+		return "", "", ErrWrongSource
+	}
+}
+
+func validatePrecedence(precedence map[string][]string, providers []*Provider) error {
+	for field, names := range precedence {
+		if !slices.Contains(domain.LocationFields, field) || len(names) == 0 {
+			return fmt.Errorf("%w: %s", errWrongPrecedence, field)
+		}
+		for i, name := range names {
+			if name != domain.SourceDbIp && !slices.ContainsFunc(providers, func(p *Provider) bool {
+				return p.Name == name
+			}) || slices.Contains(names[:i], name) {
+				return fmt.Errorf("%w: %s: %s", errWrongPrecedence, field, name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,281 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func acmeProvider() *Provider {
+	return &Provider{
+		Name: "acme",
+		Url:  "https://feed.acme.example/geo-%d-%s.csv.gz",
+		Columns: map[string]int{
+			ColumnIpStart: 1,
+			ColumnIpEnd:   2,
+			ColumnCountry: 3,
+			ColumnCity:    5,
+		},
+		Attribution: "Geolocation data by Acme",
+	}
+}
+
+func TestProvider_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(p *Provider)
+		wantErr error
+	}{
+		{
+			name:   "valid url provider",
+			modify: func(*Provider) {},
+		},
+		{
+			name: "valid file provider",
+			modify: func(p *Provider) {
+				p.Url, p.File = "", "/var/lib/ip-info/acme.csv"
+			},
+		},
+		{
+			name: "uppercase name",
+			modify: func(p *Provider) {
+				p.Name = "Acme"
+			},
+			wantErr: errWrongProviderName,
+		},
+		{
+			name: "reserved name",
+			modify: func(p *Provider) {
+				p.Name = "dbip"
+			},
+			wantErr: errReservedProvider,
+		},
+		{
+			name: "both url and file",
+			modify: func(p *Provider) {
+				p.File = "/var/lib/ip-info/acme.csv"
+			},
+			wantErr: errWrongProviderSource,
+		},
+		{
+			name: "no source",
+			modify: func(p *Provider) {
+				p.Url = ""
+			},
+			wantErr: errWrongProviderSource,
+		},
+		{
+			name: "shell characters in url",
+			modify: func(p *Provider) {
+				p.Url = "https://feed.acme.example/geo.csv';rm -rf /'"
+			},
+			wantErr: errWrongProviderSource,
+		},
+		{
+			name: "relative file",
+			modify: func(p *Provider) {
+				p.Url, p.File = "", "acme.csv"
+			},
+			wantErr: errWrongProviderSource,
+		},
+		{
+			name: "no ip_end column",
+			modify: func(p *Provider) {
+				delete(p.Columns, ColumnIpEnd)
+			},
+			wantErr: errWrongProviderColumns,
+		},
+		{
+			name: "no location column",
+			modify: func(p *Provider) {
+				p.Columns = map[string]int{ColumnIpStart: 1, ColumnIpEnd: 2}
+			},
+			wantErr: errWrongProviderColumns,
+		},
+		{
+			name: "unknown column",
+			modify: func(p *Provider) {
+				p.Columns["zip"] = 7
+			},
+			wantErr: errWrongProviderColumns,
+		},
+		{
+			name: "zero column number",
+			modify: func(p *Provider) {
+				p.Columns[ColumnLatitude] = 0
+			},
+			wantErr: errWrongProviderColumns,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := acmeProvider()
+			tt.modify(p)
+			if err := p.validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validatePrecedence(t *testing.T) {
+	tests := []struct {
+		name       string
+		precedence map[string][]string
+		wantErr    error
+	}{
+		{
+			name:       "no precedence",
+			precedence: nil,
+		},
+		{
+			name: "city from acme, country from dbip",
+			precedence: map[string][]string{
+				"city":    {"acme", "dbip"},
+				"country": {"dbip", "acme"},
+			},
+		},
+		{
+			name:       "unknown field",
+			precedence: map[string][]string{"zip": {"acme"}},
+			wantErr:    errWrongPrecedence,
+		},
+		{
+			name:       "unknown provider",
+			precedence: map[string][]string{"city": {"other"}},
+			wantErr:    errWrongPrecedence,
+		},
+		{
+			name:       "duplicate provider",
+			precedence: map[string][]string{"city": {"acme", "acme"}},
+			wantErr:    errWrongPrecedence,
+		},
+		{
+			name:       "no providers",
+			precedence: map[string][]string{"city": {}},
+			wantErr:    errWrongPrecedence,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validatePrecedence(tt.precedence, []*Provider{acmeProvider()}); !errors.Is(err, tt.wantErr) {
+				t.Errorf("validatePrecedence() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDatabase_Precedence(t *testing.T) {
+	d := (&Database{}).SetProviders(
+		[]*Provider{acmeProvider(), {Name: "other"}},
+		map[string][]string{"country": {"dbip", "acme"}},
+	)
+
+	tests := []struct {
+		name  string
+		field string
+		want  []string
+	}{
+		{
+			name:  "configured precedence",
+			field: "country",
+			want:  []string{"dbip", "acme"},
+		},
+		{
+			name:  "providers in order followed by dbip",
+			field: "city",
+			want:  []string{"acme", "other", "dbip"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := d.Precedence(tt.field); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Precedence() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := (&Database{}).Precedence("city"); !reflect.DeepEqual(got, []string{"dbip"}) {
+		t.Errorf("Precedence() = %v, want %v", got, []string{"dbip"})
+	}
+}
+
+func TestDatabase_loadProviders(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "providers.json")
+	if err := os.WriteFile(name, []byte(`{
+		"providers": [{
+			"name": "acme",
+			"url": "https://feed.acme.example/geo-%d-%s.csv.gz",
+			"columns": {"ip_start": 1, "ip_end": 2, "country": 3, "city": 5},
+			"attribution": "Geolocation data by Acme"
+		}],
+		"precedence": {"country": ["dbip", "acme"]}
+	}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &Database{providersFile: name}
+	if err := d.loadProviders(); err != nil {
+		t.Fatalf("loadProviders() error = %v", err)
+	}
+	if !reflect.DeepEqual(d.Providers(), []*Provider{acmeProvider()}) {
+		t.Errorf("Providers() = %v, want %v", d.Providers(), []*Provider{acmeProvider()})
+	}
+	if got := d.Precedence("country"); !reflect.DeepEqual(got, []string{"dbip", "acme"}) {
+		t.Errorf("Precedence() = %v, want %v", got, []string{"dbip", "acme"})
+	}
+
+	d = &Database{providersFile: filepath.Join(t.TempDir(), "missing.json")}
+	if err := d.loadProviders(); err == nil {
+		t.Error("loadProviders() expected an error for a missing file")
+	}
+}
+
+func TestDatasetSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		wantUrl  string
+		wantFile string
+		wantErr  error
+	}{
+		{
+			name:    "url",
+			source:  "https://mirror.example/dbip-city-lite-2024-09.csv.gz",
+			wantUrl: "https://mirror.example/dbip-city-lite-2024-09.csv.gz",
+		},
+		{
+			name:     "file",
+			source:   "/var/lib/ip-info/dbip-city-lite-2024-09.csv",
+			wantFile: "/var/lib/ip-info/dbip-city-lite-2024-09.csv",
+		},
+		{
+			name:    "relative file",
+			source:  "dbip-city-lite-2024-09.csv",
+			wantErr: ErrWrongSource,
+		},
+		{
+			name:    "shell characters",
+			source:  "/tmp/city.csv; rm -rf /",
+			wantErr: ErrWrongSource,
+		},
+		{
+			name:    "quoted url",
+			source:  `https://mirror.example/city.csv"|sh`,
+			wantErr: ErrWrongSource,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUrl, gotFile, err := DatasetSource(tt.source)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DatasetSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotUrl != tt.wantUrl || gotFile != tt.wantFile {
+				t.Errorf("DatasetSource() = %q, %q, want %q, %q", gotUrl, gotFile, tt.wantUrl, tt.wantFile)
+			}
+		})
+	}
+}
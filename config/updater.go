@@ -0,0 +1,158 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/streamdp/ip-info/pkg/cron"
+)
+
+const (
+	updaterDefaultJitter          = 300
+	updaterDefaultRetryBackoff    = 60
+	updaterDefaultRetryMaxBackoff = 3600
+	updaterDefaultRetryAttempts   = 10
+	updaterDefaultPollInterval    = 60
+)
+
+var (
+	errWrongJitter        = errors.New("update jitter shouldn't be negative")
+	errWrongRetryBackoff  = errors.New("retry backoff should be positive number")
+	errWrongRetryMax      = errors.New("max retry backoff shouldn't be less than the retry backoff")
+	errWrongRetryAttempts = errors.New("retry attempts should be positive number")
+	errWrongPollInterval  = errors.New("config poll interval should be positive number")
+)
+
+type Updater struct {
+	schedule string
+	cron     *cron.Schedule
+	jitter   int
+
+	retryBackoff    int
+	retryMaxBackoff int
+	retryAttempts   int
+
+	disabled     bool
+	pollInterval int
+}
+
+func newUpdaterConfig() *Updater {
+	return &Updater{
+		jitter:          updaterDefaultJitter,
+		retryBackoff:    updaterDefaultRetryBackoff,
+		retryMaxBackoff: updaterDefaultRetryMaxBackoff,
+		retryAttempts:   updaterDefaultRetryAttempts,
+		pollInterval:    updaterDefaultPollInterval,
+	}
+}
+
+// Schedule returns the cron expression of the update runs, empty when the runs follow the monthly releases.
+func (u *Updater) Schedule() string {
+	return u.schedule
+}
+
+// Cron returns the parsed schedule, nil when the runs follow the monthly releases.
+func (u *Updater) Cron() *cron.Schedule {
+	return u.cron
+}
+
+func (u *Updater) SetSchedule(expr string) (*Updater, error) {
+	s, err := cron.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	u.schedule, u.cron = expr, s
+
+	return u, nil
+}
+
+// Jitter returns the upper bound of the random delay added to every scheduled run, so that the instances don't all
+// wake at the same moment.
+func (u *Updater) Jitter() time.Duration {
+	return time.Duration(u.jitter) * time.Second
+}
+
+// RetryBackoff returns the delay before the first retry of a failed update, it's doubled on every next attempt up to
+// the max retry backoff.
+func (u *Updater) RetryBackoff() time.Duration {
+	return time.Duration(u.retryBackoff) * time.Second
+}
+
+func (u *Updater) RetryMaxBackoff() time.Duration {
+	return time.Duration(u.retryMaxBackoff) * time.Second
+}
+
+// RetryAttempts returns the number of attempts after which a failing update waits for the next scheduled run.
+func (u *Updater) RetryAttempts() int {
+	return u.retryAttempts
+}
+
+func (u *Updater) SetRetry(backoff, maxBackoff time.Duration, attempts int) *Updater {
+	u.retryBackoff, u.retryMaxBackoff = int(backoff.Seconds()), int(maxBackoff.Seconds())
+	u.retryAttempts = attempts
+
+	return u
+}
+
+// Disabled reports whether the instance never imports releases and only reloads the ones activated by the others,
+// e.g. when its database is a read-only replica.
+func (u *Updater) Disabled() bool {
+	return u.disabled
+}
+
+func (u *Updater) SetDisabled(disabled bool) *Updater {
+	u.disabled = disabled
+
+	return u
+}
+
+// PollInterval returns how often an instance that doesn't import polls the config for the releases activated by
+// the others.
+func (u *Updater) PollInterval() time.Duration {
+	return time.Duration(u.pollInterval) * time.Second
+}
+
+func (u *Updater) SetPollInterval(interval time.Duration) *Updater {
+	u.pollInterval = int(interval.Seconds())
+
+	return u
+}
+
+func (u *Updater) loadEnvs() {
+	if !u.disabled {
+		u.disabled = strings.ToLower(os.Getenv("IP_INFO_DISABLE_UPDATES")) == "true"
+	}
+	if schedule := os.Getenv("IP_INFO_UPDATE_SCHEDULE"); u.schedule == "" && schedule != "" {
+		u.schedule = schedule
+	}
+}
+
+func (u *Updater) validate() error {
+	if u.schedule != "" {
+		s, err := cron.Parse(u.schedule)
+		if err != nil {
+			return fmt.Errorf("updater: %w", err)
+		}
+		u.cron = s
+	}
+	if u.jitter < 0 {
+		return fmt.Errorf("updater: %w", errWrongJitter)
+	}
+	if u.retryBackoff <= 0 {
+		return fmt.Errorf("updater: %w", errWrongRetryBackoff)
+	}
+	if u.retryMaxBackoff < u.retryBackoff {
+		return fmt.Errorf("updater: %w", errWrongRetryMax)
+	}
+	if u.retryAttempts <= 0 {
+		return fmt.Errorf("updater: %w", errWrongRetryAttempts)
+	}
+	if u.pollInterval <= 0 {
+		return fmt.Errorf("updater: %w", errWrongPollInterval)
+	}
+
+	return nil
+}
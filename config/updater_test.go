@@ -0,0 +1,109 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/streamdp/ip-info/pkg/cron"
+)
+
+func TestUpdater_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *Updater
+		wantErr error
+	}{
+		{
+			name: "default config",
+			cfg:  newUpdaterConfig(),
+		},
+		{
+			name: "daily schedule without jitter",
+			cfg: &Updater{
+				schedule:        "30 3 * * *",
+				retryBackoff:    10,
+				retryMaxBackoff: 10,
+				retryAttempts:   1,
+				pollInterval:    30,
+			},
+		},
+		{
+			name: "wrong schedule",
+			cfg: &Updater{
+				schedule:        "30 25 * * *",
+				retryBackoff:    10,
+				retryMaxBackoff: 10,
+				retryAttempts:   1,
+			},
+			wantErr: cron.ErrWrongExpression,
+		},
+		{
+			name: "negative jitter",
+			cfg: &Updater{
+				jitter:          -1,
+				retryBackoff:    10,
+				retryMaxBackoff: 10,
+				retryAttempts:   1,
+			},
+			wantErr: errWrongJitter,
+		},
+		{
+			name: "zero retry backoff",
+			cfg: &Updater{
+				retryMaxBackoff: 10,
+				retryAttempts:   1,
+			},
+			wantErr: errWrongRetryBackoff,
+		},
+		{
+			name: "max backoff less than the backoff",
+			cfg: &Updater{
+				retryBackoff:    60,
+				retryMaxBackoff: 10,
+				retryAttempts:   1,
+			},
+			wantErr: errWrongRetryMax,
+		},
+		{
+			name: "no retry attempts",
+			cfg: &Updater{
+				retryBackoff:    10,
+				retryMaxBackoff: 10,
+			},
+			wantErr: errWrongRetryAttempts,
+		},
+		{
+			name: "no poll interval",
+			cfg: &Updater{
+				retryBackoff:    10,
+				retryMaxBackoff: 10,
+				retryAttempts:   1,
+			},
+			wantErr: errWrongPollInterval,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (tt.cfg.Schedule() != "") != (tt.cfg.Cron() != nil) {
+				t.Errorf("Cron() = %v, want the parsed %q", tt.cfg.Cron(), tt.cfg.Schedule())
+			}
+		})
+	}
+}
+
+func TestUpdater_Durations(t *testing.T) {
+	u := newUpdaterConfig()
+	if got := u.Jitter(); got != 5*time.Minute {
+		t.Errorf("Jitter() = %v, want %v", got, 5*time.Minute)
+	}
+	if got := u.RetryBackoff(); got != time.Minute {
+		t.Errorf("RetryBackoff() = %v, want %v", got, time.Minute)
+	}
+	if got := u.RetryMaxBackoff(); got != time.Hour {
+		t.Errorf("RetryMaxBackoff() = %v, want %v", got, time.Hour)
+	}
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	webhookDefaultRetryAttempts = 5
+	webhookDefaultTimeout       = 5000
+)
+
+var (
+	errWrongWebhookUrl     = errors.New("webhook url should be an absolute http(s) url")
+	errWrongWebhookRetries = errors.New("webhook retry attempts should be positive number")
+	errWrongWebhookTimeout = errors.New("webhook timeout should be positive number")
+)
+
+// Webhook configures the urls notified of the update runs. The secret signs the payloads, it's only read from the
+// environment so that it doesn't show up in the process list.
+type Webhook struct {
+	urls          []string
+	secret        string
+	templateFile  string
+	template      string
+	retryAttempts int
+	timeout       int
+}
+
+func newWebhookConfig() *Webhook {
+	return &Webhook{
+		retryAttempts: webhookDefaultRetryAttempts,
+		timeout:       webhookDefaultTimeout,
+	}
+}
+
+func (w *Webhook) Urls() []string {
+	return w.urls
+}
+
+func (w *Webhook) SetUrls(urls ...string) *Webhook {
+	w.urls = urls
+
+	return w
+}
+
+// Secret returns the key of the HMAC signature of the payloads, empty when they aren't signed.
+func (w *Webhook) Secret() string {
+	return w.secret
+}
+
+func (w *Webhook) SetSecret(secret string) *Webhook {
+	w.secret = secret
+
+	return w
+}
+
+// Template returns the text/template the payloads are rendered with, empty for the json of the event.
+func (w *Webhook) Template() string {
+	return w.template
+}
+
+func (w *Webhook) SetTemplate(template string) *Webhook {
+	w.template = template
+
+	return w
+}
+
+// RetryAttempts returns the number of the deliveries of a payload before it's dropped.
+func (w *Webhook) RetryAttempts() int {
+	return w.retryAttempts
+}
+
+func (w *Webhook) SetRetryAttempts(attempts int) *Webhook {
+	w.retryAttempts = attempts
+
+	return w
+}
+
+func (w *Webhook) Timeout() time.Duration {
+	return time.Duration(w.timeout) * time.Millisecond
+}
+
+func (w *Webhook) loadEnvs() {
+	if webhookUrls := os.Getenv("IP_INFO_WEBHOOK_URLS"); len(w.urls) == 0 && webhookUrls != "" {
+		w.setUrls(webhookUrls)
+	}
+	w.secret = os.Getenv("IP_INFO_WEBHOOK_SECRET")
+	if w.templateFile == "" {
+		w.templateFile = os.Getenv("IP_INFO_WEBHOOK_TEMPLATE")
+	}
+}
+
+func (w *Webhook) setUrls(urls string) {
+	w.urls = nil
+	for _, u := range strings.Split(urls, ",") {
+		w.urls = append(w.urls, strings.TrimSpace(u))
+	}
+}
+
+// loadTemplate reads the payload template from the file set by the flag or the IP_INFO_WEBHOOK_TEMPLATE env.
+func (w *Webhook) loadTemplate() error {
+	if w.templateFile == "" {
+		return nil
+	}
+
+	b, err := os.ReadFile(w.templateFile)
+	if err != nil {
+		return fmt.Errorf("webhook: failed to read template: %w", err)
+	}
+	w.template = string(b)
+
+	return nil
+}
+
+func (w *Webhook) validate() error {
+	for i, webhookUrl := range w.urls {
+		u, err := url.Parse(webhookUrl)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook: url %d: %w", i, errWrongWebhookUrl)
+		}
+	}
+	if w.retryAttempts <= 0 {
+		return fmt.Errorf("webhook: %w", errWrongWebhookRetries)
+	}
+	if w.timeout <= 0 {
+		return fmt.Errorf("webhook: %w", errWrongWebhookTimeout)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWebhook_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *Webhook
+		wantErr error
+	}{
+		{
+			name: "no webhooks",
+			cfg:  newWebhookConfig(),
+		},
+		{
+			name: "http and https urls",
+			cfg:  newWebhookConfig().SetUrls("https://hooks.slack.com/services/T000/B000/XXXX", "http://alerts:8080/"),
+		},
+		{
+			name:    "url without scheme",
+			cfg:     newWebhookConfig().SetUrls("hooks.slack.com/services/T000/B000/XXXX"),
+			wantErr: errWrongWebhookUrl,
+		},
+		{
+			name:    "blank url",
+			cfg:     newWebhookConfig().SetUrls("https://alerts/", ""),
+			wantErr: errWrongWebhookUrl,
+		},
+		{
+			name:    "ftp url",
+			cfg:     newWebhookConfig().SetUrls("ftp://alerts/"),
+			wantErr: errWrongWebhookUrl,
+		},
+		{
+			name:    "no retry attempts",
+			cfg:     newWebhookConfig().SetRetryAttempts(0),
+			wantErr: errWrongWebhookRetries,
+		},
+		{
+			name:    "zero timeout",
+			cfg:     &Webhook{retryAttempts: 1},
+			wantErr: errWrongWebhookTimeout,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebhook_loadTemplate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "slack.tmpl")
+	template := `{"text": {{printf "ip-info %s" .Event | json}}}`
+	if err := os.WriteFile(file, []byte(template), 0o600); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	w := &Webhook{templateFile: file}
+	if err := w.loadTemplate(); err != nil {
+		t.Fatalf("loadTemplate() error = %v", err)
+	}
+	if w.Template() != template {
+		t.Errorf("Template() = %q, want %q", w.Template(), template)
+	}
+
+	w = &Webhook{templateFile: filepath.Join(t.TempDir(), "missing.tmpl")}
+	if err := w.loadTemplate(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("loadTemplate() error = %v, want %v", err, os.ErrNotExist)
+	}
+}
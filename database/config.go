@@ -2,12 +2,17 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"maps"
 	"time"
+
+	"github.com/streamdp/ip-info/domain"
 )
 
 type configDto struct {
+	Dataset     string    `db:"dataset"`
 	LastUpdate  time.Time `db:"last_update"`
 	ActiveTable string    `db:"active_table"`
 	BackupTable string    `db:"backup_table"`
@@ -15,73 +20,182 @@ type configDto struct {
 
 var errLoadConfig = errors.New("couldn't load config from database")
 
-func (d *db) loadConfig(ctx context.Context) (*configDto, error) {
-	dto := &configDto{}
+func (d *db) loadConfig(ctx context.Context) (map[string]*configDto, error) {
+	if err := d.registerProviders(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", errLoadConfig, err)
+	}
+
+	return d.readConfig(ctx)
+}
 
-	if err := d.QueryRowContext(ctx, "select last_update, active_table, backup_table from config;").Scan(
-		&dto.LastUpdate,
-		&dto.ActiveTable,
-		&dto.BackupTable,
-	); err != nil {
+// readConfig reads the active tables of every dataset without registering the new providers, so it works on a
+// read-only database as well.
+func (d *db) readConfig(ctx context.Context) (map[string]*configDto, error) {
+	rows, err := d.QueryContext(ctx, "select dataset, last_update, active_table, backup_table from config;")
+	if err != nil {
 		return nil, errLoadConfig
 	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			d.l.Println(errClose)
+		}
+	}()
+
+	cfg := make(map[string]*configDto, len(datasets)+len(d.providers))
+	for rows.Next() {
+		dto := &configDto{}
+		if err = rows.Scan(
+			&dto.Dataset,
+			&dto.LastUpdate,
+			&dto.ActiveTable,
+			&dto.BackupTable,
+		); err != nil {
+			return nil, errLoadConfig
+		}
+		cfg[dto.Dataset] = dto
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errLoadConfig
+	}
+
+	// providers not registered yet by the importing instance keep answering from the empty template
+	for _, ds := range datasets {
+		if _, ok := cfg[ds.name]; !ok {
+			return nil, fmt.Errorf("%w: no %s dataset", errLoadConfig, ds.name)
+		}
+	}
+
+	var generation uint64
+	if err = d.QueryRowContext(ctx, "select last_value from cache_generation;").Scan(&generation); err != nil {
+		return nil, fmt.Errorf("%w: %w", errLoadConfig, err)
+	}
 
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	d.dbIpCfg.LastUpdate = dto.LastUpdate
-	d.dbIpCfg.ActiveTable = dto.ActiveTable
-	d.dbIpCfg.BackupTable = dto.BackupTable
+	d.cacheGeneration = max(d.cacheGeneration, generation)
+
+	for name, dto := range cfg {
+		if prev, ok := d.dbIpCfg[name]; ok && prev.ActiveTable != dto.ActiveTable {
+			d.l.Printf("switching to %s table", dto.ActiveTable)
+		}
+		d.dbIpCfg[name] = &domain.DatabaseConfig{
+			LastUpdate:  dto.LastUpdate,
+			ActiveTable: dto.ActiveTable,
+			BackupTable: dto.BackupTable,
+		}
+	}
 
-	return dto, nil
+	return cfg, nil
 }
 
-func (d *db) updateConfig(ctx context.Context, activeTable, backupTable string) error {
-	d.l.Println("updating database config")
+// ReloadReleases picks up the releases activated by the instance that imports them and reports whether any active
+// table or the cache generation changed, the statements of the tables already active stay prepared, so polling it
+// is cheap.
+func (d *db) ReloadReleases(ctx context.Context) (bool, error) {
+	tables, generation := d.activeTables(), d.CacheGeneration()
+	if _, err := d.readConfig(ctx); err != nil {
+		return false, err
+	}
+	if err := d.prepareStatements(ctx); err != nil {
+		d.l.Printf("reload releases: %v", err)
+	}
 
-	_, err := d.ExecContext(ctx,
-		`update config set last_update=now() at time zone 'utc', active_table=$1, backup_table=$2;`,
-		activeTable,
-		backupTable,
-	)
-	if err != nil {
-		return fmt.Errorf("error updating config: %w", err)
+	return !maps.Equal(tables, d.activeTables()) || generation != d.CacheGeneration(), nil
+}
+
+// CacheGeneration returns the generation the cached lookups are keyed by, every published release and change of the
+// overrides moves all the instances to the next one.
+func (d *db) CacheGeneration() uint64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.cacheGeneration
+}
+
+// nextCacheGeneration moves the instances to the next cache generation once the transaction commits, the others
+// read it when they're notified.
+func nextCacheGeneration(ctx context.Context, tx *sql.Tx) (uint64, error) {
+	var generation uint64
+	if err := tx.QueryRowContext(ctx, "select nextval('cache_generation');").Scan(&generation); err != nil {
+		return 0, fmt.Errorf("error moving to the next cache generation: %w", err)
 	}
 
+	return generation, nil
+}
+
+// setCacheGeneration moves the instance to the cache generation it committed, without waiting for its notification.
+func (d *db) setCacheGeneration(generation uint64) {
 	d.mu.Lock()
-	d.dbIpCfg.LastUpdate = time.Now().UTC()
-	d.mu.Unlock()
+	defer d.mu.Unlock()
 
-	return nil
+	d.cacheGeneration = max(d.cacheGeneration, generation)
 }
 
-func (d *db) activeTable() string {
+// activeTable returns the active table of the dataset, empty for the datasets of providers no longer configured.
+func (d *db) activeTable(name string) string {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
-	return d.dbIpCfg.ActiveTable
+	if cfg, ok := d.dbIpCfg[name]; ok {
+		return cfg.ActiveTable
+	}
+
+	return ""
 }
 
-func (d *db) backupTable() string {
+// activeTables returns the active table of every dataset.
+func (d *db) activeTables() map[string]string {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
-	return d.dbIpCfg.BackupTable
+	tables := make(map[string]string, len(d.dbIpCfg))
+	for name, cfg := range d.dbIpCfg {
+		tables[name] = cfg.ActiveTable
+	}
+
+	return tables
 }
 
-func (d *db) lastUpdate() time.Time {
+func (d *db) lastUpdate(name string) time.Time {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
-	return d.dbIpCfg.LastUpdate
+	return d.dbIpCfg[name].LastUpdate
 }
 
-func (d *db) swapTables() (string, string) {
+// activeRelease returns the active city and asn tables together with the time the city table was imported, read
+// under the same lock so that lookups never mix a table with the data version of another release.
+func (d *db) activeRelease() (string, string, time.Time) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.dbIpCfg[cityDataset].ActiveTable, d.dbIpCfg[asnDataset].ActiveTable, d.dbIpCfg[cityDataset].LastUpdate
+}
+
+// activeProviderTables returns the active tables of the configured providers.
+func (d *db) activeProviderTables() []providerTable {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	tables := make([]providerTable, 0, len(d.providers))
+	for _, ds := range d.providers {
+		tables = append(tables, providerTable{provider: ds.provider, table: d.dbIpCfg[ds.name].ActiveTable})
+	}
+
+	return tables
+}
+
+// activateTable makes the imported release table active, the previously active one becomes the backup.
+func (d *db) activateTable(name, table string, lastUpdate time.Time) (string, string) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	d.l.Println("swapping working and backup tables")
-	d.dbIpCfg.ActiveTable, d.dbIpCfg.BackupTable = d.dbIpCfg.BackupTable, d.dbIpCfg.ActiveTable
+	d.l.Printf("activating %s table", table)
+
+	cfg := d.dbIpCfg[name]
+	cfg.ActiveTable, cfg.BackupTable = table, cfg.ActiveTable
+	cfg.LastUpdate = lastUpdate
 
-	return d.dbIpCfg.ActiveTable, d.dbIpCfg.BackupTable
+	return cfg.ActiveTable, cfg.BackupTable
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	_ "github.com/lib/pq"
@@ -17,26 +18,64 @@ type db struct {
 
 	cfg     *config.Database
 	l       *log.Logger
-	dbIpCfg *domain.DatabaseConfig
-	mu      sync.RWMutex
+	dbIpCfg map[string]*domain.DatabaseConfig
+	// cacheGeneration keys the cached lookups, it's shared by the instances through the cache_generation sequence
+	cacheGeneration uint64
+	mu              sync.RWMutex
+
+	providers []*dataset
+
+	replicas    []*replica
+	nextReplica atomic.Uint32
+
+	stmts  map[stmtKey]*preparedStmt
+	stmtMu sync.RWMutex
 }
 
 func Connect(l *log.Logger, cfg *config.Database) (*db, error) {
-	sqlDb, err := sql.Open("postgres", cfg.Url())
+	sqlDb, err := openPool(cfg.Url(), cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	return &db{
-		DB:  sqlDb,
-		cfg: cfg,
-		l:   l,
-		dbIpCfg: &domain.DatabaseConfig{
-			LastUpdate:  time.Now().UTC().Add(-31 * 24 * time.Hour),
-			ActiveTable: "ip_to_city_one",
-			BackupTable: "ip_to_city_two",
+	replicas := make([]*replica, 0, len(cfg.ReplicaUrls()))
+	for i, url := range cfg.ReplicaUrls() {
+		replicaDb, errOpen := openPool(url, cfg)
+		if errOpen != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", replicaName(i), errOpen)
+		}
+		replicas = append(replicas, &replica{DB: replicaDb, name: replicaName(i)})
+	}
+
+	d := &db{
+		DB:        sqlDb,
+		cfg:       cfg,
+		l:         l,
+		replicas:  replicas,
+		stmts:     map[stmtKey]*preparedStmt{},
+		providers: providerDatasets(cfg.Providers()),
+		dbIpCfg: map[string]*domain.DatabaseConfig{
+			cityDataset: {
+				LastUpdate:  time.Now().UTC().Add(-31 * 24 * time.Hour),
+				ActiveTable: "ip_to_city_template",
+				BackupTable: "ip_to_city_template",
+			},
+			asnDataset: {
+				LastUpdate:  time.Now().UTC().Add(-31 * 24 * time.Hour),
+				ActiveTable: "ip_to_asn_template",
+				BackupTable: "ip_to_asn_template",
+			},
 		},
-	}, nil
+	}
+	for _, ds := range d.providers {
+		d.dbIpCfg[ds.name] = &domain.DatabaseConfig{
+			LastUpdate:  time.Now().UTC().Add(-31 * 24 * time.Hour),
+			ActiveTable: ds.templateTable(),
+			BackupTable: ds.templateTable(),
+		}
+	}
+
+	return d, nil
 }
 
 func (d *db) Close() error {
@@ -44,6 +83,14 @@ func (d *db) Close() error {
 		return nil
 	}
 
+	d.closeStatements()
+
+	for _, r := range d.replicas {
+		if err := r.Close(); err != nil {
+			d.l.Printf("failed to close %s: %v", r.name, err)
+		}
+	}
+
 	if err := d.DB.Close(); err != nil {
 		return fmt.Errorf("failed to close database: %w", err)
 	}
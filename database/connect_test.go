@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/streamdp/ip-info/config"
+	"github.com/streamdp/ip-info/domain"
+)
+
+const databaseTestTimeout = 5000
+
+// testDb connects to the database from IP_INFO_TEST_DATABASE_URL with a schema of its own, which is dropped once the
+// test ends, the tests that need Postgres are skipped without it. Use a scratch database, the advisory lock of the
+// updates is taken by the tests.
+func testDb(t testing.TB) *db {
+	t.Helper()
+
+	dsn := os.Getenv("IP_INFO_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("IP_INFO_TEST_DATABASE_URL is not set")
+	}
+
+	adminDb, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	schema := fmt.Sprintf("ip_info_test_%d", time.Now().UnixNano())
+	if _, err = adminDb.ExecContext(t.Context(), "create schema "+schema+";"); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, errDrop := adminDb.ExecContext(context.Background(), "drop schema "+schema+" cascade;"); errDrop != nil {
+			t.Errorf("failed to drop schema: %v", errDrop)
+		}
+		_ = adminDb.Close()
+	})
+
+	sqlDb, err := sql.Open("postgres", withSearchPath(dsn, schema))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	d := &db{
+		DB:      sqlDb,
+		cfg:     (&config.Database{}).SetRequestTimeout(databaseTestTimeout),
+		l:       log.New(io.Discard, "", log.LstdFlags),
+		dbIpCfg: map[string]*domain.DatabaseConfig{},
+		stmts:   map[stmtKey]*preparedStmt{},
+	}
+	t.Cleanup(func() { _ = d.Close() })
+
+	return d
+}
+
+// withSearchPath sets the search path of the sessions in a url or a key=value connection string.
+func withSearchPath(dsn, schema string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || !strings.Contains(dsn, "://") {
+		return dsn + " search_path=" + schema
+	}
+
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/streamdp/ip-info/config"
+	"github.com/streamdp/ip-info/domain"
+)
+
+const (
+	cityDataset = "city"
+	asnDataset  = "asn"
+)
+
+// dataset is a database imported monthly by a provider, every release of it is imported into its own table created
+// from the template of its kind. The csv of a dataset without columns matches the table columns.
+type dataset struct {
+	name        string
+	kind        string
+	provider    string
+	downloadUrl string
+	file        string
+	header      bool
+	columns     map[string]int
+	attribution string
+}
+
+// datasets are the db-ip.com databases, the providers configured besides db-ip are city datasets named after them.
+var datasets = []*dataset{
+	{
+		name:        cityDataset,
+		kind:        cityDataset,
+		provider:    domain.SourceDbIp,
+		downloadUrl: "https://download.db-ip.com/free/dbip-city-lite-%d-%s.csv.gz",
+		attribution: "IP Geolocation by DB-IP (https://db-ip.com), licensed under CC BY 4.0",
+	},
+	{
+		name:        asnDataset,
+		kind:        asnDataset,
+		provider:    domain.SourceDbIp,
+		downloadUrl: "https://download.db-ip.com/free/dbip-asn-lite-%d-%s.csv.gz",
+		attribution: "IP Geolocation by DB-IP (https://db-ip.com), licensed under CC BY 4.0",
+	},
+}
+
+func providerDatasets(providers []*config.Provider) []*dataset {
+	dss := make([]*dataset, 0, len(providers))
+	for _, p := range providers {
+		dss = append(dss, &dataset{
+			name:        p.Name,
+			kind:        cityDataset,
+			provider:    p.Name,
+			downloadUrl: p.Url,
+			file:        p.File,
+			header:      p.Header,
+			columns:     p.Columns,
+			attribution: p.Attribution,
+		})
+	}
+
+	return dss
+}
+
+// templateTable is the empty table that release tables of the dataset are created from.
+func (ds *dataset) templateTable() string {
+	return fmt.Sprintf("ip_to_%s_template", ds.kind)
+}
+
+func (ds *dataset) releaseTable(t time.Time) string {
+	return fmt.Sprintf("ip_to_%s_%s", ds.name, t.UTC().Format("2006_01"))
+}
+
+// program returns the shell command the database server runs to write the csv of the release to stdout, the source
+// is quoted since the config only lets the characters of urls and paths through.
+func (ds *dataset) program(t time.Time) string {
+	cmd := fmt.Sprintf(`cat "%s"`, ds.file)
+	if ds.downloadUrl != "" {
+		cmd = fmt.Sprintf(`wget -qO- "%s"`, buildDownloadUrl(ds.downloadUrl, t))
+	}
+	if strings.HasSuffix(ds.downloadUrl, ".gz") || strings.HasSuffix(ds.file, ".gz") {
+		cmd += "|gzip -d"
+	}
+
+	return cmd
+}
+
+// csvColumns returns the highest csv column number the dataset maps.
+func (ds *dataset) csvColumns() int {
+	return slices.Max(slices.Collect(maps.Values(ds.columns)))
+}
+
+// mappedColumns returns the table columns the csv of a provider is mapped to and the expressions casting the staged
+// text columns to them.
+func (ds *dataset) mappedColumns() (string, string) {
+	columns := slices.Sorted(maps.Keys(ds.columns))
+	values := make([]string, 0, len(columns))
+	for _, column := range columns {
+		switch column {
+		case config.ColumnIpStart, config.ColumnIpEnd:
+			values = append(values, fmt.Sprintf("c%d::inet", ds.columns[column]))
+		case config.ColumnLatitude, config.ColumnLongitude:
+			values = append(values, fmt.Sprintf("nullif(c%d, '')::double precision", ds.columns[column]))
+		default:
+			values = append(values, fmt.Sprintf("nullif(c%d, '')", ds.columns[column]))
+		}
+	}
+
+	return strings.Join(columns, ", "), strings.Join(values, ", ")
+}
+
+func datasetByName(name string) *dataset {
+	for _, ds := range datasets {
+		if ds.name == name {
+			return ds
+		}
+	}
+
+	return nil
+}
+
+// allDatasets returns the db-ip datasets followed by the configured providers.
+func (d *db) allDatasets() []*dataset {
+	return append(slices.Clone(datasets), d.providers...)
+}
+
+// dataset returns the db-ip dataset or the provider with the name, nil when there is none.
+func (d *db) dataset(name string) *dataset {
+	for _, ds := range d.allDatasets() {
+		if ds.name == name {
+			return ds
+		}
+	}
+
+	return nil
+}
+
+// exportColumns returns the columns of the dataset tables in the order of the db-ip csv.
+func (ds *dataset) exportColumns() []string {
+	if ds.kind == asnDataset {
+		return []string{"ip_start", "ip_end", "as_number", "as_organization"}
+	}
+
+	return []string{"ip_start", "ip_end", "continent", "country", "state_prov", "city", "latitude", "longitude"}
+}
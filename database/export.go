@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+// ExportRelease writes the active release of the dataset as a csv in the db-ip format, so it can be imported back,
+// and returns the number of the written ranges. Missing values are written as null like the import expects them.
+func (d *db) ExportRelease(ctx context.Context, name string, w io.Writer) (int64, error) {
+	if _, err := d.readConfig(ctx); err != nil {
+		return 0, err
+	}
+
+	ds := d.dataset(name)
+	if ds == nil {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownDataset, name)
+	}
+
+	columns := ds.exportColumns()
+	rows, err := d.QueryContext(ctx, fmt.Sprintf("select %s from %s order by ip_start;",
+		strings.Join(columns, ", "),
+		d.activeTable(ds.name),
+	))
+	if err != nil {
+		return 0, fmt.Errorf("failed to export %s: %w", ds.name, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var (
+		cw     = csv.NewWriter(w)
+		values = make([]sql.NullString, len(columns))
+		dest   = make([]any, len(columns))
+		record = make([]string, len(columns))
+		n      int64
+	)
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err = rows.Scan(dest...); err != nil {
+			return n, fmt.Errorf("failed to export %s: %w", ds.name, err)
+		}
+		for i, v := range values {
+			record[i] = "null"
+			if v.Valid {
+				record[i] = v.String
+			}
+		}
+		if err = cw.Write(record); err != nil {
+			return n, fmt.Errorf("failed to export %s: %w", ds.name, err)
+		}
+		n++
+	}
+	cw.Flush()
+
+	if err = errors.Join(rows.Err(), cw.Error()); err != nil {
+		return n, fmt.Errorf("failed to export %s: %w", ds.name, err)
+	}
+
+	return n, nil
+}
+
+// ExportRanges passes the ranges of the release to fn in the order of their addresses, the missing values are left
+// empty. The table is resolved from the release, so the export matches the release it's versioned by even if
+// another one is activated meanwhile.
+func (d *db) ExportRanges(ctx context.Context, release *domain.Release, fn func(r *domain.IpRange) error) error {
+	ds := d.dataset(release.Dataset)
+	if ds == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownDataset, release.Dataset)
+	}
+
+	var table string
+	if err := d.QueryRowContext(ctx, "select table_name from releases where dataset=$1 and version=$2;",
+		ds.name,
+		release.Version,
+	).Scan(&table); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: no %s release %s", domain.ErrNoRelease, ds.name, release.Version)
+		}
+
+		return fmt.Errorf("failed to export %s: %w", ds.name, err)
+	}
+
+	rows, err := d.QueryContext(ctx, fmt.Sprintf("select %s from %s order by ip_start;",
+		strings.Join(ds.exportColumns(), ", "),
+		table,
+	))
+	if err != nil {
+		return fmt.Errorf("failed to export %s: %w", ds.name, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var (
+			ipStart, ipEnd                      string
+			continent, country, stateProv, city sql.NullString
+			latitude, longitude                 sql.NullFloat64
+			asNumber                            sql.NullInt64
+			asOrg                               sql.NullString
+		)
+		dest := []any{&ipStart, &ipEnd, &continent, &country, &stateProv, &city, &latitude, &longitude}
+		if ds.kind == asnDataset {
+			dest = []any{&ipStart, &ipEnd, &asNumber, &asOrg}
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return fmt.Errorf("failed to export %s: %w", ds.name, err)
+		}
+
+		if err = fn(&domain.IpRange{
+			IpStart:   parseAddr(ipStart),
+			IpEnd:     parseAddr(ipEnd),
+			Continent: continent.String,
+			Country:   country.String,
+			StateProv: stateProv.String,
+			City:      city.String,
+			Latitude:  latitude.Float64,
+			Longitude: longitude.Float64,
+			Asn:       uint32(asNumber.Int64),
+			AsOrg:     asOrg.String,
+		}); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to export %s: %w", ds.name, err)
+	}
+
+	return nil
+}
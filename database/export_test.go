@@ -0,0 +1,49 @@
+package database
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+func TestDb_ExportRanges(t *testing.T) {
+	d := testDb(t)
+	if err := d.MigrateUp(t.Context()); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+
+	if _, err := d.ExecContext(t.Context(), `
+		insert into ip_to_city_template (ip_start, ip_end, country, city)
+			values ('203.0.113.0', '203.0.113.255', 'AU', 'Sydney');
+		insert into releases (dataset, version, table_name, imported_at)
+			values ('city', '2024-09', 'ip_to_city_template', '2024-09-02T03:00:00Z');`,
+	); err != nil {
+		t.Fatalf("failed to insert the release: %v", err)
+	}
+
+	var ranges []*domain.IpRange
+	if err := d.ExportRanges(t.Context(), &domain.Release{Dataset: cityDataset, Version: "2024-09"},
+		func(r *domain.IpRange) error {
+			ranges = append(ranges, r)
+
+			return nil
+		},
+	); err != nil {
+		t.Fatalf("ExportRanges() error = %v", err)
+	}
+	if len(ranges) != 1 || ranges[0].IpStart != netip.MustParseAddr("203.0.113.0") || ranges[0].City != "Sydney" {
+		t.Errorf("ExportRanges() = %+v, want the range of the release", ranges)
+	}
+	if len(d.dbIpCfg) != 0 {
+		t.Errorf("ExportRanges() changed the active tables to %+v", d.dbIpCfg)
+	}
+
+	err := d.ExportRanges(t.Context(), &domain.Release{Dataset: cityDataset, Version: "2024-08"},
+		func(*domain.IpRange) error { return nil },
+	)
+	if !errors.Is(err, domain.ErrNoRelease) {
+		t.Errorf("ExportRanges() error = %v, want %v", err, domain.ErrNoRelease)
+	}
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/streamdp/ip-info/config"
+	"github.com/streamdp/ip-info/domain"
+)
+
+var ErrUnknownDataset = errors.New("unknown dataset")
+
+// ImportRelease imports the release of the current month of the dataset whether it's due or not, e.g. to seed a new
+// database or to recover from a failed update. The source replaces the usual one of the dataset, it's an http(s) url
+// or a file path read by the database server. The release is checked against the max release change like the
+// scheduled ones, and the release already active can't be imported again.
+func (d *db) ImportRelease(ctx context.Context, name, source string) (*domain.ImportedRelease, error) {
+	if _, err := d.loadConfig(ctx); err != nil {
+		return nil, err
+	}
+
+	ds := d.dataset(name)
+	if ds == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDataset, name)
+	}
+	if source != "" {
+		url, file, err := config.DatasetSource(source)
+		if err != nil {
+			return nil, err
+		}
+		imported := *ds
+		imported.downloadUrl, imported.file = url, file
+		ds = &imported
+	}
+
+	conn, err := d.acquireLock(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errReleaseLock := d.releaseLock(ctx, conn); errReleaseLock != nil {
+			d.l.Printf("import release: %v", errReleaseLock)
+		}
+	}()
+
+	release, err := d.updateDataset(ctx, ds)
+	if err != nil {
+		return nil, fmt.Errorf("%s dataset: %w", ds.name, err)
+	}
+
+	return release, nil
+}
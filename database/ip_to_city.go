@@ -5,72 +5,137 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"net"
+	"net/netip"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/streamdp/ip-info/domain"
 )
 
-const downloadUrl = "https://download.db-ip.com/free/dbip-city-lite-%d-%s.csv.gz"
-
 var (
 	ErrNoUpdateRequired = errors.New("no update required")
-	ErrNoIpAddress      = errors.New("no ip address in the database")
 	errDatabaseError    = errors.New("database error")
 )
 
 type ipToCityDto struct {
 	ipStart   string
 	ipEnd     string
-	Continent string  `db:"continent"`
-	Country   string  `db:"country"`
-	StateProv string  `db:"state_prov"`
-	City      string  `db:"city"`
-	Latitude  float64 `db:"latitude"`
-	Longitude float64 `db:"longitude"`
-	ipRange   string  `db:"ip_range"`
+	Continent string         `db:"continent"`
+	Country   string         `db:"country"`
+	StateProv string         `db:"state_prov"`
+	City      string         `db:"city"`
+	Latitude  float64        `db:"latitude"`
+	Longitude float64        `db:"longitude"`
+	ipRange   string         `db:"ip_range"`
+	AsNumber  sql.NullInt64  `db:"as_number"`
+	AsOrg     sql.NullString `db:"as_organization"`
+	override  overrideDto
+	providers map[string]*providerDto
+	// imported tells whether a range of the release covers the ip, the others are answered by an override alone
+	imported bool
 }
 
-func (d *db) importCsv(ctx context.Context, url string) error {
-	d.l.Println("import ip database updates")
+// importCsv imports the release into the table and returns the number of the imported rows.
+func (d *db) importCsv(ctx context.Context, ds *dataset, table string, t time.Time) (int64, error) {
+	d.l.Printf("import %s ip database updates into %s", ds.name, table)
 
-	_, err := d.ExecContext(ctx,
-		fmt.Sprintf("copy %s from program 'wget -qO- %s|gzip -d' csv null 'null' delimiter ',';",
-			d.backupTable(),
-			url,
+	if ds.columns != nil {
+		return d.importMappedCsv(ctx, ds, table, t)
+	}
+
+	res, err := d.ExecContext(ctx,
+		fmt.Sprintf("copy %s from program '%s' csv null 'null' delimiter ',';",
+			table,
+			ds.program(t),
 		))
 	if err != nil {
-		return fmt.Errorf("failed to import csv: %w", err)
+		return 0, fmt.Errorf("failed to import csv: %w", err)
 	}
 
-	return nil
+	return rowsAffected(res), nil
 }
 
-func (d *db) truncate(ctx context.Context) error {
-	backupTable := d.backupTable()
+// importMappedCsv copies the csv of a provider into a staging table of text columns and moves the mapped ones to the
+// release table, the staging table is dropped with the transaction.
+func (d *db) importMappedCsv(ctx context.Context, ds *dataset, table string, t time.Time) (int64, error) {
+	tx, err := d.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to import csv: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
 
-	d.l.Printf("truncate %s table before importing update", backupTable)
+	staging := make([]string, 0, ds.csvColumns())
+	for i := 1; i <= ds.csvColumns(); i++ {
+		staging = append(staging, fmt.Sprintf("c%d text", i))
+	}
 
-	_, err := d.ExecContext(ctx, fmt.Sprintf("truncate table %s;", backupTable))
+	header := ""
+	if ds.header {
+		header = " header"
+	}
+
+	columns, values := ds.mappedColumns()
+	res, err := tx.ExecContext(ctx, fmt.Sprintf(`create temp table import_staging (%s) on commit drop;
+		copy import_staging from program '%s' csv%s;
+		insert into %s (%s) select %s from import_staging;`,
+		strings.Join(staging, ", "),
+		ds.program(t),
+		header,
+		table,
+		columns,
+		values,
+	))
 	if err != nil {
-		return fmt.Errorf("failed to truncate table: %w", err)
+		return 0, fmt.Errorf("failed to import csv: %w", err)
 	}
 
-	return nil
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to import csv: %w", err)
+	}
+
+	return rowsAffected(res), nil
+}
+
+// rowsAffected returns the rows counted by the command tag of the statement, the last one of a batch.
+func rowsAffected(res sql.Result) int64 {
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0
+	}
+
+	return rows
 }
 
-func (d *db) createIndex(ctx context.Context) error {
-	backupTable := d.backupTable()
+// createTable creates an empty release table from the dataset template, a table left by a failed import of the same
+// release is dropped first.
+func (d *db) createTable(ctx context.Context, ds *dataset, table string) error {
+	if table == d.activeTable(ds.name) {
+		return fmt.Errorf("failed to create table: %s is active", table)
+	}
 
-	indexName := fmt.Sprintf("%s_ip_range_spgist_idx", backupTable)
+	d.l.Printf("creating %s table", table)
 
-	d.l.Printf("creating %s index on %s table", indexName, backupTable)
+	_, err := d.ExecContext(ctx, fmt.Sprintf("drop table if exists %[1]s; create table %[1]s (like %[2]s including all);",
+		table,
+		ds.templateTable(),
+	))
+	if err != nil {
+		return fmt.Errorf("failed to create table: %w", err)
+	}
+
+	return nil
+}
+
+func (d *db) createIndex(ctx context.Context, table string) error {
+	indexName := fmt.Sprintf("%s_ip_range_spgist_idx", table)
+
+	d.l.Printf("creating %s index on %s table", indexName, table)
 
 	_, err := d.ExecContext(ctx,
 		fmt.Sprintf(`create index if not exists %s on %s using spgist(ip_range);`,
 			indexName,
-			backupTable,
+			table,
 		))
 	if err != nil {
 		return fmt.Errorf("failed to create index: %w", err)
@@ -79,99 +144,314 @@ func (d *db) createIndex(ctx context.Context) error {
 	return nil
 }
 
-func (d *db) dropIndex(ctx context.Context) error {
-	indexName := fmt.Sprintf("%s_ip_range_spgist_idx", d.backupTable())
+func (d *db) IpInfo(ctx context.Context, ip netip.Addr) (*domain.IpInfo, error) {
+	cityTable, asnTable, updatedAt := d.activeRelease()
+	providerTables := d.activeProviderTables()
 
-	d.l.Println("droping ", indexName)
+	var dto *ipToCityDto
+	if err := d.read(ctx, func(ctx context.Context, pool *sql.DB) (err error) {
+		if dto, err = d.ipInfo(ctx, pool, ipInfoLookup(cityTable, asnTable, true), ip); err != nil {
+			return err
+		}
+		dto.providers, err = d.providerRanges(ctx, pool, providerTables, true, ip)
 
-	_, err := d.ExecContext(ctx, fmt.Sprintf("drop index if exists %s;", indexName))
-	if err != nil {
-		return fmt.Errorf("failed to drop index: %w", err)
+		return err
+	}); err != nil {
+		if errors.Is(err, domain.ErrNoIpAddress) {
+			return nil, domain.ErrNoIpAddress
+		}
+
+		return nil, errDatabaseError
 	}
 
-	return nil
+	return dto.ipInfo(ip, updatedAt, d.cfg.Precedence), nil
 }
 
-func (d *db) IpInfo(ctx context.Context, ip net.IP) (*domain.IpInfo, error) {
-	ctx, cancel := context.WithTimeout(ctx, d.cfg.RequestTimeout())
-	defer cancel()
+// ipInfo merges the location fields of the db-ip range and the ranges of the providers by the precedence of every
+// field, the network is the intersection of the db-ip range and the ranges the fields were taken from, and the asn
+// always comes from db-ip. The override of the network is applied last, it's the only source of the addresses no
+// db-ip range covers.
+func (dto *ipToCityDto) ipInfo(
+	ip netip.Addr, updatedAt time.Time, precedence func(field string) []string,
+) *domain.IpInfo {
+	ipInfo := &domain.IpInfo{
+		Ip:            ip,
+		DataVersion:   dataVersion(updatedAt),
+		DataUpdatedAt: updatedAt,
+		Source:        domain.SourceDbIp,
+		Sources:       domain.Sources{},
+		Asn:           uint32(dto.AsNumber.Int64),
+		AsOrg:         dto.AsOrg.String,
+	}
+
+	ranges := map[string]*providerDto{}
+	if dto.imported {
+		ipInfo.Network = rangeToNetwork(dto.ipStart, dto.ipEnd, dto.ipRange)
+		ranges[domain.SourceDbIp] = dto.dbIpRange()
+	}
+	for provider, r := range dto.providers {
+		ranges[provider] = r
+	}
+	for _, field := range domain.LocationFields {
+		for _, provider := range precedence(field) {
+			if r, ok := ranges[provider]; ok && r.fill(ipInfo, field) {
+				ipInfo.Sources[field] = provider
+
+				break
+			}
+		}
+	}
+	for _, provider := range ipInfo.Sources {
+		if r, ok := dto.providers[provider]; ok {
+			ipInfo.Network = r.narrow(ipInfo.Network)
+		}
+	}
+
+	if dto.override.Id.Valid {
+		dto.override.override().Apply(ipInfo)
+	}
+
+	return ipInfo
+}
+
+// dbIpRange returns the location of the db-ip range the way the providers' ones are read, empty fields are missing.
+func (dto *ipToCityDto) dbIpRange() *providerDto {
+	return &providerDto{
+		Continent: sql.NullString{String: dto.Continent, Valid: dto.Continent != ""},
+		Country:   sql.NullString{String: dto.Country, Valid: dto.Country != ""},
+		StateProv: sql.NullString{String: dto.StateProv, Valid: dto.StateProv != ""},
+		City:      sql.NullString{String: dto.City, Valid: dto.City != ""},
+		Latitude:  sql.NullFloat64{Float64: dto.Latitude, Valid: true},
+		Longitude: sql.NullFloat64{Float64: dto.Longitude, Valid: true},
+	}
+}
+
+func (d *db) ipInfo(ctx context.Context, pool *sql.DB, q lookupQuery, ip netip.Addr) (*ipToCityDto, error) {
+	row, err := d.queryRow(ctx, pool, q, ip.String())
+	if err != nil {
+		return nil, err
+	}
 
 	dto := &ipToCityDto{}
-	if err := d.QueryRowContext(ctx, fmt.Sprintf(
-		`select * from %s where ip_range::inet>>='%s';`,
-		d.activeTable(),
-		ip.String(),
-	)).Scan(
+	if err = row.Scan(
 		&dto.ipStart,
 		&dto.ipEnd,
 		&dto.Continent,
 		&dto.Country,
 		&dto.StateProv,
 		&dto.City,
-		&dto.Longitude,
 		&dto.Latitude,
+		&dto.Longitude,
 		&dto.ipRange,
+		&dto.imported,
+		&dto.AsNumber,
+		&dto.AsOrg,
+		&dto.override.Id,
+		&dto.override.Network,
+		&dto.override.Continent,
+		&dto.override.Country,
+		&dto.override.StateProv,
+		&dto.override.City,
+		&dto.override.Latitude,
+		&dto.override.Longitude,
 	); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, ErrNoIpAddress
+			return nil, domain.ErrNoIpAddress
 		}
 
+		return nil, fmt.Errorf("failed to look up ip: %w", err)
+	}
+
+	return dto, nil
+}
+
+func (d *db) NetworkInfo(
+	ctx context.Context, network netip.Prefix, after netip.Addr, limit int,
+) ([]*domain.IpRange, error) {
+	cityTable := d.activeTable(cityDataset)
+
+	var ranges []*domain.IpRange
+	if err := d.read(ctx, func(ctx context.Context, pool *sql.DB) (err error) {
+		ranges, err = d.networkInfo(ctx, pool, cityTable, network, after, limit)
+
+		return err
+	}); err != nil {
 		return nil, errDatabaseError
 	}
 
-	return &domain.IpInfo{
-		Ip:        ip,
-		Continent: dto.Continent,
-		Country:   dto.Country,
-		StateProv: dto.StateProv,
-		City:      dto.City,
-		Latitude:  dto.Latitude,
-		Longitude: dto.Longitude,
-	}, nil
+	return ranges, nil
 }
 
-func (d *db) UpdateIpDatabase(ctx context.Context) (time.Duration, error) {
+func (d *db) networkInfo(
+	ctx context.Context, pool *sql.DB, cityTable string, network netip.Prefix, after netip.Addr, limit int,
+) ([]*domain.IpRange, error) {
+	var afterIp any
+	if after.IsValid() {
+		afterIp = after.String()
+	}
+
+	rows, err := d.query(ctx, pool, networkInfoLookup(cityTable), network.String(), afterIp, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up network: %w", err)
+	}
+	defer func() {
+		if errClose := rows.Close(); errClose != nil {
+			d.l.Println(errClose)
+		}
+	}()
+
+	var ranges []*domain.IpRange
+	for rows.Next() {
+		dto := &ipToCityDto{}
+		if err = rows.Scan(
+			&dto.ipStart,
+			&dto.ipEnd,
+			&dto.Continent,
+			&dto.Country,
+			&dto.StateProv,
+			&dto.City,
+			&dto.Latitude,
+			&dto.Longitude,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan network range: %w", err)
+		}
+
+		ranges = append(ranges, &domain.IpRange{
+			IpStart:   parseAddr(dto.ipStart),
+			IpEnd:     parseAddr(dto.ipEnd),
+			Continent: dto.Continent,
+			Country:   dto.Country,
+			StateProv: dto.StateProv,
+			City:      dto.City,
+			Latitude:  dto.Latitude,
+			Longitude: dto.Longitude,
+		})
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read network ranges: %w", err)
+	}
+
+	return ranges, nil
+}
+
+// UpdateIpDatabase imports the releases of the outdated datasets and reports them along with the time left until the
+// next update. onStarted is called once the lock is held and the import begins, it may be nil. The run is skipped
+// with ErrNoUpdateRequired when no release is due, or with ErrLockAcquired while another instance imports them.
+func (d *db) UpdateIpDatabase(ctx context.Context, onStarted func()) (*domain.UpdateReport, error) {
 	cfg, err := d.loadConfig(ctx)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	now := time.Now().UTC()
-	if cfg.LastUpdate.Year() == now.Year() && cfg.LastUpdate.Month() == now.Month() {
-		return nextUpdateInterval(d.dbIpCfg.LastUpdate), ErrNoUpdateRequired
+
+	var outdated []*dataset
+	for _, ds := range d.allDatasets() {
+		lastUpdate := cfg[ds.name].LastUpdate
+		if lastUpdate.Year() == now.Year() && lastUpdate.Month() == now.Month() {
+			continue
+		}
+
+		rejected, errRejected := d.rejectedRelease(ctx, ds, dataVersion(now))
+		if errRejected != nil {
+			return nil, errRejected
+		}
+		if rejected {
+			d.l.Printf("%s release %s was rejected, raise the max release change to import it", ds.name,
+				dataVersion(now))
+
+			continue
+		}
+
+		outdated = append(outdated, ds)
+	}
+	if len(outdated) == 0 {
+		// rejected datasets keep the previous release, so the next update is counted from now
+		return &domain.UpdateReport{NextUpdate: nextUpdateInterval(now)}, ErrNoUpdateRequired
 	}
 
-	if err = d.acquireLock(ctx); err != nil {
-		return 0, err
+	conn, err := d.acquireLock(ctx, false)
+	if errors.Is(err, ErrLockAcquired) {
+		// the instance holding the lock imports the due releases, so the next update is counted from now
+		return &domain.UpdateReport{NextUpdate: nextUpdateInterval(now)}, ErrLockAcquired
+	}
+	if err != nil {
+		return nil, err
 	}
 	defer func() {
-		if errReleaseLock := d.releaseLock(ctx); errReleaseLock != nil {
+		if errReleaseLock := d.releaseLock(ctx, conn); errReleaseLock != nil {
 			d.l.Printf("update ip database: %v", errReleaseLock)
 		}
 	}()
 
-	if err = d.truncate(ctx); err != nil {
-		return 0, err
+	if onStarted != nil {
+		onStarted()
+	}
+
+	report := &domain.UpdateReport{}
+	for _, ds := range outdated {
+		release, errUpdate := d.updateDataset(ctx, ds)
+		if errUpdate != nil {
+			return nil, fmt.Errorf("%s dataset: %w", ds.name, errUpdate)
+		}
+		report.Releases = append(report.Releases, release)
+	}
+	report.NextUpdate = d.nextUpdateInterval()
+
+	return report, nil
+}
+
+func (d *db) updateDataset(ctx context.Context, ds *dataset) (*domain.ImportedRelease, error) {
+	updatedAt := time.Now().UTC()
+	table := ds.releaseTable(updatedAt)
+
+	if err := d.createTable(ctx, ds, table); err != nil {
+		return nil, err
 	}
-	if err = d.dropIndex(ctx); err != nil {
-		return 0, err
+	rows, err := d.importCsv(ctx, ds, table, updatedAt)
+	if err != nil {
+		return nil, err
 	}
-	if err = d.importCsv(ctx, buildDownloadUrl(time.Now().UTC())); err != nil {
-		return 0, err
+	if err = d.createIndex(ctx, table); err != nil {
+		return nil, err
 	}
-	if err = d.createIndex(ctx); err != nil {
-		return 0, err
+	if err = d.checkRelease(ctx, ds, table, updatedAt); err != nil {
+		return nil, err
 	}
 
-	activeTable, backupTable := d.swapTables()
-	if err = d.updateConfig(ctx, activeTable, backupTable); err != nil {
+	updatedAt = time.Now().UTC()
+	activeTable, backupTable := d.activateTable(ds.name, table, updatedAt)
+	if err = d.publishRelease(ctx, ds, activeTable, backupTable, updatedAt); err != nil {
+		d.l.Printf("update ip database: %v", err)
+	}
+	if err = d.prepareStatements(ctx); err != nil {
+		d.l.Printf("update ip database: %v", err)
+	}
+	if err = d.dropExpiredReleases(ctx, ds); err != nil {
 		d.l.Printf("update ip database: %v", err)
 	}
 
-	return nextUpdateInterval(d.dbIpCfg.LastUpdate), nil
+	return &domain.ImportedRelease{Dataset: ds.name, Version: dataVersion(updatedAt), Rows: rows}, nil
 }
 
-func buildDownloadUrl(t time.Time) string {
+// nextUpdateInterval returns the time left until the update of the dataset imported the longest time ago.
+func (d *db) nextUpdateInterval() time.Duration {
+	oldest := d.lastUpdate(datasets[0].name)
+	for _, ds := range d.allDatasets()[1:] {
+		if lastUpdate := d.lastUpdate(ds.name); lastUpdate.Before(oldest) {
+			oldest = lastUpdate
+		}
+	}
+
+	return nextUpdateInterval(oldest)
+}
+
+// buildDownloadUrl fills the year and month placeholders of the url, urls without them are the same every month.
+func buildDownloadUrl(format string, t time.Time) string {
+	if !strings.Contains(format, "%d") {
+		return format
+	}
+
 	year, month, _ := t.Date()
 
 	monthStr := strconv.Itoa(int(month))
@@ -179,7 +459,7 @@ func buildDownloadUrl(t time.Time) string {
 		monthStr = "0" + monthStr
 	}
 
-	return fmt.Sprintf(downloadUrl, year, monthStr)
+	return fmt.Sprintf(format, year, monthStr)
 }
 
 func nextUpdateInterval(t time.Time) time.Duration {
@@ -187,3 +467,41 @@ func nextUpdateInterval(t time.Time) time.Duration {
 
 	return time.Date(year, month+1, 2, 0, 0, -1, 0, time.UTC).Sub(time.Now().UTC())
 }
+
+// parseAddr parses the text form of a host inet value, the database only stores valid addresses.
+func parseAddr(s string) netip.Addr {
+	addr, _ := netip.ParseAddr(s)
+
+	return addr
+}
+
+func dataVersion(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// rangeToNetwork returns the CIDR of the matched range when it is exactly a prefix, otherwise "start-end".
+func rangeToNetwork(ipStart, ipEnd, ipRange string) string {
+	start, errStart := netip.ParseAddr(ipStart)
+	end, errEnd := netip.ParseAddr(ipEnd)
+	if errStart != nil || errEnd != nil {
+		return ipStart + "-" + ipEnd
+	}
+
+	var (
+		prefix netip.Prefix
+		err    error
+	)
+	if strings.Contains(ipRange, "/") {
+		prefix, err = netip.ParsePrefix(ipRange)
+	} else {
+		var addr netip.Addr
+		if addr, err = netip.ParseAddr(ipRange); err == nil {
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+	}
+	if err != nil || prefix.Masked().Addr() != start || domain.LastAddr(prefix) != end {
+		return start.String() + "-" + end.String()
+	}
+
+	return prefix.Masked().String()
+}
@@ -1,8 +1,13 @@
 package database
 
 import (
+	"errors"
+	"net/netip"
 	"testing"
 	"time"
+
+	"github.com/streamdp/ip-info/config"
+	"github.com/streamdp/ip-info/domain"
 )
 
 func Test_nextUpdateInterval(t *testing.T) {
@@ -43,26 +48,193 @@ func Test_nextUpdateInterval(t *testing.T) {
 
 func Test_buildDownloadUrl(t *testing.T) {
 	tests := []struct {
-		name string
-		t    time.Time
-		want string
+		name   string
+		format string
+		t      time.Time
+		want   string
 	}{
 		{
-			name: "get download url for September",
-			t:    time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
-			want: "https://download.db-ip.com/free/dbip-city-lite-2024-09.csv.gz",
+			name:   "get download url for September",
+			format: datasets[0].downloadUrl,
+			t:      time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
+			want:   "https://download.db-ip.com/free/dbip-city-lite-2024-09.csv.gz",
+		},
+		{
+			name:   "get download url for December",
+			format: datasets[0].downloadUrl,
+			t:      time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+			want:   "https://download.db-ip.com/free/dbip-city-lite-2024-12.csv.gz",
+		},
+		{
+			name:   "get asn download url",
+			format: datasets[1].downloadUrl,
+			t:      time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
+			want:   "https://download.db-ip.com/free/dbip-asn-lite-2024-09.csv.gz",
 		},
 		{
-			name: "get download url for December",
-			t:    time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
-			want: "https://download.db-ip.com/free/dbip-city-lite-2024-12.csv.gz",
+			name:   "url without placeholders",
+			format: "https://feed.acme.example/geo.csv",
+			t:      time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
+			want:   "https://feed.acme.example/geo.csv",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := buildDownloadUrl(tt.t); got != tt.want {
+			if got := buildDownloadUrl(tt.format, tt.t); got != tt.want {
 				t.Errorf("buildDownloadUrl() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func Test_rangeToNetwork(t *testing.T) {
+	tests := []struct {
+		name    string
+		ipStart string
+		ipEnd   string
+		ipRange string
+		want    string
+	}{
+		{
+			name:    "ipv4 range matching a prefix",
+			ipStart: "8.8.8.0",
+			ipEnd:   "8.8.8.255",
+			ipRange: "8.8.8.0/24",
+			want:    "8.8.8.0/24",
+		},
+		{
+			name:    "ipv4 range not matching a prefix",
+			ipStart: "8.8.8.0",
+			ipEnd:   "8.8.9.127",
+			ipRange: "8.8.8.0/23",
+			want:    "8.8.8.0-8.8.9.127",
+		},
+		{
+			name:    "single host range",
+			ipStart: "1.1.1.1",
+			ipEnd:   "1.1.1.1",
+			ipRange: "1.1.1.1",
+			want:    "1.1.1.1/32",
+		},
+		{
+			name:    "ipv6 range matching a prefix",
+			ipStart: "2001:db8::",
+			ipEnd:   "2001:db8:0:ffff:ffff:ffff:ffff:ffff",
+			ipRange: "2001:db8::/48",
+			want:    "2001:db8::/48",
+		},
+		{
+			name:    "ipv6 range with a prefix not on byte boundary",
+			ipStart: "2001:db8::",
+			ipEnd:   "2001:db8:7fff:ffff:ffff:ffff:ffff:ffff",
+			ipRange: "2001:db8::/33",
+			want:    "2001:db8::/33",
+		},
+		{
+			name:    "broken range",
+			ipStart: "8.8.8.0",
+			ipEnd:   "8.8.8.255",
+			ipRange: "",
+			want:    "8.8.8.0-8.8.8.255",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rangeToNetwork(tt.ipStart, tt.ipEnd, tt.ipRange); got != tt.want {
+				t.Errorf("rangeToNetwork() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_dataVersion(t *testing.T) {
+	if got := dataVersion(time.Date(2024, 9, 2, 10, 0, 0, 0, time.UTC)); got != "2024-09" {
+		t.Errorf("dataVersion() = %v, want %v", got, "2024-09")
+	}
+}
+
+func Test_releaseTable(t *testing.T) {
+	ds := datasetByName(asnDataset)
+
+	if got := ds.releaseTable(time.Date(2024, 9, 2, 10, 0, 0, 0, time.UTC)); got != "ip_to_asn_2024_09" {
+		t.Errorf("releaseTable() = %v, want %v", got, "ip_to_asn_2024_09")
+	}
+	if got := ds.templateTable(); got != "ip_to_asn_template" {
+		t.Errorf("templateTable() = %v, want %v", got, "ip_to_asn_template")
+	}
+}
+
+func TestDb_ipInfo(t *testing.T) {
+	d := testDb(t)
+	if err := d.MigrateUp(t.Context()); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+
+	// the merged range of the second rows, 10.0.0.0/22, contains the first ones too
+	if _, err := d.ExecContext(t.Context(), `
+		insert into ip_to_city_template (ip_start, ip_end, country, city) values
+			('10.0.0.0', '10.0.0.255', 'US', 'Springfield'), ('10.0.1.0', '10.0.2.255', 'US', 'Shelbyville');
+		insert into ip_to_asn_template (ip_start, ip_end, as_number, as_organization) values
+			('10.0.0.0', '10.0.0.255', 64500, 'Springfield ISP'), ('10.0.1.0', '10.0.2.255', 64501, 'Shelbyville ISP');
+		insert into overrides (network, country, city) values ('10.0.4.0/24', 'US', 'Capital City');`,
+	); err != nil {
+		t.Fatalf("failed to insert ranges: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		ip          string
+		wantCity    string
+		wantAsn     int64
+		wantNetwork string
+		wantErr     error
+	}{
+		{
+			name:        "range inside the merged range of another one",
+			ip:          "10.0.0.5",
+			wantCity:    "Springfield",
+			wantAsn:     64500,
+			wantNetwork: "10.0.0.0/24",
+		},
+		{
+			name:        "range with a broader merged range",
+			ip:          "10.0.2.5",
+			wantCity:    "Shelbyville",
+			wantAsn:     64501,
+			wantNetwork: "10.0.1.0-10.0.2.255",
+		},
+		{
+			name:    "gap in the merged range",
+			ip:      "10.0.3.5",
+			wantErr: domain.ErrNoIpAddress,
+		},
+		{
+			name:        "override of a network no range covers",
+			ip:          "10.0.4.5",
+			wantCity:    "Capital City",
+			wantNetwork: "10.0.4.0/24",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := ipInfoLookup("ip_to_city_template", "ip_to_asn_template", true)
+			dto, err := d.ipInfo(t.Context(), d.DB, q, netip.MustParseAddr(tt.ip))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ipInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			ipInfo := dto.ipInfo(netip.MustParseAddr(tt.ip), time.Now(), (&config.Database{}).Precedence)
+			if ipInfo.City != tt.wantCity {
+				t.Errorf("ipInfo() city = %v, want %v", ipInfo.City, tt.wantCity)
+			}
+			if int64(ipInfo.Asn) != tt.wantAsn {
+				t.Errorf("ipInfo() asn = %v, want %v", ipInfo.Asn, tt.wantAsn)
+			}
+			if ipInfo.Network != tt.wantNetwork {
+				t.Errorf("ipInfo() network = %v, want %v", ipInfo.Network, tt.wantNetwork)
+			}
+		})
+	}
+}
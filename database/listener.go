@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// swapChannel is notified with the dataset name whenever a release is published, and with overridesNotification
+// whenever the overrides change. The notification is delivered when the transaction making the change commits.
+const (
+	swapChannel           = "ip_info_swap"
+	overridesNotification = "overrides"
+)
+
+const (
+	listenerMinReconnect = time.Second
+	listenerMaxReconnect = time.Minute
+	// listenerPingInterval makes a dead connection noticed even when no notifications arrive
+	listenerPingInterval = 90 * time.Second
+)
+
+var errListenerClosed = errors.New("swap listener closed")
+
+// notificationSource is the connection the swaps are listened on. pq.Listener reconnects by itself and sends a nil
+// notification once the connection is re-established, since the notifications sent meanwhile are lost.
+type notificationSource interface {
+	Listen(channel string) error
+	NotificationChannel() <-chan *pq.Notification
+	Ping() error
+	Close() error
+}
+
+type swapListener struct {
+	src    notificationSource
+	reload func(ctx context.Context) (bool, error)
+	onSwap func(ctx context.Context) error
+	l      *log.Logger
+}
+
+// ListenSwaps reloads the active tables whenever any instance publishes a release or changes the overrides and then
+// calls onSwap, e.g. to drop the cached lookups, onSwap may be nil. The listener connects to the primary and
+// reconnects when the connection drops, it returns when the context is done.
+func (d *db) ListenSwaps(ctx context.Context, onSwap func(ctx context.Context) error) error {
+	src := pq.NewListener(d.cfg.Url(), listenerMinReconnect, listenerMaxReconnect,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				d.l.Printf("swap listener %s: %v", event, err)
+			}
+		},
+	)
+
+	return (&swapListener{
+		src:    src,
+		reload: d.ReloadReleases,
+		onSwap: onSwap,
+		l:      d.l,
+	}).run(ctx)
+}
+
+func (s *swapListener) run(ctx context.Context) error {
+	defer func() {
+		if err := s.src.Close(); err != nil {
+			s.l.Printf("failed to close swap listener: %v", err)
+		}
+	}()
+
+	// Listen blocks until the connection is established, closing the source on exit unblocks it
+	listened := make(chan error, 1)
+	go func() { listened <- s.src.Listen(swapChannel) }()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-listened:
+		if err != nil {
+			return fmt.Errorf("failed to listen %s: %w", swapChannel, err)
+		}
+	}
+
+	ping := time.NewTicker(listenerPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n, ok := <-s.src.NotificationChannel():
+			if !ok {
+				return errListenerClosed
+			}
+			switch {
+			case n == nil:
+				s.l.Println("swap listener reconnected, reloading releases")
+			case n.Extra == overridesNotification:
+				s.l.Println("overrides changed")
+			default:
+				s.l.Printf("%s release published", n.Extra)
+			}
+			s.swapped(ctx)
+		case <-ping.C:
+			go func() { _ = s.src.Ping() }()
+		}
+	}
+}
+
+// swapped reloads the active tables, the caches are only dropped once the lookups go to the new tables.
+func (s *swapListener) swapped(ctx context.Context) {
+	if _, err := s.reload(ctx); err != nil {
+		s.l.Printf("failed to reload releases: %v", err)
+
+		return
+	}
+	if s.onSwap == nil {
+		return
+	}
+	if err := s.onSwap(ctx); err != nil {
+		s.l.Printf("failed to handle releases swap: %v", err)
+	}
+}
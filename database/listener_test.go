@@ -0,0 +1,203 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var errCommon = errors.New("some_error")
+
+type fakeNotificationSource struct {
+	notifications chan *pq.Notification
+	listenErr     error
+	// unblock holds Listen until the source is closed, like a listener that can't connect
+	unblock chan struct{}
+
+	channels []string
+	closed   bool
+}
+
+func (f *fakeNotificationSource) Listen(channel string) error {
+	if f.unblock != nil {
+		<-f.unblock
+
+		return errListenerClosed
+	}
+	f.channels = append(f.channels, channel)
+
+	return f.listenErr
+}
+
+func (f *fakeNotificationSource) NotificationChannel() <-chan *pq.Notification {
+	return f.notifications
+}
+
+func (f *fakeNotificationSource) Ping() error {
+	return nil
+}
+
+func (f *fakeNotificationSource) Close() error {
+	f.closed = true
+	if f.unblock != nil {
+		close(f.unblock)
+	}
+
+	return nil
+}
+
+// newFakeNotificationSource returns a source delivering the notifications and closing its channel after them.
+func newFakeNotificationSource(notifications ...*pq.Notification) *fakeNotificationSource {
+	f := &fakeNotificationSource{notifications: make(chan *pq.Notification, len(notifications))}
+	for _, n := range notifications {
+		f.notifications <- n
+	}
+	close(f.notifications)
+
+	return f
+}
+
+func TestSwapListener_run(t *testing.T) {
+	tests := []struct {
+		name          string
+		notifications []*pq.Notification
+		reloadErr     error
+		swapErr       error
+		wantReloads   int
+		wantSwaps     int
+	}{
+		{
+			name: "reload on every published release",
+			notifications: []*pq.Notification{
+				{Channel: swapChannel, Extra: cityDataset},
+				{Channel: swapChannel, Extra: asnDataset},
+			},
+			wantReloads: 2,
+			wantSwaps:   2,
+		},
+		{
+			name: "overrides change drops the caches",
+			notifications: []*pq.Notification{
+				{Channel: swapChannel, Extra: overridesNotification},
+			},
+			wantReloads: 1,
+			wantSwaps:   1,
+		},
+		{
+			name:          "reload after reconnecting",
+			notifications: []*pq.Notification{nil},
+			wantReloads:   1,
+			wantSwaps:     1,
+		},
+		{
+			name:          "caches are kept when the reload fails",
+			notifications: []*pq.Notification{{Channel: swapChannel, Extra: cityDataset}},
+			reloadErr:     errCommon,
+			wantReloads:   1,
+			wantSwaps:     0,
+		},
+		{
+			name: "failed swap handling doesn't stop the listener",
+			notifications: []*pq.Notification{
+				{Channel: swapChannel, Extra: cityDataset},
+				{Channel: swapChannel, Extra: cityDataset},
+			},
+			swapErr:     errCommon,
+			wantReloads: 2,
+			wantSwaps:   2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reloads, swaps int
+
+			src := newFakeNotificationSource(tt.notifications...)
+			s := &swapListener{
+				src: src,
+				reload: func(context.Context) (bool, error) {
+					reloads++
+
+					return true, tt.reloadErr
+				},
+				onSwap: func(context.Context) error {
+					swaps++
+
+					return tt.swapErr
+				},
+				l: log.New(io.Discard, "", log.LstdFlags),
+			}
+
+			if err := s.run(context.Background()); !errors.Is(err, errListenerClosed) {
+				t.Errorf("run() error = %v, want %v", err, errListenerClosed)
+			}
+			if len(src.channels) != 1 || src.channels[0] != swapChannel {
+				t.Errorf("run() listened %v, want %s", src.channels, swapChannel)
+			}
+			if reloads != tt.wantReloads || swaps != tt.wantSwaps {
+				t.Errorf("run() reloads = %d, swaps = %d, want %d and %d", reloads, swaps, tt.wantReloads,
+					tt.wantSwaps)
+			}
+			if !src.closed {
+				t.Errorf("run() didn't close the source")
+			}
+		})
+	}
+}
+
+func TestSwapListener_runWithoutSwapHandler(t *testing.T) {
+	reloads := 0
+	s := &swapListener{
+		src: newFakeNotificationSource(&pq.Notification{Channel: swapChannel, Extra: cityDataset}),
+		reload: func(context.Context) (bool, error) {
+			reloads++
+
+			return true, nil
+		},
+		l: log.New(io.Discard, "", log.LstdFlags),
+	}
+
+	if err := s.run(context.Background()); !errors.Is(err, errListenerClosed) {
+		t.Errorf("run() error = %v, want %v", err, errListenerClosed)
+	}
+	if reloads != 1 {
+		t.Errorf("run() reloads = %d, want 1", reloads)
+	}
+}
+
+func TestSwapListener_runListenError(t *testing.T) {
+	src := newFakeNotificationSource()
+	src.listenErr = errCommon
+
+	s := &swapListener{src: src, l: log.New(io.Discard, "", log.LstdFlags)}
+	if err := s.run(context.Background()); !errors.Is(err, errCommon) {
+		t.Errorf("run() error = %v, want %v", err, errCommon)
+	}
+	if !src.closed {
+		t.Errorf("run() didn't close the source")
+	}
+}
+
+func TestSwapListener_runCanceled(t *testing.T) {
+	src := &fakeNotificationSource{notifications: make(chan *pq.Notification), unblock: make(chan struct{})}
+	s := &swapListener{src: src, l: log.New(io.Discard, "", log.LstdFlags)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.run(ctx) }()
+
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("run() error = %v, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("run() didn't return while connecting after the context was canceled")
+	}
+}
@@ -2,35 +2,60 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 )
 
-var errLockAcquired = errors.New("lock already acquired")
+// ErrLockAcquired is returned when another instance holds the lock, e.g. while it imports the same releases.
+var ErrLockAcquired = errors.New("lock already acquired")
 
 const advisoryLockID = 147881257 // Unique ID for the ip-info update process lock
 
-func (d *db) acquireLock(ctx context.Context) error {
+// acquireLock takes the advisory lock on a dedicated connection, since a session lock can only be released by the
+// session holding it. With wait it blocks until the lock is free or the context is done.
+func (d *db) acquireLock(ctx context.Context, wait bool) (*sql.Conn, error) {
 	d.l.Println("acquiring advisory lock")
 
-	var acquired bool
-	err := d.QueryRowContext(ctx, "select pg_try_advisory_lock($1);", advisoryLockID).Scan(&acquired)
+	conn, err := d.Conn(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to acquire advisory lock: %w", err)
+		return nil, fmt.Errorf("failed to acquire advisory lock: %w", err)
 	}
 
-	if !acquired {
-		return errLockAcquired
+	acquired := true
+	if wait {
+		_, err = conn.ExecContext(ctx, "select pg_advisory_lock($1);", advisoryLockID)
+	} else {
+		err = conn.QueryRowContext(ctx, "select pg_try_advisory_lock($1);", advisoryLockID).Scan(&acquired)
+	}
+	if err != nil || !acquired {
+		if errClose := conn.Close(); errClose != nil {
+			d.l.Println(errClose)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire advisory lock: %w", err)
+		}
+
+		return nil, ErrLockAcquired
 	}
 
-	return nil
+	return conn, nil
 }
 
-func (d *db) releaseLock(ctx context.Context) error {
+func (d *db) releaseLock(ctx context.Context, conn *sql.Conn) error {
 	d.l.Println("releasing advisory lock")
 
-	_, err := d.ExecContext(ctx, "select pg_advisory_unlock($1);", advisoryLockID)
+	_, err := conn.ExecContext(ctx, "select pg_advisory_unlock($1);", advisoryLockID)
 	if err != nil {
+		// drop the connection instead of returning it to the pool, closing the session releases the lock
+		_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		_ = conn.Close()
+
+		return fmt.Errorf("failed to release advisory lock: %w", err)
+	}
+
+	if err = conn.Close(); err != nil {
 		return fmt.Errorf("failed to release advisory lock: %w", err)
 	}
 
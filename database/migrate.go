@@ -0,0 +1,372 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var (
+	ErrSchemaOutdated     = errors.New("database schema is outdated")
+	ErrSchemaIncompatible = errors.New("database schema is incompatible")
+	errNoMigration        = errors.New("no migration to roll back")
+	errWrongMigration     = errors.New("wrong migration file")
+)
+
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+var migrations = mustLoadMigrations(migrationFiles)
+
+// loadMigrations reads the <version>_<name>.up.sql and <version>_<name>.down.sql pairs sorted by version.
+func loadMigrations(fsys fs.FS) ([]*migration, error) {
+	files, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*migration{}
+	for _, file := range files {
+		base := path.Base(file)
+
+		stem, direction, ok := strings.Cut(strings.TrimSuffix(base, ".sql"), ".")
+		if !ok || direction != "up" && direction != "down" {
+			return nil, fmt.Errorf("%w: %s", errWrongMigration, base)
+		}
+		versionStr, name, ok := strings.Cut(stem, "_")
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", errWrongMigration, base)
+		}
+		version, errAtoi := strconv.Atoi(versionStr)
+		if errAtoi != nil || version <= 0 {
+			return nil, fmt.Errorf("%w: %s", errWrongMigration, base)
+		}
+
+		body, errRead := fs.ReadFile(fsys, file)
+		if errRead != nil {
+			return nil, errRead
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: name}
+			byVersion[version] = m
+		}
+		if m.name != name {
+			return nil, fmt.Errorf("%w: %s: version %d is already used by %s", errWrongMigration, base, version, m.name)
+		}
+		if direction == "up" {
+			m.up = string(body)
+		} else {
+			m.down = string(body)
+		}
+	}
+
+	var list []*migration
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("%w: %04d_%s needs both up and down files", errWrongMigration, m.version, m.name)
+		}
+		list = append(list, m)
+	}
+	slices.SortFunc(list, func(a, b *migration) int { return a.version - b.version })
+
+	for i, m := range list {
+		if m.version != i+1 {
+			return nil, fmt.Errorf("%w: missing version %d", errWrongMigration, i+1)
+		}
+	}
+
+	return list, nil
+}
+
+func mustLoadMigrations(fsys fs.FS) []*migration {
+	list, err := loadMigrations(fsys)
+	if err != nil {
+		panic(err)
+	}
+
+	return list
+}
+
+func latestVersion() int {
+	return len(migrations)
+}
+
+const createSchemaMigrations = `create table if not exists schema_migrations (
+  version    integer primary key,
+  name       text not null,
+  applied_at timestamptz not null default now()
+);`
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// appliedMigrations returns the applied versions with the time they were applied, a database without the
+// schema_migrations table has none.
+func appliedMigrations(ctx context.Context, q querier) (map[int]time.Time, error) {
+	var exists bool
+	if err := q.QueryRowContext(ctx,
+		"select to_regclass('schema_migrations') is not null;",
+	).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	applied := map[int]time.Time{}
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := q.QueryContext(ctx, "select version, applied_at from schema_migrations;")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read schema version: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	return applied, nil
+}
+
+func schemaVersion(applied map[int]time.Time) int {
+	version := 0
+	for v := range applied {
+		version = max(version, v)
+	}
+
+	return version
+}
+
+// CheckSchema fails when the schema version doesn't match the migrations of this build, an outdated schema is
+// migrated first when autoMigrate is set.
+func (d *db) CheckSchema(ctx context.Context, autoMigrate bool) error {
+	if autoMigrate {
+		if err := d.MigrateUp(ctx); err != nil {
+			return err
+		}
+	}
+
+	applied, err := appliedMigrations(ctx, d)
+	if err != nil {
+		return err
+	}
+
+	return checkVersion(schemaVersion(applied))
+}
+
+func checkVersion(version int) error {
+	switch {
+	case version < latestVersion():
+		return fmt.Errorf("%w: version %d, want %d, run \"ip-info migrate up\" or start with -auto-migrate",
+			ErrSchemaOutdated, version, latestVersion())
+	case version > latestVersion():
+		return fmt.Errorf("%w: version %d is newer than %d supported by this build",
+			ErrSchemaIncompatible, version, latestVersion())
+	}
+
+	return nil
+}
+
+// MigrateUp applies the pending migrations under the advisory lock, so that instances starting together and the
+// updater never change the schema at the same time.
+func (d *db) MigrateUp(ctx context.Context) error {
+	conn, err := d.acquireLock(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if errReleaseLock := d.releaseLock(context.WithoutCancel(ctx), conn); errReleaseLock != nil {
+			d.l.Printf("migrate up: %v", errReleaseLock)
+		}
+	}()
+
+	if _, err = conn.ExecContext(ctx, createSchemaMigrations); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	if err = d.baseline(ctx, conn); err != nil {
+		return err
+	}
+
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if err = checkVersion(schemaVersion(applied)); errors.Is(err, ErrSchemaIncompatible) {
+		return err
+	}
+
+	for _, m := range migrations {
+		if _, ok := applied[m.version]; ok {
+			continue
+		}
+
+		d.l.Printf("applying migration %04d_%s", m.version, m.name)
+		if err = runMigration(ctx, conn, m.up,
+			"insert into schema_migrations (version, name) values ($1, $2);", m.version, m.name,
+		); err != nil {
+			return fmt.Errorf("migration %04d_%s: %w", m.version, m.name, err)
+		}
+	}
+
+	return nil
+}
+
+// MigrateDown rolls back the latest applied migration.
+func (d *db) MigrateDown(ctx context.Context) error {
+	conn, err := d.acquireLock(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if errReleaseLock := d.releaseLock(context.WithoutCancel(ctx), conn); errReleaseLock != nil {
+			d.l.Printf("migrate down: %v", errReleaseLock)
+		}
+	}()
+
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	version := schemaVersion(applied)
+	if version == 0 {
+		return errNoMigration
+	}
+	if version > latestVersion() {
+		return checkVersion(version)
+	}
+
+	m := migrations[version-1]
+	d.l.Printf("rolling back migration %04d_%s", m.version, m.name)
+	if err = runMigration(ctx, conn, m.down,
+		"delete from schema_migrations where version=$1;", m.version,
+	); err != nil {
+		return fmt.Errorf("migration %04d_%s: %w", m.version, m.name, err)
+	}
+
+	return nil
+}
+
+// MigrationStatus lists the migrations of this build with the time they were applied.
+func (d *db) MigrationStatus(ctx context.Context) ([]*domain.Migration, error) {
+	applied, err := appliedMigrations(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]*domain.Migration, 0, len(migrations))
+	for _, m := range migrations {
+		status = append(status, &domain.Migration{Version: m.version, Name: m.name, AppliedAt: applied[m.version]})
+	}
+
+	return status, nil
+}
+
+// runMigration runs the migration script and records it in schema_migrations within one transaction.
+func runMigration(ctx context.Context, conn *sql.Conn, script, record string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err = tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// baseline records the migrations the init.sql script already created as applied for databases created before the
+// migrations were introduced: the city tables of 0001_init since release v1.0.0, and the asn tables with the
+// per-dataset config of 0002_asn once the script had them. The pending migrations then upgrade the schema in place.
+// Schemas from before release v1.0.0, without the generated ip_range column, can't be upgraded.
+func (d *db) baseline(ctx context.Context, conn *sql.Conn) error {
+	var baseline, ipRange, asn bool
+	if err := conn.QueryRowContext(ctx, `select
+		not exists (select 1 from schema_migrations) and to_regclass('config') is not null,
+		exists (select 1 from information_schema.columns
+			where table_schema = current_schema() and table_name = 'ip_to_city_one' and column_name = 'ip_range'),
+		exists (select 1 from information_schema.columns
+			where table_schema = current_schema() and table_name = 'config' and column_name = 'dataset');`,
+	).Scan(&baseline, &ipRange, &asn); err != nil {
+		return fmt.Errorf("failed to check the schema: %w", err)
+	}
+	if !baseline {
+		return nil
+	}
+	if !ipRange {
+		return fmt.Errorf("%w: the city tables predate release v1.0.0, re-create the database",
+			ErrSchemaIncompatible)
+	}
+	if !asn {
+		return d.recordBaseline(ctx, conn, 1)
+	}
+
+	return d.recordBaseline(ctx, conn, 2)
+}
+
+// recordBaseline records the migrations up to the version as applied within one transaction.
+func (d *db) recordBaseline(ctx context.Context, conn *sql.Conn, version int) error {
+	d.l.Printf("recording migrations up to %04d_%s as applied to the existing schema", migrations[version-1].version,
+		migrations[version-1].name)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, m := range migrations[:version] {
+		if _, err = tx.ExecContext(ctx, "insert into schema_migrations (version, name) values ($1, $2);",
+			m.version, m.name,
+		); err != nil {
+			return fmt.Errorf("failed to record the baseline migration %04d_%s: %w", m.version, m.name, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,244 @@
+package database
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func Test_loadMigrations(t *testing.T) {
+	file := func(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+
+	tests := []struct {
+		name         string
+		fsys         fstest.MapFS
+		wantVersions []int
+		wantErr      error
+	}{
+		{
+			name: "sorted by version",
+			fsys: fstest.MapFS{
+				"migrations/0002_history.up.sql":   file("create table history();"),
+				"migrations/0002_history.down.sql": file("drop table history;"),
+				"migrations/0001_init.up.sql":      file("create table config();"),
+				"migrations/0001_init.down.sql":    file("drop table config;"),
+			},
+			wantVersions: []int{1, 2},
+		},
+		{
+			name: "missing down file",
+			fsys: fstest.MapFS{
+				"migrations/0001_init.up.sql": file("create table config();"),
+			},
+			wantErr: errWrongMigration,
+		},
+		{
+			name: "gap between versions",
+			fsys: fstest.MapFS{
+				"migrations/0001_init.up.sql":      file("create table config();"),
+				"migrations/0001_init.down.sql":    file("drop table config;"),
+				"migrations/0003_history.up.sql":   file("create table history();"),
+				"migrations/0003_history.down.sql": file("drop table history;"),
+			},
+			wantErr: errWrongMigration,
+		},
+		{
+			name: "version used twice",
+			fsys: fstest.MapFS{
+				"migrations/0001_init.up.sql":     file("create table config();"),
+				"migrations/0001_init.down.sql":   file("drop table config;"),
+				"migrations/0001_config.up.sql":   file("create table config();"),
+				"migrations/0001_config.down.sql": file("drop table config;"),
+			},
+			wantErr: errWrongMigration,
+		},
+		{
+			name: "wrong file name",
+			fsys: fstest.MapFS{
+				"migrations/init.sql": file("create table config();"),
+			},
+			wantErr: errWrongMigration,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadMigrations(tt.fsys)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("loadMigrations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.wantVersions) {
+				t.Fatalf("loadMigrations() = %d migrations, want %d", len(got), len(tt.wantVersions))
+			}
+			for i, m := range got {
+				if m.version != tt.wantVersions[i] {
+					t.Errorf("loadMigrations()[%d] version = %d, want %d", i, m.version, tt.wantVersions[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_embeddedMigrations(t *testing.T) {
+	if latestVersion() == 0 || migrations[0].name != "init" {
+		t.Fatalf("embedded migrations = %v, want 0001_init first", migrations)
+	}
+}
+
+func Test_checkVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		wantErr error
+	}{
+		{
+			name:    "empty database",
+			version: 0,
+			wantErr: ErrSchemaOutdated,
+		},
+		{
+			name:    "up to date",
+			version: latestVersion(),
+		},
+		{
+			name:    "newer than the build",
+			version: latestVersion() + 1,
+			wantErr: ErrSchemaIncompatible,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkVersion(tt.version); !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// legacyCitySchema is the init.sql script of the releases before v1.0.0, without the generated ip_range column.
+const legacyCitySchema = `create table ip_to_city_one (
+  ip_start inet unique, ip_end inet unique, continent char(2), country char(2), state_prov text, city text,
+  latitude double precision, longitude double precision
+);
+create table ip_to_city_two (like ip_to_city_one);
+create table config (last_update timestamp, active_table text, backup_table text);
+insert into config values (date_trunc('month', now() - interval '1' month), 'ip_to_city_one', 'ip_to_city_two');`
+
+func TestDb_MigrateUp(t *testing.T) {
+	tests := []struct {
+		name string
+		// schema is created before the migrations, like the init.sql script of the past releases did
+		schema       string
+		wantReleases []string
+		// wantTables are the dataset tables left, the empty active and backup tables aren't kept
+		wantTables []string
+		wantErr    error
+	}{
+		{
+			name:       "empty database",
+			wantTables: []string{"ip_to_asn_template", "ip_to_city_template"},
+		},
+		{
+			name: "init.sql of release v1.0.0",
+			schema: migrations[0].up + `insert into ip_to_city_one (ip_start, ip_end, country, city)
+				values ('8.8.8.0', '8.8.8.255', 'US', 'Mountain View');`,
+			wantReleases: []string{"city ip_to_city_one"},
+			wantTables:   []string{"ip_to_asn_template", "ip_to_city_one", "ip_to_city_template"},
+		},
+		{
+			name:       "init.sql with the asn dataset",
+			schema:     migrations[0].up + migrations[1].up,
+			wantTables: []string{"ip_to_asn_template", "ip_to_city_template"},
+		},
+		{
+			name:    "init.sql before release v1.0.0",
+			schema:  legacyCitySchema,
+			wantErr: ErrSchemaIncompatible,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := testDb(t)
+			if tt.schema != "" {
+				if _, err := d.ExecContext(t.Context(), tt.schema); err != nil {
+					t.Fatalf("failed to create the schema: %v", err)
+				}
+			}
+
+			if err := d.MigrateUp(t.Context()); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MigrateUp() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if err := d.CheckSchema(t.Context(), false); err != nil {
+				t.Fatalf("CheckSchema() error = %v", err)
+			}
+
+			datasets, err := d.loadConfig(t.Context())
+			if err != nil {
+				t.Fatalf("loadConfig() error = %v", err)
+			}
+			for _, dataset := range []string{cityDataset, asnDataset} {
+				if datasets[dataset] == nil {
+					t.Errorf("loadConfig() has no %s dataset", dataset)
+				}
+			}
+
+			rows, err := d.QueryContext(t.Context(), "select dataset || ' ' || table_name from releases;")
+			if err != nil {
+				t.Fatalf("failed to read releases: %v", err)
+			}
+			defer func() { _ = rows.Close() }()
+			var releases []string
+			for rows.Next() {
+				var r string
+				if err = rows.Scan(&r); err != nil {
+					t.Fatalf("failed to read releases: %v", err)
+				}
+				releases = append(releases, r)
+			}
+			if !slices.Equal(releases, tt.wantReleases) {
+				t.Errorf("releases = %v, want %v", releases, tt.wantReleases)
+			}
+
+			tables, err := d.QueryContext(t.Context(), `select table_name from information_schema.tables
+				where table_schema = current_schema() and table_name like 'ip\_to\_%' order by table_name;`)
+			if err != nil {
+				t.Fatalf("failed to read tables: %v", err)
+			}
+			defer func() { _ = tables.Close() }()
+			var names []string
+			for tables.Next() {
+				var name string
+				if err = tables.Scan(&name); err != nil {
+					t.Fatalf("failed to read tables: %v", err)
+				}
+				names = append(names, name)
+			}
+			if !slices.Equal(names, tt.wantTables) {
+				t.Errorf("tables = %v, want %v", names, tt.wantTables)
+			}
+		})
+	}
+}
+
+func TestDb_MigrateDown(t *testing.T) {
+	d := testDb(t)
+	if err := d.MigrateUp(t.Context()); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+
+	for range latestVersion() {
+		if err := d.MigrateDown(t.Context()); err != nil {
+			t.Fatalf("MigrateDown() error = %v", err)
+		}
+	}
+	if err := d.MigrateDown(t.Context()); !errors.Is(err, errNoMigration) {
+		t.Fatalf("MigrateDown() error = %v, wantErr %v", err, errNoMigration)
+	}
+
+	if err := d.MigrateUp(t.Context()); err != nil {
+		t.Fatalf("MigrateUp() after rolling back every migration error = %v", err)
+	}
+}
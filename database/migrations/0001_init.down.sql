@@ -0,0 +1,3 @@
+drop table if exists config;
+drop table if exists ip_to_city_two;
+drop table if exists ip_to_city_one;
@@ -0,0 +1,35 @@
+create table ip_to_city_one (
+  ip_start   inet unique,
+  ip_end     inet unique,
+  continent  char(2),
+  country    char(2),
+  state_prov text,
+  city       text,
+  latitude   double precision,
+  longitude  double precision,
+  ip_range   inet generated always as (inet_merge(ip_start, ip_end)) stored
+);
+
+create table ip_to_city_two (
+  ip_start   inet unique,
+  ip_end     inet unique,
+  continent  char(2),
+  country    char(2),
+  state_prov text,
+  city       text,
+  latitude   double precision,
+  longitude  double precision,
+  ip_range   inet generated always as (inet_merge(ip_start, ip_end)) stored
+);
+
+create table config (
+  last_update timestamp,
+  active_table text,
+  backup_table text
+);
+
+insert into config values(
+date_trunc('month', now() - interval '1' month),
+'ip_to_city_one',
+'ip_to_city_two'
+);
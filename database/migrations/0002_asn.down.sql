@@ -0,0 +1,5 @@
+delete from config where dataset <> 'city';
+alter table config drop column dataset;
+
+drop table if exists ip_to_asn_two;
+drop table if exists ip_to_asn_one;
@@ -0,0 +1,23 @@
+-- The db-ip.com ASN lite dataset is imported alongside the city one, so the config keeps the tables of every dataset.
+create table ip_to_asn_one (
+  ip_start        inet unique,
+  ip_end          inet unique,
+  as_number       bigint,
+  as_organization text,
+  ip_range        inet generated always as (inet_merge(ip_start, ip_end)) stored
+);
+
+create table ip_to_asn_two (
+  ip_start        inet unique,
+  ip_end          inet unique,
+  as_number       bigint,
+  as_organization text,
+  ip_range        inet generated always as (inet_merge(ip_start, ip_end)) stored
+);
+
+alter table config add column dataset text;
+update config set dataset = 'city';
+alter table config add primary key (dataset);
+
+insert into config (dataset, last_update, active_table, backup_table)
+values ('asn', date_trunc('month', now() - interval '1' month), 'ip_to_asn_one', 'ip_to_asn_two');
@@ -0,0 +1,24 @@
+-- Release tables are kept, the active one stays referenced by the config and the others can be dropped by hand. The
+-- scratch backup tables are created again, so that imports never truncate the active table, and the datasets still
+-- answered by the template get an empty active table of their own, since the templates are dropped.
+do $$
+declare
+  cfg record;
+begin
+  for cfg in select dataset, active_table, backup_table from config loop
+    if cfg.active_table in ('ip_to_city_template', 'ip_to_asn_template') then
+      execute format('create table %I (like %I including all)', 'ip_to_' || cfg.dataset || '_one', cfg.active_table);
+      update config set active_table = 'ip_to_' || cfg.dataset || '_one' where dataset = cfg.dataset;
+      cfg.active_table := 'ip_to_' || cfg.dataset || '_one';
+    end if;
+    if cfg.backup_table = cfg.active_table or cfg.backup_table in ('ip_to_city_template', 'ip_to_asn_template') then
+      execute format('create table %I (like %I including all)', 'ip_to_' || cfg.dataset || '_backup',
+        cfg.active_table);
+      update config set backup_table = 'ip_to_' || cfg.dataset || '_backup' where dataset = cfg.dataset;
+    end if;
+  end loop;
+end $$;
+
+drop table if exists releases;
+drop table if exists ip_to_asn_template;
+drop table if exists ip_to_city_template;
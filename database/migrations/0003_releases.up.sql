@@ -0,0 +1,56 @@
+-- Every monthly import goes to its own table created from the dataset template, so that past releases stay
+-- available for historical lookups until the retention drops them.
+create table ip_to_city_template (
+  ip_start   inet unique,
+  ip_end     inet unique,
+  continent  char(2),
+  country    char(2),
+  state_prov text,
+  city       text,
+  latitude   double precision,
+  longitude  double precision,
+  ip_range   inet generated always as (inet_merge(ip_start, ip_end)) stored
+);
+
+create table ip_to_asn_template (
+  ip_start        inet unique,
+  ip_end          inet unique,
+  as_number       bigint,
+  as_organization text,
+  ip_range        inet generated always as (inet_merge(ip_start, ip_end)) stored
+);
+
+create table releases (
+  dataset     text        not null,
+  version     text        not null,
+  table_name  text        not null unique,
+  imported_at timestamptz not null,
+  primary key (dataset, version)
+);
+
+create index releases_dataset_imported_at_idx on releases (dataset, imported_at);
+
+-- The active tables become the first releases when they hold an import, so that the retention drops them like any
+-- other release. Empty active tables were never imported and are dropped, the template answers the lookups until the
+-- first release like it does for the new providers. The backup tables were only used as the scratch space for the
+-- next import and are dropped too.
+do $$
+declare
+  cfg      record;
+  imported boolean;
+begin
+  for cfg in select dataset, last_update, active_table, backup_table from config loop
+    execute format('drop table if exists %I', cfg.backup_table);
+
+    execute format('select exists (select 1 from %I)', cfg.active_table) into imported;
+    if imported then
+      insert into releases (dataset, version, table_name, imported_at)
+      values (cfg.dataset, to_char(cfg.last_update, 'YYYY-MM'), cfg.active_table, cfg.last_update at time zone 'UTC');
+      update config set backup_table = active_table where dataset = cfg.dataset;
+    else
+      execute format('drop table if exists %I', cfg.active_table);
+      update config set active_table = 'ip_to_' || cfg.dataset || '_template',
+        backup_table = 'ip_to_' || cfg.dataset || '_template' where dataset = cfg.dataset;
+    end if;
+  end loop;
+end $$;
@@ -0,0 +1,15 @@
+-- Diff reports compare every imported city release with the one it replaces, rejected releases keep their report
+-- so that the same import isn't retried until the change threshold is raised.
+create table release_diffs (
+  dataset          text        not null,
+  version          text        not null,
+  previous_version text        not null default '',
+  previous_ranges  bigint      not null,
+  added            bigint      not null,
+  removed          bigint      not null,
+  changed          bigint      not null,
+  countries        jsonb       not null default '[]',
+  rejected         boolean     not null default false,
+  created_at       timestamptz not null default now(),
+  primary key (dataset, version)
+);
@@ -0,0 +1,17 @@
+-- Manual corrections of the imported locations, they don't belong to a release and survive the monthly swaps.
+-- Location columns left null keep the imported values.
+create table overrides (
+  id         bigserial   primary key,
+  network    cidr        not null unique,
+  continent  char(2),
+  country    char(2),
+  state_prov text,
+  city       text,
+  latitude   double precision,
+  longitude  double precision,
+  comment    text        not null default '',
+  created_at timestamptz not null default now(),
+  updated_at timestamptz not null default now()
+);
+
+create index overrides_network_idx on overrides using gist (network inet_ops);
@@ -0,0 +1 @@
+drop sequence if exists cache_generation;
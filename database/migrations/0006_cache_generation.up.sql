@@ -0,0 +1,6 @@
+-- The cached lookups are keyed by the cache generation, publishing a release or changing the overrides moves every
+-- instance to the next one, so that the entries of a shared cache are dropped as well. The first value is taken
+-- right away, since last_value doesn't move on the first nextval otherwise.
+create sequence cache_generation;
+
+select nextval('cache_generation');
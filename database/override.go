@@ -0,0 +1,262 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/streamdp/ip-info/domain"
+)
+
+// uniqueViolation is the SQLSTATE of the unique constraint errors.
+const uniqueViolation = "23505"
+
+const overrideColumns = `id, network::text, continent, country, state_prov, city, latitude, longitude, comment,
+	created_at, updated_at`
+
+type overrideDto struct {
+	Id        sql.NullInt64   `db:"id"`
+	Network   sql.NullString  `db:"network"`
+	Continent sql.NullString  `db:"continent"`
+	Country   sql.NullString  `db:"country"`
+	StateProv sql.NullString  `db:"state_prov"`
+	City      sql.NullString  `db:"city"`
+	Latitude  sql.NullFloat64 `db:"latitude"`
+	Longitude sql.NullFloat64 `db:"longitude"`
+	Comment   string          `db:"comment"`
+	CreatedAt time.Time       `db:"created_at"`
+	UpdatedAt time.Time       `db:"updated_at"`
+}
+
+func (dto *overrideDto) override() *domain.Override {
+	network, _ := netip.ParsePrefix(dto.Network.String)
+
+	return &domain.Override{
+		Id:        dto.Id.Int64,
+		Network:   network,
+		Continent: nullString(dto.Continent),
+		Country:   nullString(dto.Country),
+		StateProv: nullString(dto.StateProv),
+		City:      nullString(dto.City),
+		Latitude:  nullFloat(dto.Latitude),
+		Longitude: nullFloat(dto.Longitude),
+		Comment:   dto.Comment,
+		CreatedAt: dto.CreatedAt.UTC(),
+		UpdatedAt: dto.UpdatedAt.UTC(),
+	}
+}
+
+func scanOverride(row interface{ Scan(dest ...any) error }) (*domain.Override, error) {
+	dto := &overrideDto{}
+	if err := row.Scan(
+		&dto.Id,
+		&dto.Network,
+		&dto.Continent,
+		&dto.Country,
+		&dto.StateProv,
+		&dto.City,
+		&dto.Latitude,
+		&dto.Longitude,
+		&dto.Comment,
+		&dto.CreatedAt,
+		&dto.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return dto.override(), nil
+}
+
+func (d *db) ListOverrides(ctx context.Context) ([]*domain.Override, error) {
+	var overrides []*domain.Override
+	if err := d.withTimeout(ctx, d.DB, func(ctx context.Context, pool *sql.DB) error {
+		rows, err := pool.QueryContext(ctx, fmt.Sprintf("select %s from overrides order by network;", overrideColumns))
+		if err != nil {
+			return fmt.Errorf("failed to list overrides: %w", err)
+		}
+		defer func() { _ = rows.Close() }()
+
+		overrides = []*domain.Override{}
+		for rows.Next() {
+			o, errScan := scanOverride(rows)
+			if errScan != nil {
+				return fmt.Errorf("failed to list overrides: %w", errScan)
+			}
+			overrides = append(overrides, o)
+		}
+
+		return rows.Err()
+	}); err != nil {
+		return nil, d.overrideError(err)
+	}
+
+	return overrides, nil
+}
+
+func (d *db) Override(ctx context.Context, id int64) (*domain.Override, error) {
+	var o *domain.Override
+	if err := d.withTimeout(ctx, d.DB, func(ctx context.Context, pool *sql.DB) (err error) {
+		o, err = scanOverride(pool.QueryRowContext(ctx,
+			fmt.Sprintf("select %s from overrides where id=$1;", overrideColumns),
+			id,
+		))
+
+		return err
+	}); err != nil {
+		return nil, d.overrideError(err)
+	}
+
+	return o, nil
+}
+
+func (d *db) CreateOverride(ctx context.Context, o *domain.Override) (*domain.Override, error) {
+	var created *domain.Override
+	if err := d.withTimeout(ctx, d.DB, func(ctx context.Context, pool *sql.DB) error {
+		tx, err := pool.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create override: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		created, err = scanOverride(tx.QueryRowContext(ctx,
+			fmt.Sprintf(`insert into overrides (network, continent, country, state_prov, city, latitude, longitude,
+			comment) values ($1, $2, $3, $4, $5, $6, $7, $8) on conflict (network) do nothing returning %s;`,
+				overrideColumns,
+			),
+			o.Network.String(),
+			o.Continent,
+			o.Country,
+			o.StateProv,
+			o.City,
+			o.Latitude,
+			o.Longitude,
+			o.Comment,
+		))
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrOverrideExists
+		}
+		if err != nil {
+			return err
+		}
+
+		return d.commitOverrides(ctx, tx)
+	}); err != nil {
+		return nil, d.overrideError(err)
+	}
+
+	return created, nil
+}
+
+// UpdateOverride replaces the override with the id.
+func (d *db) UpdateOverride(ctx context.Context, o *domain.Override) (*domain.Override, error) {
+	var updated *domain.Override
+	if err := d.withTimeout(ctx, d.DB, func(ctx context.Context, pool *sql.DB) error {
+		tx, err := pool.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to update override: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		if updated, err = scanOverride(tx.QueryRowContext(ctx,
+			fmt.Sprintf(`update overrides set network=$2, continent=$3, country=$4, state_prov=$5, city=$6,
+			latitude=$7, longitude=$8, comment=$9, updated_at=now() where id=$1 returning %s;`,
+				overrideColumns,
+			),
+			o.Id,
+			o.Network.String(),
+			o.Continent,
+			o.Country,
+			o.StateProv,
+			o.City,
+			o.Latitude,
+			o.Longitude,
+			o.Comment,
+		)); err != nil {
+			return err
+		}
+
+		return d.commitOverrides(ctx, tx)
+	}); err != nil {
+		return nil, d.overrideError(err)
+	}
+
+	return updated, nil
+}
+
+func (d *db) DeleteOverride(ctx context.Context, id int64) error {
+	if err := d.withTimeout(ctx, d.DB, func(ctx context.Context, pool *sql.DB) error {
+		tx, err := pool.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to delete override: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		var deleted int64
+		if err = tx.QueryRowContext(ctx, "delete from overrides where id=$1 returning id;", id).Scan(
+			&deleted,
+		); err != nil {
+			return err
+		}
+
+		return d.commitOverrides(ctx, tx)
+	}); err != nil {
+		return d.overrideError(err)
+	}
+
+	return nil
+}
+
+// commitOverrides commits the change of the overrides together with the next cache generation, every instance drops
+// its cached lookups once it's notified.
+func (d *db) commitOverrides(ctx context.Context, tx *sql.Tx) error {
+	generation, err := nextCacheGeneration(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `select pg_notify($1, $2);`, swapChannel, overridesNotification); err != nil {
+		return fmt.Errorf("error notifying overrides change: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit overrides change: %w", err)
+	}
+	d.setCacheGeneration(generation)
+
+	return nil
+}
+
+// overrideError keeps the errors clients can act on and hides the others.
+func (d *db) overrideError(err error) error {
+	var pqErr *pq.Error
+	switch {
+	case errors.Is(err, domain.ErrOverrideExists):
+		return domain.ErrOverrideExists
+	case errors.As(err, &pqErr) && pqErr.Code == uniqueViolation:
+		return domain.ErrOverrideExists
+	case errors.Is(err, sql.ErrNoRows):
+		return domain.ErrNoOverride
+	default:
+		d.l.Println(err)
+
+		return errDatabaseError
+	}
+}
+
+func nullString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+
+	return &s.String
+}
+
+func nullFloat(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+
+	return &f.Float64
+}
@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+// providerDto is the range of a provider covering the looked up ip, the columns the provider doesn't map are null.
+type providerDto struct {
+	ipStart   string
+	ipEnd     string
+	Continent sql.NullString  `db:"continent"`
+	Country   sql.NullString  `db:"country"`
+	StateProv sql.NullString  `db:"state_prov"`
+	City      sql.NullString  `db:"city"`
+	Latitude  sql.NullFloat64 `db:"latitude"`
+	Longitude sql.NullFloat64 `db:"longitude"`
+}
+
+// providerTable is the release table of a provider the lookup goes to.
+type providerTable struct {
+	provider string
+	table    string
+}
+
+// fill sets the field of ipInfo when the range has a value for it.
+func (dto *providerDto) fill(ipInfo *domain.IpInfo, field string) bool {
+	switch field {
+	case domain.FieldContinent:
+		return fillString(&ipInfo.Continent, dto.Continent)
+	case domain.FieldCountry:
+		return fillString(&ipInfo.Country, dto.Country)
+	case domain.FieldStateProv:
+		return fillString(&ipInfo.StateProv, dto.StateProv)
+	case domain.FieldCity:
+		return fillString(&ipInfo.City, dto.City)
+	case domain.FieldLocation:
+		if !dto.Latitude.Valid || !dto.Longitude.Valid {
+			return false
+		}
+		ipInfo.Latitude, ipInfo.Longitude = dto.Latitude.Float64, dto.Longitude.Float64
+
+		return true
+	default:
+		return false
+	}
+}
+
+// narrow intersects the network of the lookup with the range, once a field was taken from it.
+func (dto *providerDto) narrow(network string) string {
+	start, end := parseAddr(dto.ipStart), parseAddr(dto.ipEnd)
+	if !start.IsValid() || !end.IsValid() {
+		return network
+	}
+
+	return domain.IntersectNetwork(network, start, end)
+}
+
+func fillString(dst *string, s sql.NullString) bool {
+	if !s.Valid || s.String == "" {
+		return false
+	}
+	*dst = s.String
+
+	return true
+}
+
+// registerProviders adds the config of the providers that were never imported, their lookups go to the empty
+// template until their first release is imported.
+func (d *db) registerProviders(ctx context.Context) error {
+	for _, ds := range d.providers {
+		if _, err := d.ExecContext(ctx,
+			`insert into config (dataset, last_update, active_table, backup_table)
+			values ($1, date_trunc('month', now() - interval '1' month), $2, $2) on conflict (dataset) do nothing;`,
+			ds.name,
+			ds.templateTable(),
+		); err != nil {
+			return fmt.Errorf("failed to register %s provider: %w", ds.name, err)
+		}
+	}
+
+	return nil
+}
+
+// providerRanges looks the ip up in the tables of the providers, the providers not covering it are left out. The
+// lookups are prepared for the active tables only.
+func (d *db) providerRanges(
+	ctx context.Context, pool *sql.DB, tables []providerTable, prepare bool, ip netip.Addr,
+) (map[string]*providerDto, error) {
+	ranges := make(map[string]*providerDto, len(tables))
+	for _, t := range tables {
+		row, err := d.queryRow(ctx, pool, providerLookup(t.table, prepare), ip.String())
+		if err != nil {
+			return nil, err
+		}
+
+		dto := &providerDto{}
+		if err = row.Scan(
+			&dto.ipStart,
+			&dto.ipEnd,
+			&dto.Continent,
+			&dto.Country,
+			&dto.StateProv,
+			&dto.City,
+			&dto.Latitude,
+			&dto.Longitude,
+		); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+
+			return nil, fmt.Errorf("failed to look up ip in %s: %w", t.provider, err)
+		}
+		ranges[t.provider] = dto
+	}
+
+	return ranges, nil
+}
+
+// Providers returns the providers of the location fields with their attributions, db-ip is listed once for both of
+// its datasets.
+func (d *db) Providers() []*domain.Provider {
+	var providers []*domain.Provider
+	for _, ds := range d.allDatasets() {
+		if ds.kind != cityDataset {
+			continue
+		}
+
+		p := &domain.Provider{Name: ds.provider, Attribution: ds.attribution, Fields: []string{}}
+		for _, field := range domain.LocationFields {
+			if slices.Contains(d.cfg.Precedence(field), ds.provider) {
+				p.Fields = append(p.Fields, field)
+			}
+		}
+		providers = append(providers, p)
+	}
+
+	return providers
+}
@@ -0,0 +1,249 @@
+package database
+
+import (
+	"database/sql"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/streamdp/ip-info/config"
+	"github.com/streamdp/ip-info/domain"
+)
+
+func Test_ipToCityDto_ipInfo(t *testing.T) {
+	acme := &config.Provider{Name: "acme"}
+	updatedAt := time.Date(2024, 9, 2, 10, 0, 0, 0, time.UTC)
+
+	dbIp := func() *ipToCityDto {
+		return &ipToCityDto{
+			ipStart:   "203.0.112.0",
+			ipEnd:     "203.0.115.255",
+			ipRange:   "203.0.112.0/22",
+			Continent: "OC",
+			Country:   "AU",
+			StateProv: "New South Wales",
+			City:      "",
+			Latitude:  -33.8688,
+			Longitude: 151.209,
+			imported:  true,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		dto         func() *ipToCityDto
+		cfg         *config.Database
+		wantCity    string
+		wantCountry string
+		wantNetwork string
+		wantSources domain.Sources
+	}{
+		{
+			name: "db-ip only",
+			dto:  dbIp,
+			cfg:  &config.Database{},
+			wantSources: domain.Sources{
+				domain.FieldContinent: domain.SourceDbIp,
+				domain.FieldCountry:   domain.SourceDbIp,
+				domain.FieldStateProv: domain.SourceDbIp,
+				domain.FieldLocation:  domain.SourceDbIp,
+			},
+			wantCountry: "AU",
+			wantNetwork: "203.0.112.0/22",
+		},
+		{
+			name: "provider fills the gaps of db-ip by default",
+			dto: func() *ipToCityDto {
+				dto := dbIp()
+				dto.providers = map[string]*providerDto{"acme": {
+					ipStart: "203.0.113.0",
+					ipEnd:   "203.0.113.127",
+					Country: sql.NullString{String: "NZ", Valid: true},
+					City:    sql.NullString{String: "Wellington", Valid: true},
+				}}
+
+				return dto
+			},
+			cfg: (&config.Database{}).SetProviders([]*config.Provider{acme}, nil),
+			wantSources: domain.Sources{
+				domain.FieldContinent: domain.SourceDbIp,
+				domain.FieldCountry:   "acme",
+				domain.FieldStateProv: domain.SourceDbIp,
+				domain.FieldCity:      "acme",
+				domain.FieldLocation:  domain.SourceDbIp,
+			},
+			wantCity:    "Wellington",
+			wantCountry: "NZ",
+			wantNetwork: "203.0.113.0/25",
+		},
+		{
+			name: "city from acme, country from db-ip",
+			dto: func() *ipToCityDto {
+				dto := dbIp()
+				dto.providers = map[string]*providerDto{"acme": {
+					ipStart: "203.0.111.0",
+					ipEnd:   "203.0.113.20",
+					Country: sql.NullString{String: "NZ", Valid: true},
+					City:    sql.NullString{String: "Wellington", Valid: true},
+				}}
+
+				return dto
+			},
+			cfg: (&config.Database{}).SetProviders([]*config.Provider{acme}, map[string][]string{
+				domain.FieldCountry: {domain.SourceDbIp, "acme"},
+			}),
+			wantSources: domain.Sources{
+				domain.FieldContinent: domain.SourceDbIp,
+				domain.FieldCountry:   domain.SourceDbIp,
+				domain.FieldStateProv: domain.SourceDbIp,
+				domain.FieldCity:      "acme",
+				domain.FieldLocation:  domain.SourceDbIp,
+			},
+			wantCity:    "Wellington",
+			wantCountry: "AU",
+			wantNetwork: "203.0.112.0-203.0.113.20",
+		},
+		{
+			name: "provider not contributing any field",
+			dto: func() *ipToCityDto {
+				dto := dbIp()
+				dto.providers = map[string]*providerDto{"acme": {
+					ipStart: "203.0.113.0",
+					ipEnd:   "203.0.113.127",
+					Country: sql.NullString{String: "NZ", Valid: true},
+				}}
+
+				return dto
+			},
+			cfg: (&config.Database{}).SetProviders([]*config.Provider{acme}, map[string][]string{
+				domain.FieldCountry: {domain.SourceDbIp, "acme"},
+			}),
+			wantSources: domain.Sources{
+				domain.FieldContinent: domain.SourceDbIp,
+				domain.FieldCountry:   domain.SourceDbIp,
+				domain.FieldStateProv: domain.SourceDbIp,
+				domain.FieldLocation:  domain.SourceDbIp,
+			},
+			wantCountry: "AU",
+			wantNetwork: "203.0.112.0/22",
+		},
+		{
+			name: "provider not covering the ip",
+			dto:  dbIp,
+			cfg: (&config.Database{}).SetProviders([]*config.Provider{acme}, map[string][]string{
+				domain.FieldCity: {"acme"},
+			}),
+			wantSources: domain.Sources{
+				domain.FieldContinent: domain.SourceDbIp,
+				domain.FieldCountry:   domain.SourceDbIp,
+				domain.FieldStateProv: domain.SourceDbIp,
+				domain.FieldLocation:  domain.SourceDbIp,
+			},
+			wantCountry: "AU",
+			wantNetwork: "203.0.112.0/22",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.dto().ipInfo(netip.MustParseAddr("203.0.113.10"), updatedAt, tt.cfg.Precedence)
+			if got.City != tt.wantCity || got.Country != tt.wantCountry {
+				t.Errorf("ipInfo() city, country = %v, %v, want %v, %v", got.City, got.Country, tt.wantCity,
+					tt.wantCountry)
+			}
+			if !reflect.DeepEqual(got.Sources, tt.wantSources) {
+				t.Errorf("ipInfo() sources = %v, want %v", got.Sources, tt.wantSources)
+			}
+			if got.Network != tt.wantNetwork || got.Source != domain.SourceDbIp {
+				t.Errorf("ipInfo() network, source = %v, %v, want %v, %v", got.Network, got.Source, tt.wantNetwork,
+					domain.SourceDbIp)
+			}
+		})
+	}
+}
+
+func Test_dataset_program(t *testing.T) {
+	t2024 := time.Date(2024, 9, 2, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		ds   *dataset
+		want string
+	}{
+		{
+			name: "db-ip download",
+			ds:   datasetByName(cityDataset),
+			want: `wget -qO- "https://download.db-ip.com/free/dbip-city-lite-2024-09.csv.gz"|gzip -d`,
+		},
+		{
+			name: "plain csv url",
+			ds:   &dataset{downloadUrl: "https://feed.acme.example/geo.csv"},
+			want: `wget -qO- "https://feed.acme.example/geo.csv"`,
+		},
+		{
+			name: "gzipped file",
+			ds:   &dataset{file: "/var/lib/ip-info/acme.csv.gz"},
+			want: `cat "/var/lib/ip-info/acme.csv.gz"|gzip -d`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ds.program(t2024); got != tt.want {
+				t.Errorf("program() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_dataset_mappedColumns(t *testing.T) {
+	ds := providerDatasets([]*config.Provider{{
+		Name: "acme",
+		Columns: map[string]int{
+			config.ColumnIpStart:  1,
+			config.ColumnIpEnd:    2,
+			config.ColumnCity:     5,
+			config.ColumnLatitude: 7,
+		},
+	}})[0]
+
+	columns, values := ds.mappedColumns()
+	if want := "city, ip_end, ip_start, latitude"; columns != want {
+		t.Errorf("mappedColumns() columns = %v, want %v", columns, want)
+	}
+	if want := "nullif(c5, ''), c2::inet, c1::inet, nullif(c7, '')::double precision"; values != want {
+		t.Errorf("mappedColumns() values = %v, want %v", values, want)
+	}
+	if got := ds.csvColumns(); got != 7 {
+		t.Errorf("csvColumns() = %v, want %v", got, 7)
+	}
+	if got := ds.releaseTable(time.Date(2024, 9, 2, 10, 0, 0, 0, time.UTC)); got != "ip_to_acme_2024_09" {
+		t.Errorf("releaseTable() = %v, want %v", got, "ip_to_acme_2024_09")
+	}
+	if got := ds.templateTable(); got != "ip_to_city_template" {
+		t.Errorf("templateTable() = %v, want %v", got, "ip_to_city_template")
+	}
+}
+
+func TestDb_Providers(t *testing.T) {
+	cfg := (&config.Database{}).SetProviders(
+		[]*config.Provider{{Name: "acme", Attribution: "Geolocation data by Acme"}},
+		map[string][]string{domain.FieldCountry: {domain.SourceDbIp}},
+	)
+	d := &db{cfg: cfg, providers: providerDatasets(cfg.Providers())}
+
+	want := []*domain.Provider{
+		{
+			Name:        domain.SourceDbIp,
+			Attribution: datasetByName(cityDataset).attribution,
+			Fields:      domain.LocationFields,
+		},
+		{
+			Name:        "acme",
+			Attribution: "Geolocation data by Acme",
+			Fields:      []string{domain.FieldContinent, domain.FieldStateProv, domain.FieldCity, domain.FieldLocation},
+		},
+	}
+	if got := d.Providers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Providers() = %v, want %v", got, want)
+	}
+}
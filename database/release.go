@@ -0,0 +1,232 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+type releaseDto struct {
+	Dataset    string    `db:"dataset"`
+	Version    string    `db:"version"`
+	TableName  string    `db:"table_name"`
+	ImportedAt time.Time `db:"imported_at"`
+}
+
+// publishRelease stores the active tables and registers the imported release within one transaction, so that
+// historical lookups never see a release the config doesn't know about.
+func (d *db) publishRelease(
+	ctx context.Context, ds *dataset, activeTable, backupTable string, importedAt time.Time,
+) error {
+	d.l.Printf("publishing %s release %s", ds.name, dataVersion(importedAt))
+
+	tx, err := d.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error publishing release: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err = tx.ExecContext(ctx,
+		`update config set last_update=$3, active_table=$1, backup_table=$2 where dataset=$4;`,
+		activeTable,
+		backupTable,
+		importedAt,
+		ds.name,
+	); err != nil {
+		return fmt.Errorf("error updating config: %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx,
+		`insert into releases (dataset, version, table_name, imported_at) values ($1, $2, $3, $4)
+		on conflict (dataset, version) do update set table_name=excluded.table_name, imported_at=excluded.imported_at;`,
+		ds.name,
+		dataVersion(importedAt),
+		activeTable,
+		importedAt,
+	); err != nil {
+		return fmt.Errorf("error registering release: %w", err)
+	}
+
+	generation, err := nextCacheGeneration(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	// the other instances reload the config once the transaction commits
+	if _, err = tx.ExecContext(ctx, `select pg_notify($1, $2);`, swapChannel, ds.name); err != nil {
+		return fmt.Errorf("error notifying release swap: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error publishing release: %w", err)
+	}
+	d.setCacheGeneration(generation)
+
+	return nil
+}
+
+// dropExpiredReleases keeps the active release of the dataset and the configured number of past ones, none when the
+// retention is zero.
+func (d *db) dropExpiredReleases(ctx context.Context, ds *dataset) error {
+	rows, err := d.QueryContext(ctx,
+		`select table_name from releases where dataset=$1 and table_name<>$2 order by imported_at desc offset $3;`,
+		ds.name,
+		d.activeTable(ds.name),
+		d.cfg.ReleaseRetention(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to list expired releases: %w", err)
+	}
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err = rows.Scan(&table); err != nil {
+			_ = rows.Close()
+
+			return fmt.Errorf("failed to list expired releases: %w", err)
+		}
+		tables = append(tables, table)
+	}
+	if err = errors.Join(rows.Err(), rows.Close()); err != nil {
+		return fmt.Errorf("failed to list expired releases: %w", err)
+	}
+
+	for _, table := range tables {
+		d.l.Printf("dropping expired %s release table %s", ds.name, table)
+
+		if _, err = d.ExecContext(ctx, "delete from releases where table_name=$1;", table); err != nil {
+			return fmt.Errorf("failed to drop expired release: %w", err)
+		}
+		if _, err = d.ExecContext(ctx, fmt.Sprintf("drop table if exists %s;", table)); err != nil {
+			return fmt.Errorf("failed to drop expired release: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// IpInfoAsOf locates the ip in the release of each dataset that was active at the given time, the overrides aren't
+// applied. The queries run unprepared, past releases are looked up too rarely to keep statements for them.
+func (d *db) IpInfoAsOf(ctx context.Context, ip netip.Addr, asOf time.Time) (*domain.IpInfo, error) {
+	var (
+		city *releaseDto
+		dto  *ipToCityDto
+	)
+	if err := d.read(ctx, func(ctx context.Context, pool *sql.DB) (err error) {
+		releases, err := releasesAsOf(ctx, pool, asOf)
+		if err != nil {
+			return err
+		}
+
+		var ok bool
+		if city, ok = releases[cityDataset]; !ok {
+			return domain.ErrNoRelease
+		}
+
+		// a date before the first asn release still gets the location, the empty template has no asn to join
+		asnTable := datasetByName(asnDataset).templateTable()
+		if asn, ok := releases[asnDataset]; ok {
+			asnTable = asn.TableName
+		}
+
+		if dto, err = d.ipInfo(ctx, pool, ipInfoLookup(city.TableName, asnTable, false), ip); err != nil {
+			return err
+		}
+		// the overrides table only keeps the current corrections, historical lookups return the imported data
+		if !dto.imported {
+			return domain.ErrNoIpAddress
+		}
+		dto.override = overrideDto{}
+
+		// providers imported after the date have no release to answer it
+		var providerTables []providerTable
+		for _, ds := range d.providers {
+			if r, ok := releases[ds.name]; ok {
+				providerTables = append(providerTables, providerTable{provider: ds.provider, table: r.TableName})
+			}
+		}
+		dto.providers, err = d.providerRanges(ctx, pool, providerTables, false, ip)
+
+		return err
+	}); err != nil {
+		if errors.Is(err, domain.ErrNoIpAddress) || errors.Is(err, domain.ErrNoRelease) {
+			return nil, err
+		}
+
+		return nil, errDatabaseError
+	}
+
+	return dto.ipInfo(ip, city.ImportedAt, d.cfg.Precedence), nil
+}
+
+// releasesAsOf returns the latest release of every dataset imported by the given time.
+func releasesAsOf(ctx context.Context, q querier, asOf time.Time) (map[string]*releaseDto, error) {
+	rows, err := q.QueryContext(ctx,
+		`select distinct on (dataset) dataset, version, table_name, imported_at from releases
+		where imported_at <= $1 order by dataset, imported_at desc;`,
+		asOf,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve releases: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	releases := map[string]*releaseDto{}
+	for rows.Next() {
+		dto := &releaseDto{}
+		if err = rows.Scan(&dto.Dataset, &dto.Version, &dto.TableName, &dto.ImportedAt); err != nil {
+			return nil, fmt.Errorf("failed to resolve releases: %w", err)
+		}
+		releases[dto.Dataset] = dto
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to resolve releases: %w", err)
+	}
+
+	return releases, nil
+}
+
+// Releases lists the imported releases of every dataset, the latest first.
+func (d *db) Releases(ctx context.Context) ([]*domain.Release, error) {
+	var dtos []*releaseDto
+	if err := d.read(ctx, func(ctx context.Context, pool *sql.DB) error {
+		rows, err := pool.QueryContext(ctx,
+			`select dataset, version, table_name, imported_at from releases order by dataset, imported_at desc;`,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to list releases: %w", err)
+		}
+		defer func() { _ = rows.Close() }()
+
+		dtos = dtos[:0]
+		for rows.Next() {
+			dto := &releaseDto{}
+			if err = rows.Scan(&dto.Dataset, &dto.Version, &dto.TableName, &dto.ImportedAt); err != nil {
+				return fmt.Errorf("failed to list releases: %w", err)
+			}
+			dtos = append(dtos, dto)
+		}
+
+		return rows.Err()
+	}); err != nil {
+		return nil, errDatabaseError
+	}
+
+	releases := make([]*domain.Release, 0, len(dtos))
+	for _, dto := range dtos {
+		releases = append(releases, &domain.Release{
+			Dataset:    dto.Dataset,
+			Version:    dto.Version,
+			ImportedAt: dto.ImportedAt.UTC(),
+			Active:     dto.TableName == d.activeTable(dto.Dataset),
+		})
+	}
+
+	return releases, nil
+}
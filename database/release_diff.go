@@ -0,0 +1,211 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+var ErrReleaseRejected = errors.New("release changes exceed the threshold")
+
+// releaseDiffQuery matches the ranges of two city tables by their bounds, a range is changed when it kept its bounds
+// but got another country or city. Removed ranges are grouped by their previous country.
+const releaseDiffQuery = `select coalesce(n.country, o.country, ''),
+	count(*) filter (where o.ip_start is null),
+	count(*) filter (where n.ip_start is null),
+	count(*) filter (where o.ip_start is not null and n.ip_start is not null
+		and (o.country is distinct from n.country or o.city is distinct from n.city)),
+	count(o.ip_start)
+	from %[1]s o full join %[2]s n on n.ip_start = o.ip_start and n.ip_end = o.ip_end
+	group by 1 order by 1;`
+
+// checkRelease diffs the imported table with the active one and stores the report, the release is rejected when its
+// changes exceed the configured threshold. Only the city dataset is diffed.
+func (d *db) checkRelease(ctx context.Context, ds *dataset, table string, importedAt time.Time) error {
+	if ds.name != cityDataset {
+		return nil
+	}
+
+	diff, err := d.diffRelease(ctx, ds, table, dataVersion(importedAt))
+	if err != nil {
+		return err
+	}
+	diff.Rejected = d.exceedsMaxChange(diff)
+
+	d.l.Printf("%s release %s: %d ranges added, %d removed, %d changed (%.2f%%)",
+		ds.name, diff.Version, diff.Added, diff.Removed, diff.Changed, diff.ChangedPercent(),
+	)
+
+	if err = d.storeReleaseDiff(ctx, diff); err != nil {
+		return err
+	}
+
+	if diff.Rejected {
+		if _, err = d.ExecContext(ctx, fmt.Sprintf("drop table if exists %s;", table)); err != nil {
+			d.l.Printf("failed to drop rejected release: %v", err)
+		}
+
+		return fmt.Errorf("%w: %.2f%% of the ranges changed, at most %.2f%% allowed",
+			ErrReleaseRejected, diff.ChangedPercent(), d.cfg.MaxReleaseChange(),
+		)
+	}
+
+	return nil
+}
+
+func (d *db) exceedsMaxChange(diff *domain.ReleaseDiff) bool {
+	return d.cfg.MaxReleaseChange() > 0 && diff.ChangedPercent() > d.cfg.MaxReleaseChange()
+}
+
+func (d *db) diffRelease(ctx context.Context, ds *dataset, table, version string) (*domain.ReleaseDiff, error) {
+	rows, err := d.QueryContext(ctx, fmt.Sprintf(releaseDiffQuery, d.activeTable(ds.name), table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff release: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	diff := &domain.ReleaseDiff{
+		Dataset:   ds.name,
+		Version:   version,
+		Countries: []*domain.CountryDiff{},
+	}
+	for rows.Next() {
+		var (
+			c              = &domain.CountryDiff{}
+			previousRanges int64
+		)
+		if err = rows.Scan(&c.Country, &c.Added, &c.Removed, &c.Changed, &previousRanges); err != nil {
+			return nil, fmt.Errorf("failed to diff release: %w", err)
+		}
+
+		diff.PreviousRanges += previousRanges
+		diff.Added += c.Added
+		diff.Removed += c.Removed
+		diff.Changed += c.Changed
+		if c.Added+c.Removed+c.Changed > 0 {
+			diff.Countries = append(diff.Countries, c)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to diff release: %w", err)
+	}
+
+	if diff.PreviousRanges > 0 {
+		diff.PreviousVersion = dataVersion(d.lastUpdate(ds.name))
+	}
+
+	return diff, nil
+}
+
+func (d *db) storeReleaseDiff(ctx context.Context, diff *domain.ReleaseDiff) error {
+	countries, err := json.Marshal(diff.Countries)
+	if err != nil {
+		return fmt.Errorf("failed to store release diff: %w", err)
+	}
+
+	diff.CreatedAt = time.Now().UTC()
+
+	if _, err = d.ExecContext(ctx,
+		`insert into release_diffs (dataset, version, previous_version, previous_ranges, added, removed, changed,
+		countries, rejected, created_at) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		on conflict (dataset, version) do update set previous_version=excluded.previous_version,
+		previous_ranges=excluded.previous_ranges, added=excluded.added, removed=excluded.removed,
+		changed=excluded.changed, countries=excluded.countries, rejected=excluded.rejected,
+		created_at=excluded.created_at;`,
+		diff.Dataset,
+		diff.Version,
+		diff.PreviousVersion,
+		diff.PreviousRanges,
+		diff.Added,
+		diff.Removed,
+		diff.Changed,
+		string(countries),
+		diff.Rejected,
+		diff.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("failed to store release diff: %w", err)
+	}
+
+	return nil
+}
+
+// ReleaseDiff returns the diff report of the city release, it's read from the primary since it's written right
+// before the release is activated.
+func (d *db) ReleaseDiff(ctx context.Context, version string) (*domain.ReleaseDiff, error) {
+	var diff *domain.ReleaseDiff
+	if err := d.withTimeout(ctx, d.DB, func(ctx context.Context, pool *sql.DB) (err error) {
+		diff, err = releaseDiff(ctx, pool, cityDataset, version)
+
+		return err
+	}); err != nil {
+		if errors.Is(err, domain.ErrNoReleaseDiff) {
+			return nil, err
+		}
+		d.l.Println(err)
+
+		return nil, errDatabaseError
+	}
+
+	return diff, nil
+}
+
+func releaseDiff(ctx context.Context, q querier, dataset, version string) (*domain.ReleaseDiff, error) {
+	var (
+		diff      = &domain.ReleaseDiff{Dataset: dataset, Version: version}
+		countries []byte
+	)
+	if err := q.QueryRowContext(ctx,
+		`select d.previous_version, d.previous_ranges, d.added, d.removed, d.changed, d.countries, d.rejected,
+		d.created_at, r.version is not null
+		from release_diffs d left join releases r on r.dataset = d.dataset and r.version = d.version
+		where d.dataset=$1 and d.version=$2;`,
+		dataset,
+		version,
+	).Scan(
+		&diff.PreviousVersion,
+		&diff.PreviousRanges,
+		&diff.Added,
+		&diff.Removed,
+		&diff.Changed,
+		&countries,
+		&diff.Rejected,
+		&diff.CreatedAt,
+		&diff.Activated,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNoReleaseDiff
+		}
+
+		return nil, fmt.Errorf("failed to get release diff: %w", err)
+	}
+	if err := json.Unmarshal(countries, &diff.Countries); err != nil {
+		return nil, fmt.Errorf("failed to get release diff: %w", err)
+	}
+	diff.CreatedAt = diff.CreatedAt.UTC()
+
+	return diff, nil
+}
+
+// rejectedRelease reports whether the release of the dataset was rejected and would still be with the current
+// threshold, so that the same import isn't downloaded again until the threshold is raised.
+func (d *db) rejectedRelease(ctx context.Context, ds *dataset, version string) (bool, error) {
+	if ds.name != cityDataset || d.cfg.MaxReleaseChange() == 0 {
+		return false, nil
+	}
+
+	diff, err := releaseDiff(ctx, d, ds.name, version)
+	if err != nil {
+		if errors.Is(err, domain.ErrNoReleaseDiff) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return diff.Rejected && d.exceedsMaxChange(diff), nil
+}
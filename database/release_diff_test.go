@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/streamdp/ip-info/config"
+	"github.com/streamdp/ip-info/domain"
+)
+
+func TestDb_exceedsMaxChange(t *testing.T) {
+	tests := []struct {
+		name             string
+		maxReleaseChange float64
+		diff             *domain.ReleaseDiff
+		want             bool
+	}{
+		{
+			name:             "check disabled",
+			maxReleaseChange: 0,
+			diff:             &domain.ReleaseDiff{PreviousRanges: 100, Removed: 100},
+			want:             false,
+		},
+		{
+			name:             "changes within the threshold",
+			maxReleaseChange: 5,
+			diff:             &domain.ReleaseDiff{PreviousRanges: 100, Added: 2, Removed: 1, Changed: 2},
+			want:             false,
+		},
+		{
+			name:             "changes exceed the threshold",
+			maxReleaseChange: 5,
+			diff:             &domain.ReleaseDiff{PreviousRanges: 100, Added: 2, Removed: 1, Changed: 3},
+			want:             true,
+		},
+		{
+			name:             "first release",
+			maxReleaseChange: 5,
+			diff:             &domain.ReleaseDiff{Added: 100},
+			want:             false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &db{cfg: (&config.Database{}).SetMaxReleaseChange(tt.maxReleaseChange)}
+			if got := d.exceedsMaxChange(tt.diff); got != tt.want {
+				t.Errorf("exceedsMaxChange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDb_checkRelease(t *testing.T) {
+	// only the city dataset is diffed, so checking an asn release never reaches the database
+	d := &db{
+		cfg: (&config.Database{}).SetMaxReleaseChange(5),
+		l:   log.New(io.Discard, "", log.LstdFlags),
+	}
+	asn := datasetByName(asnDataset)
+
+	if err := d.checkRelease(context.Background(), asn, "ip_to_asn_2024_09", time.Now()); err != nil {
+		t.Errorf("checkRelease() error = %v", err)
+	}
+
+	rejected, err := d.rejectedRelease(context.Background(), asn, "2024-09")
+	if err != nil || rejected {
+		t.Errorf("rejectedRelease() = %v, %v, want false", rejected, err)
+	}
+}
+
+func TestDb_ReleaseDiff(t *testing.T) {
+	d := testDb(t)
+	if err := d.MigrateUp(t.Context()); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+
+	// 2024-09 was published after its check, 2024-10 was checked but its import never got activated
+	if _, err := d.ExecContext(t.Context(), `
+		insert into release_diffs (dataset, version, previous_ranges, added, removed, changed)
+			values ('city', '2024-09', 0, 10, 0, 0), ('city', '2024-10', 10, 1, 0, 0);
+		insert into releases (dataset, version, table_name, imported_at)
+			values ('city', '2024-09', 'ip_to_city_template', '2024-09-02T03:00:00Z');`,
+	); err != nil {
+		t.Fatalf("failed to insert the diffs: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		version       string
+		wantActivated bool
+		wantErr       error
+	}{
+		{
+			name:          "activated release",
+			version:       "2024-09",
+			wantActivated: true,
+		},
+		{
+			name:    "release not activated",
+			version: "2024-10",
+		},
+		{
+			name:    "no report",
+			version: "2024-11",
+			wantErr: domain.ErrNoReleaseDiff,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := d.ReleaseDiff(t.Context(), tt.version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReleaseDiff() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && diff.Activated != tt.wantActivated {
+				t.Errorf("ReleaseDiff() activated = %v, want %v", diff.Activated, tt.wantActivated)
+			}
+		})
+	}
+}
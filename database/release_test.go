@@ -0,0 +1,121 @@
+package database
+
+import (
+	"errors"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+func TestDb_IpInfoAsOf(t *testing.T) {
+	d := testDb(t)
+	if err := d.MigrateUp(t.Context()); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+
+	if _, err := d.ExecContext(t.Context(), `
+		insert into ip_to_city_template (ip_start, ip_end, country, city)
+			values ('203.0.113.0', '203.0.113.255', 'AU', 'Sydney');
+		insert into releases (dataset, version, table_name, imported_at)
+			values ('city', '2024-09', 'ip_to_city_template', '2024-09-02T03:00:00Z');
+		insert into overrides (network, country, city)
+			values ('203.0.113.0/24', 'NZ', 'Auckland'), ('198.51.100.0/24', 'NZ', 'Wellington');`,
+	); err != nil {
+		t.Fatalf("failed to insert the release: %v", err)
+	}
+
+	ip := netip.MustParseAddr("203.0.113.10")
+
+	// the live lookups apply the override
+	dto, err := d.ipInfo(t.Context(), d.DB, ipInfoLookup("ip_to_city_template", "ip_to_asn_template", true), ip)
+	if err != nil {
+		t.Fatalf("ipInfo() error = %v", err)
+	}
+	if !dto.override.Id.Valid {
+		t.Errorf("ipInfo() didn't join the override")
+	}
+
+	ipInfo, err := d.IpInfoAsOf(t.Context(), ip, time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("IpInfoAsOf() error = %v", err)
+	}
+	if ipInfo.Source != domain.SourceDbIp || ipInfo.Country != "AU" || ipInfo.City != "Sydney" {
+		t.Errorf("IpInfoAsOf() = %s %s from %s, want the imported AU Sydney", ipInfo.Country, ipInfo.City,
+			ipInfo.Source)
+	}
+
+	// an override alone doesn't answer the historical lookups
+	_, err = d.IpInfoAsOf(t.Context(), netip.MustParseAddr("198.51.100.10"), time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC))
+	if !errors.Is(err, domain.ErrNoIpAddress) {
+		t.Errorf("IpInfoAsOf() error = %v, want %v", err, domain.ErrNoIpAddress)
+	}
+}
+
+func TestDb_dropExpiredReleases(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention int
+		want      []string
+	}{
+		{
+			name:      "past releases kept",
+			retention: 1,
+			want:      []string{"2024-08", "2024-09"},
+		},
+		{
+			name: "active release only",
+			want: []string{"2024-09"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := testDb(t)
+			if err := d.MigrateUp(t.Context()); err != nil {
+				t.Fatalf("MigrateUp() error = %v", err)
+			}
+			d.cfg.SetReleaseRetention(tt.retention)
+
+			if _, err := d.ExecContext(t.Context(), `
+				create table ip_to_city_2024_07 (like ip_to_city_template including all);
+				create table ip_to_city_2024_08 (like ip_to_city_template including all);
+				create table ip_to_city_2024_09 (like ip_to_city_template including all);
+				insert into releases (dataset, version, table_name, imported_at) values
+					('city', '2024-07', 'ip_to_city_2024_07', '2024-07-02T03:00:00Z'),
+					('city', '2024-08', 'ip_to_city_2024_08', '2024-08-02T03:00:00Z'),
+					('city', '2024-09', 'ip_to_city_2024_09', '2024-09-02T03:00:00Z');
+				update config set active_table = 'ip_to_city_2024_09' where dataset = 'city';`,
+			); err != nil {
+				t.Fatalf("failed to insert the releases: %v", err)
+			}
+			if _, err := d.loadConfig(t.Context()); err != nil {
+				t.Fatalf("loadConfig() error = %v", err)
+			}
+
+			if err := d.dropExpiredReleases(t.Context(), datasetByName(cityDataset)); err != nil {
+				t.Fatalf("dropExpiredReleases() error = %v", err)
+			}
+
+			var got []string
+			rows, err := d.QueryContext(t.Context(),
+				"select version from releases where dataset = 'city' order by version;",
+			)
+			if err != nil {
+				t.Fatalf("failed to list the releases: %v", err)
+			}
+			defer func() { _ = rows.Close() }()
+			for rows.Next() {
+				var version string
+				if err = rows.Scan(&version); err != nil {
+					t.Fatalf("failed to list the releases: %v", err)
+				}
+				got = append(got, version)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("dropExpiredReleases() kept %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
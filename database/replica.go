@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/streamdp/ip-info/config"
+	"github.com/streamdp/ip-info/domain"
+)
+
+const (
+	primaryPool = "primary"
+
+	// replicaRetryInterval is how long a failed replica is skipped before lookups try it again.
+	replicaRetryInterval = 30 * time.Second
+)
+
+type replica struct {
+	*sql.DB
+
+	name     string
+	failedAt atomic.Int64
+}
+
+func (r *replica) healthy(now time.Time) bool {
+	failedAt := r.failedAt.Load()
+
+	return failedAt == 0 || now.Sub(time.Unix(0, failedAt)) >= replicaRetryInterval
+}
+
+func (r *replica) markUnhealthy(now time.Time) {
+	r.failedAt.Store(now.UnixNano())
+}
+
+func (r *replica) markHealthy() {
+	r.failedAt.Store(0)
+}
+
+func openPool(url string, cfg *config.Database) (*sql.DB, error) {
+	pool, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+
+	pool.SetMaxOpenConns(cfg.MaxOpenConns())
+	pool.SetMaxIdleConns(cfg.MaxIdleConns())
+	pool.SetConnMaxLifetime(cfg.ConnMaxLifetime())
+
+	return pool, nil
+}
+
+// replica returns the next healthy replica in round-robin order, or nil when lookups should go to the primary.
+func (d *db) replica(now time.Time) *replica {
+	n := len(d.replicas)
+	if n == 0 {
+		return nil
+	}
+
+	start := int(d.nextReplica.Add(1) - 1)
+	for i := range n {
+		if r := d.replicas[(start+i)%n]; r.healthy(now) {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// read runs the lookup on a replica and falls back to the primary when there is no healthy one or the replica fails.
+// Every attempt gets its own request timeout, so a hanging replica doesn't leave the primary without time.
+func (d *db) read(ctx context.Context, lookup func(ctx context.Context, pool *sql.DB) error) error {
+	if r := d.replica(time.Now()); r != nil {
+		err := d.withTimeout(ctx, r.DB, lookup)
+		if err == nil || errors.Is(err, domain.ErrNoIpAddress) || errors.Is(err, domain.ErrNoRelease) || ctx.Err() != nil {
+			r.markHealthy()
+
+			return err
+		}
+
+		d.l.Printf("%s is unhealthy, falling back to the %s: %v", r.name, primaryPool, err)
+		r.markUnhealthy(time.Now())
+	}
+
+	return d.withTimeout(ctx, d.DB, lookup)
+}
+
+func (d *db) withTimeout(
+	ctx context.Context, pool *sql.DB, lookup func(ctx context.Context, pool *sql.DB) error,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.RequestTimeout())
+	defer cancel()
+
+	return lookup(ctx, pool)
+}
+
+func (d *db) PoolStats() []*domain.PoolStats {
+	now := time.Now()
+
+	stats := make([]*domain.PoolStats, 0, len(d.replicas)+1)
+	stats = append(stats, &domain.PoolStats{DBStats: d.Stats(), Pool: primaryPool, Healthy: true})
+	for _, r := range d.replicas {
+		stats = append(stats, &domain.PoolStats{DBStats: r.Stats(), Pool: r.name, Healthy: r.healthy(now)})
+	}
+
+	return stats
+}
+
+func replicaName(i int) string {
+	return fmt.Sprintf("replica_%d", i)
+}
@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/streamdp/ip-info/config"
+	"github.com/streamdp/ip-info/domain"
+)
+
+var errReplicaDown = errors.New("replica is down")
+
+func newReplicaTestDb(t *testing.T, replicas int) *db {
+	t.Helper()
+
+	open := func() *sql.DB {
+		pool, err := sql.Open("postgres", "postgres://localhost/dbip")
+		if err != nil {
+			t.Fatalf("failed to open pool: %v", err)
+		}
+		t.Cleanup(func() { _ = pool.Close() })
+
+		return pool
+	}
+
+	d := &db{
+		DB:  open(),
+		cfg: (&config.Database{}).SetRequestTimeout(1000),
+		l:   log.New(io.Discard, "", log.LstdFlags),
+	}
+	for i := range replicas {
+		d.replicas = append(d.replicas, &replica{DB: open(), name: replicaName(i)})
+	}
+
+	return d
+}
+
+func TestDb_replica(t *testing.T) {
+	now := time.Now()
+
+	d := newReplicaTestDb(t, 3)
+	d.replicas[1].markUnhealthy(now)
+
+	var got []string
+	for range 4 {
+		got = append(got, d.replica(now).name)
+	}
+	want := []string{"replica_0", "replica_2", "replica_2", "replica_0"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("replica() = %v, want %v", got, want)
+		}
+	}
+
+	if r := d.replica(now.Add(replicaRetryInterval)); r.name != "replica_1" {
+		t.Errorf("replica() = %v, want replica_1 to be retried", r.name)
+	}
+
+	for _, r := range d.replicas {
+		r.markUnhealthy(now)
+	}
+	if r := d.replica(now); r != nil {
+		t.Errorf("replica() = %v, want nil when all replicas are unhealthy", r.name)
+	}
+
+	if r := newReplicaTestDb(t, 0).replica(now); r != nil {
+		t.Errorf("replica() = %v, want nil without replicas", r.name)
+	}
+}
+
+func TestDb_read(t *testing.T) {
+	tests := []struct {
+		name        string
+		replicaErr  error
+		wantErr     error
+		wantPrimary bool
+		wantHealthy bool
+	}{
+		{
+			name:        "lookup on the replica",
+			wantHealthy: true,
+		},
+		{
+			name:        "ip address not found on the replica",
+			replicaErr:  domain.ErrNoIpAddress,
+			wantErr:     domain.ErrNoIpAddress,
+			wantHealthy: true,
+		},
+		{
+			name:        "no release for the date on the replica",
+			replicaErr:  domain.ErrNoRelease,
+			wantErr:     domain.ErrNoRelease,
+			wantHealthy: true,
+		},
+		{
+			name:        "fall back to the primary",
+			replicaErr:  errReplicaDown,
+			wantPrimary: true,
+			wantHealthy: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newReplicaTestDb(t, 1)
+
+			var usedPrimary bool
+			err := d.read(context.Background(), func(_ context.Context, pool *sql.DB) error {
+				if pool == d.DB {
+					usedPrimary = true
+
+					return nil
+				}
+
+				return tt.replicaErr
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("read() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if usedPrimary != tt.wantPrimary {
+				t.Errorf("read() used primary = %v, want %v", usedPrimary, tt.wantPrimary)
+			}
+			if healthy := d.replicas[0].healthy(time.Now()); healthy != tt.wantHealthy {
+				t.Errorf("read() replica healthy = %v, want %v", healthy, tt.wantHealthy)
+			}
+		})
+	}
+}
+
+func TestDb_PoolStats(t *testing.T) {
+	d := newReplicaTestDb(t, 2)
+	d.replicas[1].markUnhealthy(time.Now())
+
+	stats := d.PoolStats()
+	if len(stats) != 3 {
+		t.Fatalf("PoolStats() = %d pools, want 3", len(stats))
+	}
+
+	want := []struct {
+		pool    string
+		healthy bool
+	}{
+		{pool: primaryPool, healthy: true},
+		{pool: "replica_0", healthy: true},
+		{pool: "replica_1", healthy: false},
+	}
+	for i, w := range want {
+		if stats[i].Pool != w.pool || stats[i].Healthy != w.healthy {
+			t.Errorf("PoolStats()[%d] = %s %v, want %s %v", i, stats[i].Pool, stats[i].Healthy, w.pool, w.healthy)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Table names can't be bound as parameters, so the lookup queries are prepared once per release table and cached.
+// Only the active releases are prepared, and the statements of a release are closed once it's no longer active.
+const (
+	// the lookup is driven by the address rather than the city range, so that an override answers for the addresses
+	// no range of the release covers
+	ipInfoQuery = `select coalesce(host(c.ip_start), ''), coalesce(host(c.ip_end), ''), coalesce(c.continent, ''),
+	coalesce(c.country, ''), coalesce(c.state_prov, ''), coalesce(c.city, ''), coalesce(c.latitude, 0),
+	coalesce(c.longitude, 0), coalesce(c.ip_range::text, ''), c.ip_start is not null, a.as_number, a.as_organization,
+	o.id, o.network, o.continent, o.country, o.state_prov, o.city, o.latitude, o.longitude from (select 1) ip
+	left join %[1]s c on c.ip_range >>= $1::inet and c.ip_start <= $1::inet and c.ip_end >= $1::inet
+	left join %[2]s a on a.ip_range >>= $1::inet and a.ip_start <= $1::inet and a.ip_end >= $1::inet
+	left join lateral (select id, network::text as network, continent, country, state_prov, city, latitude, longitude
+		from overrides where network >>= $1::inet order by masklen(network) desc limit 1) o on true
+	where c.ip_start is not null or o.id is not null;`
+
+	providerQuery = `select ip_start, ip_end, continent, country, state_prov, city, latitude, longitude from %s
+	where ip_range >>= $1::inet and ip_start <= $1::inet and ip_end >= $1::inet limit 1;`
+
+	networkInfoQuery = `select ip_start, ip_end, continent, country, state_prov, city, latitude, longitude from %s
+	where ip_range && $1::inet
+	and ip_start <= host(broadcast($1::inet))::inet and ip_end >= host(network($1::inet))::inet
+	and ($2::inet is null or ip_start > $2::inet)
+	order by ip_start limit $3;`
+)
+
+// stmtKey identifies a prepared statement, statements belong to the pool they were prepared on.
+type stmtKey struct {
+	pool  *sql.DB
+	query string
+}
+
+type preparedStmt struct {
+	*sql.Stmt
+
+	tables []string
+	// refs counts the lookups holding the statement, an evicted statement is closed once the last of them is done
+	refs    int
+	evicted bool
+}
+
+// lookupQuery is a lookup on release tables. The queries of the active tables are prepared, the rest, like the
+// historical lookups, run unprepared so that past releases don't pile up statements.
+type lookupQuery struct {
+	query   string
+	tables  []string
+	prepare bool
+}
+
+func ipInfoLookup(cityTable, asnTable string, prepare bool) lookupQuery {
+	return lookupQuery{
+		query:   fmt.Sprintf(ipInfoQuery, cityTable, asnTable),
+		tables:  []string{cityTable, asnTable},
+		prepare: prepare,
+	}
+}
+
+func providerLookup(table string, prepare bool) lookupQuery {
+	return lookupQuery{query: fmt.Sprintf(providerQuery, table), tables: []string{table}, prepare: prepare}
+}
+
+func networkInfoLookup(cityTable string) lookupQuery {
+	return lookupQuery{query: fmt.Sprintf(networkInfoQuery, cityTable), tables: []string{cityTable}, prepare: true}
+}
+
+// queryRow runs the lookup, the statement is held only until the query starts, the row keeps it open afterwards.
+func (d *db) queryRow(ctx context.Context, pool *sql.DB, q lookupQuery, args ...any) (*sql.Row, error) {
+	if !q.prepare {
+		return pool.QueryRowContext(ctx, q.query, args...), nil
+	}
+
+	stmt, err := d.stmt(ctx, pool, q.query, q.tables...)
+	if err != nil {
+		return nil, err
+	}
+	defer d.releaseStmt(stmt)
+
+	return stmt.QueryRowContext(ctx, args...), nil
+}
+
+// query runs the lookup like queryRow does.
+func (d *db) query(ctx context.Context, pool *sql.DB, q lookupQuery, args ...any) (*sql.Rows, error) {
+	if !q.prepare {
+		return pool.QueryContext(ctx, q.query, args...)
+	}
+
+	stmt, err := d.stmt(ctx, pool, q.query, q.tables...)
+	if err != nil {
+		return nil, err
+	}
+	defer d.releaseStmt(stmt)
+
+	return stmt.QueryContext(ctx, args...)
+}
+
+// stmt returns the prepared statement for the query on the tables, preparing it on the first use. The statement is
+// held until releaseStmt is called.
+func (d *db) stmt(ctx context.Context, pool *sql.DB, query string, tables ...string) (*preparedStmt, error) {
+	key := stmtKey{pool: pool, query: query}
+
+	d.stmtMu.Lock()
+	defer d.stmtMu.Unlock()
+
+	if stmt, ok := d.stmts[key]; ok {
+		stmt.refs++
+
+		return stmt, nil
+	}
+
+	prepared, err := pool.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	stmt := &preparedStmt{Stmt: prepared, tables: tables, refs: 1}
+	d.stmts[key] = stmt
+
+	return stmt, nil
+}
+
+func (d *db) releaseStmt(stmt *preparedStmt) {
+	d.stmtMu.Lock()
+	defer d.stmtMu.Unlock()
+
+	stmt.refs--
+	if stmt.evicted && stmt.refs == 0 {
+		d.closeStmt(stmt)
+	}
+}
+
+// prepareStatements prepares the lookup queries of the active tables on every pool, so that the first requests
+// after a swap don't pay for it, and evicts the statements of the tables no longer active.
+func (d *db) prepareStatements(ctx context.Context) error {
+	cityTable, asnTable, _ := d.activeRelease()
+	providerTables := d.activeProviderTables()
+
+	active := []string{cityTable, asnTable}
+	for _, t := range providerTables {
+		active = append(active, t.table)
+	}
+	d.evictStatements(func(stmt *preparedStmt) bool {
+		return slices.ContainsFunc(stmt.tables, func(table string) bool { return !slices.Contains(active, table) })
+	})
+
+	pools := []*sql.DB{d.DB}
+	for _, r := range d.replicas {
+		pools = append(pools, r.DB)
+	}
+
+	queries := []lookupQuery{ipInfoLookup(cityTable, asnTable, true), networkInfoLookup(cityTable)}
+	for _, t := range providerTables {
+		queries = append(queries, providerLookup(t.table, true))
+	}
+
+	var errs []error
+	for _, pool := range pools {
+		for _, q := range queries {
+			stmt, err := d.stmt(ctx, pool, q.query, q.tables...)
+			if err != nil {
+				errs = append(errs, err)
+
+				continue
+			}
+			d.releaseStmt(stmt)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (d *db) closeStatements() {
+	d.evictStatements(func(*preparedStmt) bool { return true })
+}
+
+// evictStatements removes the matching statements from the cache, the ones still held by lookups are closed by the
+// last of them.
+func (d *db) evictStatements(match func(stmt *preparedStmt) bool) {
+	d.stmtMu.Lock()
+	defer d.stmtMu.Unlock()
+
+	for key, stmt := range d.stmts {
+		if !match(stmt) {
+			continue
+		}
+		delete(d.stmts, key)
+		stmt.evicted = true
+		if stmt.refs == 0 {
+			d.closeStmt(stmt)
+		}
+	}
+}
+
+func (d *db) closeStmt(stmt *preparedStmt) {
+	if err := stmt.Close(); err != nil {
+		d.l.Printf("failed to close statement: %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"testing"
+)
+
+var benchmarkIp = netip.MustParseAddr("8.8.8.8")
+
+// benchmarkDb migrates the test database and imports the range of the benchmarked address into the active tables.
+func benchmarkDb(b *testing.B) *db {
+	b.Helper()
+
+	d := testDb(b)
+	if err := d.MigrateUp(b.Context()); err != nil {
+		b.Fatalf("MigrateUp() error = %v", err)
+	}
+	if _, err := d.loadConfig(b.Context()); err != nil {
+		b.Fatalf("failed to load config: %v", err)
+	}
+
+	cityTable, asnTable, _ := d.activeRelease()
+	if _, err := d.ExecContext(b.Context(), fmt.Sprintf(`
+		insert into %s (ip_start, ip_end, country, city) values ('8.8.8.0', '8.8.8.255', 'US', 'Mountain View');
+		insert into %s (ip_start, ip_end, as_number, as_organization) values ('8.8.8.0', '8.8.8.255', 15169, 'Google');`,
+		cityTable, asnTable,
+	)); err != nil {
+		b.Fatalf("failed to insert ranges: %v", err)
+	}
+
+	return d
+}
+
+func BenchmarkIpInfo(b *testing.B) {
+	d := benchmarkDb(b)
+
+	for b.Loop() {
+		if _, err := d.IpInfo(context.Background(), benchmarkIp); err != nil {
+			b.Fatalf("IpInfo() error = %v", err)
+		}
+	}
+}
+
+// BenchmarkIpInfoUnprepared runs the same query without a prepared statement, so Postgres parses and plans it on
+// every call.
+func BenchmarkIpInfoUnprepared(b *testing.B) {
+	d := benchmarkDb(b)
+
+	cityTable, asnTable, _ := d.activeRelease()
+	query := fmt.Sprintf(ipInfoQuery, cityTable, asnTable)
+
+	for b.Loop() {
+		rows, err := d.QueryContext(context.Background(), query, benchmarkIp.String())
+		if err != nil {
+			b.Fatalf("query error = %v", err)
+		}
+		if err = rows.Close(); err != nil {
+			b.Fatalf("close rows error = %v", err)
+		}
+	}
+}
+
+func TestDb_evictStatements(t *testing.T) {
+	d := testDb(t)
+
+	held, err := d.stmt(t.Context(), d.DB, "select 1;", "ip_to_city_one")
+	if err != nil {
+		t.Fatalf("stmt() error = %v", err)
+	}
+	idle, err := d.stmt(t.Context(), d.DB, "select 2;", "ip_to_city_two")
+	if err != nil {
+		t.Fatalf("stmt() error = %v", err)
+	}
+	d.releaseStmt(idle)
+
+	d.closeStatements()
+	if len(d.stmts) != 0 {
+		t.Fatalf("closeStatements() left %d statements", len(d.stmts))
+	}
+
+	var n int
+	if err = held.QueryRowContext(t.Context()).Scan(&n); err != nil {
+		t.Errorf("evicted statement still held by a lookup failed: %v", err)
+	}
+	if err = idle.QueryRowContext(t.Context()).Scan(&n); err == nil {
+		t.Errorf("evicted idle statement isn't closed")
+	}
+
+	d.releaseStmt(held)
+	if err = held.QueryRowContext(t.Context()).Scan(&n); err == nil {
+		t.Errorf("evicted statement isn't closed by the last lookup")
+	}
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+// DatasetStatus returns the active release of every dataset with its number of ranges.
+func (d *db) DatasetStatus(ctx context.Context) ([]*domain.DatasetStatus, error) {
+	cfg, err := d.readConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var status []*domain.DatasetStatus
+	for _, ds := range d.allDatasets() {
+		dto, ok := cfg[ds.name]
+		if !ok {
+			continue
+		}
+
+		s := &domain.DatasetStatus{
+			Dataset: ds.name,
+			DatabaseConfig: domain.DatabaseConfig{
+				LastUpdate:  dto.LastUpdate,
+				ActiveTable: dto.ActiveTable,
+				BackupTable: dto.BackupTable,
+			},
+		}
+		if err = d.QueryRowContext(ctx, fmt.Sprintf("select count(*) from %s;", dto.ActiveTable)).Scan(
+			&s.Rows,
+		); err != nil {
+			return nil, fmt.Errorf("failed to count %s rows: %w", ds.name, err)
+		}
+		status = append(status, s)
+	}
+
+	return status, nil
+}
@@ -0,0 +1,15 @@
+package domain
+
+// AddressType classifies an address by the IANA IPv4 and IPv6 special-purpose address registries.
+type AddressType string
+
+const (
+	AddressTypeGlobal        AddressType = "global"
+	AddressTypePrivate       AddressType = "private"
+	AddressTypeLoopback      AddressType = "loopback"
+	AddressTypeLinkLocal     AddressType = "link_local"
+	AddressTypeCgnat         AddressType = "cgnat"
+	AddressTypeDocumentation AddressType = "documentation"
+	AddressTypeMulticast     AddressType = "multicast"
+	AddressTypeReserved      AddressType = "reserved"
+)
@@ -7,3 +7,10 @@ type DatabaseConfig struct {
 	ActiveTable string    `db:"active_table" json:"active_table"`
 	BackupTable string    `db:"backup_table" json:"backup_table"`
 }
+
+// DatasetStatus is the active release of a dataset along with its number of ranges.
+type DatasetStatus struct {
+	Dataset string `json:"dataset"`
+	DatabaseConfig
+	Rows int64 `json:"rows"`
+}
@@ -2,17 +2,40 @@ package domain
 
 import (
 	"encoding/json"
-	"net"
+	"errors"
+	"net/netip"
+	"time"
 )
 
+// ErrNoIpAddress is returned when no imported range contains the address.
+var ErrNoIpAddress = errors.New("no ip address in the database")
+
 type IpInfo struct {
-	Ip        net.IP  `json:"ip"`
-	Continent string  `db:"continent"  json:"continent"`
-	Country   string  `db:"country"    json:"country"`
-	StateProv string  `db:"state_prov" json:"state_prov"`
-	City      string  `db:"city"       json:"city"`
-	Latitude  float64 `db:"latitude"   json:"latitude"`
-	Longitude float64 `db:"longitude"  json:"longitude"`
+	Ip            netip.Addr  `json:"ip"`
+	Continent     string      `db:"continent"  json:"continent"`
+	Country       string      `db:"country"    json:"country"`
+	StateProv     string      `db:"state_prov" json:"state_prov"`
+	City          string      `db:"city"       json:"city"`
+	Latitude      float64     `db:"latitude"   json:"latitude"`
+	Longitude     float64     `db:"longitude"  json:"longitude"`
+	AddressType   AddressType `json:"address_type"`
+	Network       string      `json:"network"`
+	Source        string      `json:"source,omitempty"`
+	Sources       Sources     `json:"sources,omitempty"`
+	DataVersion   string      `json:"data_version"`
+	DataUpdatedAt time.Time   `json:"data_updated_at,omitzero"`
+	Asn           uint32      `json:"asn"`
+	AsOrg         string      `json:"as_org"`
+	TimeZone      string      `json:"time_zone"`
+	UtcOffset     string      `json:"utc_offset"`
+	IsDst         bool        `json:"is_dst"`
+	CountryName   string      `json:"country_name,omitempty"`
+	ContinentName string      `json:"continent_name,omitempty"`
+	IsEu          *bool       `json:"is_eu,omitempty"`
+	Currency      string      `json:"currency,omitempty"`
+	CallingCode   string      `json:"calling_code,omitempty"`
+	CountryFlag   string      `json:"country_flag,omitempty"`
+	Lang          string      `json:"lang,omitempty"`
 }
 
 func (i *IpInfo) Bytes() []byte {
@@ -1,8 +1,9 @@
 package domain
 
 import (
-	"net"
+	"net/netip"
 	"testing"
+	"time"
 )
 
 func TestIpInfo_String(t *testing.T) {
@@ -14,20 +15,30 @@ func TestIpInfo_String(t *testing.T) {
 		{
 			name: "marshal ip info struct",
 			info: &IpInfo{
-				Ip:        net.ParseIP("8.8.8.8"),
-				Continent: "NA",
-				Country:   "US",
-				StateProv: "California",
-				City:      "Mountain View",
-				Latitude:  -122.085,
-				Longitude: 37.4223,
+				Ip:            netip.MustParseAddr("8.8.8.8"),
+				Continent:     "NA",
+				Country:       "US",
+				StateProv:     "California",
+				City:          "Mountain View",
+				Latitude:      -122.085,
+				Longitude:     37.4223,
+				AddressType:   AddressTypeGlobal,
+				Network:       "8.8.8.0/24",
+				Source:        SourceDbIp,
+				DataVersion:   "2024-09",
+				DataUpdatedAt: time.Date(2024, 9, 2, 0, 0, 0, 0, time.UTC),
+				Asn:           15169,
+				AsOrg:         "Google LLC",
+				TimeZone:      "America/Los_Angeles",
+				UtcOffset:     "-07:00",
+				IsDst:         true,
 			},
-			want: "{\n  \"ip\": \"8.8.8.8\",\n  \"continent\": \"NA\",\n  \"country\": \"US\",\n  \"state_prov\": \"California\",\n  \"city\": \"Mountain View\",\n  \"latitude\": -122.085,\n  \"longitude\": 37.4223\n}",
+			want: "{\n  \"ip\": \"8.8.8.8\",\n  \"continent\": \"NA\",\n  \"country\": \"US\",\n  \"state_prov\": \"California\",\n  \"city\": \"Mountain View\",\n  \"latitude\": -122.085,\n  \"longitude\": 37.4223,\n  \"address_type\": \"global\",\n  \"network\": \"8.8.8.0/24\",\n  \"source\": \"dbip\",\n  \"data_version\": \"2024-09\",\n  \"data_updated_at\": \"2024-09-02T00:00:00Z\",\n  \"asn\": 15169,\n  \"as_org\": \"Google LLC\",\n  \"time_zone\": \"America/Los_Angeles\",\n  \"utc_offset\": \"-07:00\",\n  \"is_dst\": true\n}",
 		},
 		{
 			name: "marshal empty ip info struct",
 			info: &IpInfo{},
-			want: "{\n  \"ip\": \"\",\n  \"continent\": \"\",\n  \"country\": \"\",\n  \"state_prov\": \"\",\n  \"city\": \"\",\n  \"latitude\": 0,\n  \"longitude\": 0\n}",
+			want: "{\n  \"ip\": \"\",\n  \"continent\": \"\",\n  \"country\": \"\",\n  \"state_prov\": \"\",\n  \"city\": \"\",\n  \"latitude\": 0,\n  \"longitude\": 0,\n  \"address_type\": \"\",\n  \"network\": \"\",\n  \"data_version\": \"\",\n  \"asn\": 0,\n  \"as_org\": \"\",\n  \"time_zone\": \"\",\n  \"utc_offset\": \"\",\n  \"is_dst\": false\n}",
 		},
 	}
 	for _, tt := range tests {
@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// ExpandCountry adds the country and continent names, EU membership, currency, calling code and flag to the response.
+const ExpandCountry = "country"
+
+// LookupOptions holds the opt-in parts of the lookup response requested by the client.
+type LookupOptions struct {
+	Expand []string
+	// Language is a language tag or an Accept-Language header value the expanded names are localized to.
+	Language string
+	// AsOf locates the address in the releases that were active at the time instead of the current ones.
+	AsOf time.Time
+}
+
+func (o *LookupOptions) Expands(field string) bool {
+	if o == nil {
+		return false
+	}
+
+	for _, f := range o.Expand {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,10 @@
+package domain
+
+import "time"
+
+// Migration is a versioned schema change, AppliedAt is zero while it's pending.
+type Migration struct {
+	Version   int       `json:"version"`
+	Name      string    `json:"name"`
+	AppliedAt time.Time `json:"applied_at,omitzero"`
+}
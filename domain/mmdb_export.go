@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// MmdbExport is the active release of a dataset written in the MaxMind DB format.
+type MmdbExport struct {
+	Dataset string `json:"dataset"`
+	// Version identifies the exported data, it's the release version followed by a fingerprint of the overrides when
+	// there are any.
+	Version string    `json:"version"`
+	Ranges  int64     `json:"ranges"`
+	BuiltAt time.Time `json:"built_at"`
+	// Data is the database, it's only kept for the exports served by the api.
+	Data []byte `json:"-"`
+}
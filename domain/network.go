@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"encoding/json"
+	"net/netip"
+	"strings"
+)
+
+type IpRange struct {
+	IpStart   netip.Addr `db:"ip_start"   json:"ip_start"`
+	IpEnd     netip.Addr `db:"ip_end"     json:"ip_end"`
+	Continent string     `db:"continent"  json:"continent"`
+	Country   string     `db:"country"    json:"country"`
+	StateProv string     `db:"state_prov" json:"state_prov"`
+	City      string     `db:"city"       json:"city"`
+	Latitude  float64    `db:"latitude"   json:"latitude"`
+	Longitude float64    `db:"longitude"  json:"longitude"`
+	// Asn and AsOrg are only set for the ranges of the asn dataset.
+	Asn   uint32 `db:"as_number"       json:"asn,omitempty"`
+	AsOrg string `db:"as_organization" json:"as_org,omitempty"`
+}
+
+type NetworkInfo struct {
+	Network       string     `json:"network"`
+	Ranges        []*IpRange `json:"ranges"`
+	NextPageToken string     `json:"next_page_token"`
+}
+
+func (n *NetworkInfo) Bytes() []byte {
+	b, _ := json.MarshalIndent(n, "", "  ")
+
+	return b
+}
+
+// RangeNetwork returns the CIDR of the range when it is exactly a prefix, otherwise "start-end".
+func RangeNetwork(start, end netip.Addr) string {
+	for bits := 0; bits <= start.BitLen(); bits++ {
+		if p := netip.PrefixFrom(start, bits).Masked(); p.Addr() == start && LastAddr(p) == end {
+			return p.String()
+		}
+	}
+
+	return start.String() + "-" + end.String()
+}
+
+// IntersectNetwork narrows the network of a lookup, a CIDR or a "start-end" range, to the addresses from start to end,
+// both cover the looked up address. The network is replaced when it can't be parsed.
+func IntersectNetwork(network string, start, end netip.Addr) string {
+	s, e, ok := parseNetwork(network)
+	if !ok || s.BitLen() != start.BitLen() {
+		return RangeNetwork(start, end)
+	}
+
+	if s.Less(start) {
+		s = start
+	}
+	if end.Less(e) {
+		e = end
+	}
+
+	return RangeNetwork(s, e)
+}
+
+// LastAddr returns the last address of the prefix.
+func LastAddr(prefix netip.Prefix) netip.Addr {
+	b := prefix.Addr().AsSlice()
+	for i := range b {
+		hostBits := max(0, min(8, (i+1)*8-prefix.Bits()))
+		b[i] |= byte(1<<hostBits - 1)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+
+	return addr
+}
+
+func parseNetwork(network string) (netip.Addr, netip.Addr, bool) {
+	if prefix, err := netip.ParsePrefix(network); err == nil {
+		return prefix.Masked().Addr(), LastAddr(prefix), true
+	}
+
+	start, end, ok := strings.Cut(network, "-")
+	if !ok {
+		return netip.Addr{}, netip.Addr{}, false
+	}
+	s, errStart := netip.ParseAddr(start)
+	e, errEnd := netip.ParseAddr(end)
+
+	return s, e, errStart == nil && errEnd == nil
+}
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestRangeNetwork(t *testing.T) {
+	tests := []struct {
+		name  string
+		start string
+		end   string
+		want  string
+	}{
+		{name: "prefix", start: "8.8.8.0", end: "8.8.8.255", want: "8.8.8.0/24"},
+		{name: "single address", start: "8.8.8.8", end: "8.8.8.8", want: "8.8.8.8/32"},
+		{name: "whole address space", start: "0.0.0.0", end: "255.255.255.255", want: "0.0.0.0/0"},
+		{name: "range", start: "8.8.8.0", end: "8.8.9.127", want: "8.8.8.0-8.8.9.127"},
+		{name: "ipv6 prefix", start: "2001:db8::", end: "2001:db8::ffff", want: "2001:db8::/112"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RangeNetwork(netip.MustParseAddr(tt.start), netip.MustParseAddr(tt.end)); got != tt.want {
+				t.Errorf("RangeNetwork() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
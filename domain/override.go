@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"net/netip"
+	"time"
+)
+
+// Sources of the location returned by the lookups, the providers configured besides db-ip are named by their config.
+const (
+	SourceDbIp     = "dbip"
+	SourceOverride = "override"
+)
+
+// ErrNoOverride is returned when no override has the id, ErrOverrideExists when another one has the network.
+var (
+	ErrNoOverride     = errors.New("no override with the id")
+	ErrOverrideExists = errors.New("override for the network already exists")
+)
+
+// Override corrects the location of the addresses in the network, fields left nil keep the imported values. The most
+// specific override covering an address wins.
+type Override struct {
+	Id        int64        `json:"id"`
+	Network   netip.Prefix `json:"network"`
+	Continent *string      `json:"continent,omitempty"`
+	Country   *string      `json:"country,omitempty"`
+	StateProv *string      `json:"state_prov,omitempty"`
+	City      *string      `json:"city,omitempty"`
+	Latitude  *float64     `json:"latitude,omitempty"`
+	Longitude *float64     `json:"longitude,omitempty"`
+	Comment   string       `json:"comment,omitempty"`
+	CreatedAt time.Time    `json:"created_at,omitzero"`
+	UpdatedAt time.Time    `json:"updated_at,omitzero"`
+}
+
+// Apply replaces the location fields of ipInfo set in the override and marks them and the lookup as overridden. The
+// network is narrowed to the override when it's more specific than the imported range.
+func (o *Override) Apply(ipInfo *IpInfo) {
+	if ipInfo.Sources == nil {
+		ipInfo.Sources = Sources{}
+	}
+	if o.Continent != nil {
+		ipInfo.Continent = *o.Continent
+		ipInfo.Sources[FieldContinent] = SourceOverride
+	}
+	if o.Country != nil {
+		ipInfo.Country = *o.Country
+		ipInfo.Sources[FieldCountry] = SourceOverride
+	}
+	if o.StateProv != nil {
+		ipInfo.StateProv = *o.StateProv
+		ipInfo.Sources[FieldStateProv] = SourceOverride
+	}
+	if o.City != nil {
+		ipInfo.City = *o.City
+		ipInfo.Sources[FieldCity] = SourceOverride
+	}
+	if o.Latitude != nil && o.Longitude != nil {
+		ipInfo.Latitude = *o.Latitude
+		ipInfo.Longitude = *o.Longitude
+		ipInfo.Sources[FieldLocation] = SourceOverride
+	}
+	ipInfo.Network = IntersectNetwork(ipInfo.Network, o.Network.Masked().Addr(), LastAddr(o.Network))
+	ipInfo.Source = SourceOverride
+}
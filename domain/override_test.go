@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestOverride_Apply(t *testing.T) {
+	city, latitude, longitude := "Wellington", -41.2865, 174.7762
+
+	ipInfo := &IpInfo{
+		Ip:        netip.MustParseAddr("203.0.113.10"),
+		Continent: "OC",
+		Country:   "AU",
+		StateProv: "New South Wales",
+		City:      "Sydney",
+		Latitude:  -33.8688,
+		Longitude: 151.209,
+		Network:   "203.0.112.0/22",
+		Source:    SourceDbIp,
+		Sources: Sources{
+			FieldContinent: SourceDbIp,
+			FieldCountry:   SourceDbIp,
+			FieldStateProv: SourceDbIp,
+			FieldCity:      "acme",
+			FieldLocation:  "acme",
+		},
+	}
+	country := "NZ"
+
+	(&Override{
+		Network:   netip.MustParsePrefix("203.0.113.0/24"),
+		Country:   &country,
+		City:      &city,
+		Latitude:  &latitude,
+		Longitude: &longitude,
+	}).Apply(ipInfo)
+
+	want := &IpInfo{
+		Ip:        netip.MustParseAddr("203.0.113.10"),
+		Continent: "OC",
+		Country:   "NZ",
+		StateProv: "New South Wales",
+		City:      "Wellington",
+		Latitude:  -41.2865,
+		Longitude: 174.7762,
+		Network:   "203.0.113.0/24",
+		Source:    SourceOverride,
+		Sources: Sources{
+			FieldContinent: SourceDbIp,
+			FieldCountry:   SourceOverride,
+			FieldStateProv: SourceDbIp,
+			FieldCity:      SourceOverride,
+			FieldLocation:  SourceOverride,
+		},
+	}
+	if !reflect.DeepEqual(ipInfo, want) {
+		t.Errorf("Apply() = %v, want %v", ipInfo, want)
+	}
+}
+
+func TestOverride_ApplyNetwork(t *testing.T) {
+	country := "NZ"
+
+	tests := []struct {
+		name     string
+		override string
+		network  string
+		want     string
+	}{
+		{
+			name:     "override more specific than the range",
+			override: "203.0.113.0/24",
+			network:  "203.0.112.0/22",
+			want:     "203.0.113.0/24",
+		},
+		{
+			name:     "override broader than the range",
+			override: "203.0.112.0/22",
+			network:  "203.0.113.0/24",
+			want:     "203.0.113.0/24",
+		},
+		{
+			name:     "range that isn't a prefix",
+			override: "203.0.112.0/22",
+			network:  "203.0.113.10-203.0.113.20",
+			want:     "203.0.113.10-203.0.113.20",
+		},
+		{
+			name:     "range crossing the override",
+			override: "203.0.113.0/24",
+			network:  "203.0.112.200-203.0.113.20",
+			want:     "203.0.113.0-203.0.113.20",
+		},
+		{
+			name:     "no imported network",
+			override: "2001:db8::/32",
+			want:     "2001:db8::/32",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ipInfo := &IpInfo{Network: tt.network}
+			(&Override{Network: netip.MustParsePrefix(tt.override), Country: &country}).Apply(ipInfo)
+
+			if ipInfo.Network != tt.want {
+				t.Errorf("Apply() network = %v, want %v", ipInfo.Network, tt.want)
+			}
+		})
+	}
+}
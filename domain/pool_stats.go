@@ -0,0 +1,11 @@
+package domain
+
+import "database/sql"
+
+// PoolStats describes a database connection pool, the primary is always healthy.
+type PoolStats struct {
+	sql.DBStats
+
+	Pool    string
+	Healthy bool
+}
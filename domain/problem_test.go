@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestNewProblem(t *testing.T) {
+	type args struct {
+		status   int
+		code     string
+		detail   string
+		instance string
+	}
+	tests := []struct {
+		name string
+		args args
+		want *Problem
+	}{
+		{
+			name: "get problem",
+			args: args{
+				status:   http.StatusNotFound,
+				code:     "IP_ADDRESS_NOT_FOUND",
+				detail:   "no ip address in the database",
+				instance: "/v1/ip-info",
+			},
+			want: &Problem{
+				Type:     "about:blank",
+				Title:    "Not Found",
+				Status:   http.StatusNotFound,
+				Detail:   "no ip address in the database",
+				Instance: "/v1/ip-info",
+				Code:     "IP_ADDRESS_NOT_FOUND",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewProblem(tt.args.status, tt.args.code, tt.args.detail, tt.args.instance)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewProblem() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProblem_Bytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		problem *Problem
+		want    []byte
+	}{
+		{
+			name:    "marshal problem struct",
+			problem: NewProblem(http.StatusTooManyRequests, "RATE_LIMIT_EXCEEDED", "rate limit exceeded", ""),
+			want: []byte("{\n  \"type\": \"about:blank\",\n  \"title\": \"Too Many Requests\",\n  \"status\": 429,\n" +
+				"  \"detail\": \"rate limit exceeded\",\n  \"code\": \"RATE_LIMIT_EXCEEDED\"\n}"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.problem.Bytes(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Bytes() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
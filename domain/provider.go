@@ -0,0 +1,25 @@
+package domain
+
+// Location fields merged from the providers, the sources of a lookup are keyed by them. The location field stands for
+// the latitude and longitude, which always come from the same provider.
+const (
+	FieldContinent = "continent"
+	FieldCountry   = "country"
+	FieldStateProv = "state_prov"
+	FieldCity      = "city"
+	FieldLocation  = "location"
+)
+
+var LocationFields = []string{FieldContinent, FieldCountry, FieldStateProv, FieldCity, FieldLocation}
+
+// Provider is a dataset the location fields of the lookups are taken from, its attribution has to be shown along
+// with the data it supplied.
+type Provider struct {
+	Name        string `json:"name"`
+	Attribution string `json:"attribution"`
+	// Location fields the provider may supply, it's used for a field when the providers preceding it have no value.
+	Fields []string `json:"fields"`
+}
+
+// Sources maps the location fields of a lookup to the provider that supplied them.
+type Sources map[string]string
@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNoRelease is returned when no release of a dataset was active at the date of a lookup or export.
+var ErrNoRelease = errors.New("no database release for the date")
+
+// Release is a monthly import of a dataset, the active one answers the lookups without as_of.
+type Release struct {
+	Dataset    string    `json:"dataset"`
+	Version    string    `json:"version"`
+	ImportedAt time.Time `json:"imported_at"`
+	Active     bool      `json:"active"`
+}
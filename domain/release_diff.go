@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNoReleaseDiff is returned when no diff report was stored for the release.
+var ErrNoReleaseDiff = errors.New("no diff report for the release")
+
+// ReleaseDiff compares an imported release with the one it replaces, ranges are matched by their bounds. The report
+// is stored before the release is activated, Activated tells whether it was published to the releases since.
+type ReleaseDiff struct {
+	Dataset         string         `json:"dataset"`
+	Version         string         `json:"version"`
+	PreviousVersion string         `json:"previous_version,omitempty"`
+	PreviousRanges  int64          `json:"previous_ranges"`
+	Added           int64          `json:"added"`
+	Removed         int64          `json:"removed"`
+	Changed         int64          `json:"changed"`
+	Countries       []*CountryDiff `json:"countries"`
+	Rejected        bool           `json:"rejected"`
+	Activated       bool           `json:"activated"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+// CountryDiff counts the ranges of a country that were added, removed, or got another country or city. Removed
+// ranges are counted in their previous country, the others in the new one.
+type CountryDiff struct {
+	Country string `json:"country"`
+	Added   int64  `json:"added"`
+	Removed int64  `json:"removed"`
+	Changed int64  `json:"changed"`
+}
+
+// ChangedPercent returns the share of the previous ranges touched by the release, zero for the first release.
+func (d *ReleaseDiff) ChangedPercent() float64 {
+	if d.PreviousRanges == 0 {
+		return 0
+	}
+
+	return float64(d.Added+d.Removed+d.Changed) / float64(d.PreviousRanges) * 100
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "testing"
+
+func TestReleaseDiff_ChangedPercent(t *testing.T) {
+	tests := []struct {
+		name string
+		diff *ReleaseDiff
+		want float64
+	}{
+		{
+			name: "first release",
+			diff: &ReleaseDiff{Added: 100},
+			want: 0,
+		},
+		{
+			name: "changed ranges",
+			diff: &ReleaseDiff{PreviousRanges: 200, Added: 10, Removed: 4, Changed: 6},
+			want: 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.diff.ChangedPercent(); got != tt.want {
+				t.Errorf("ChangedPercent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
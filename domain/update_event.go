@@ -0,0 +1,38 @@
+package domain
+
+import "time"
+
+// Lifecycle events of the update runs, sent to the webhooks.
+const (
+	UpdateEventStarted   = "update.started"
+	UpdateEventSucceeded = "update.succeeded"
+	UpdateEventFailed    = "update.failed"
+	UpdateEventSkipped   = "update.skipped"
+)
+
+// ImportedRelease is a release imported by an update run.
+type ImportedRelease struct {
+	Dataset string `json:"dataset"`
+	Version string `json:"version"`
+	Rows    int64  `json:"rows"`
+}
+
+// UpdateReport sums up an update run, the releases are the ones it imported.
+type UpdateReport struct {
+	NextUpdate time.Duration
+	Releases   []*ImportedRelease
+}
+
+// UpdateEvent is the payload of the update webhooks.
+type UpdateEvent struct {
+	Event    string    `json:"event"`
+	Instance string    `json:"instance"`
+	Time     time.Time `json:"time"`
+	// Duration of the run in milliseconds, set once it's over.
+	DurationMs int64              `json:"duration_ms,omitempty"`
+	Releases   []*ImportedRelease `json:"releases,omitempty"`
+	Error      string             `json:"error,omitempty"`
+	// Failed attempts of the run, the failed event is sent on each of them.
+	Attempts int       `json:"attempts,omitempty"`
+	NextRun  time.Time `json:"next_run,omitzero"`
+}
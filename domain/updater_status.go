@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// Update modes of an instance, the reloading ones only pick up the releases imported by the others.
+const (
+	UpdateModeImport = "import"
+	UpdateModeReload = "reload"
+)
+
+// UpdaterStatus describes the database update runs of the instance.
+type UpdaterStatus struct {
+	Mode string `json:"mode"`
+	// Cron expression of the runs, empty when they follow the monthly releases.
+	Schedule  string    `json:"schedule,omitempty"`
+	NextRun   time.Time `json:"next_run,omitzero"`
+	LastRun   time.Time `json:"last_run,omitzero"`
+	LastError string    `json:"last_error,omitempty"`
+	// Failed attempts of the current run, reset once it succeeds.
+	Attempts int `json:"attempts"`
+}
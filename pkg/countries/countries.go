@@ -0,0 +1,98 @@
+package countries
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+type Country struct {
+	Code        string
+	Name        string
+	Continent   string
+	Currency    string
+	CallingCode string
+	IsEu        bool
+}
+
+//go:embed countries.csv
+var countriesCsv []byte
+
+var continents = map[string]string{
+	"AF": "Africa",
+	"AN": "Antarctica",
+	"AS": "Asia",
+	"EU": "Europe",
+	"NA": "North America",
+	"OC": "Oceania",
+	"SA": "South America",
+}
+
+var countries = mustLoad(countriesCsv)
+
+// Lookup returns the reference data of the country with the ISO 3166-1 alpha-2 code.
+func Lookup(code string) (*Country, bool) {
+	c, ok := countries[strings.ToUpper(code)]
+
+	return c, ok
+}
+
+// ContinentName returns the English name of the continent with the two-letter code used by db-ip.com.
+func ContinentName(code string) string {
+	return continents[strings.ToUpper(code)]
+}
+
+// Flag returns the flag emoji of the country, built from the regional indicator symbols of its code.
+func Flag(code string) string {
+	if len(code) != 2 {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		if r < 'A' || r > 'Z' {
+			return ""
+		}
+		b.WriteRune(r - 'A' + 0x1F1E6)
+	}
+
+	return b.String()
+}
+
+func mustLoad(b []byte) map[string]*Country {
+	m, err := load(b)
+	if err != nil {
+		panic(err)
+	}
+
+	return m
+}
+
+func load(b []byte) (map[string]*Country, error) {
+	records, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read countries: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("failed to read countries: empty dataset")
+	}
+
+	m := make(map[string]*Country, len(records)-1)
+	for _, r := range records[1:] {
+		if len(r) != 6 {
+			return nil, fmt.Errorf("failed to read countries: wrong record %v", r)
+		}
+		m[r[0]] = &Country{
+			Code:        r[0],
+			Name:        r[1],
+			Continent:   r[2],
+			Currency:    r[3],
+			CallingCode: r[4],
+			IsEu:        r[5] == "1",
+		}
+	}
+
+	return m, nil
+}
@@ -0,0 +1,115 @@
+package countries
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name   string
+		code   string
+		want   *Country
+		wantOk bool
+	}{
+		{
+			name: "eu member",
+			code: "DE",
+			want: &Country{
+				Code:        "DE",
+				Name:        "Germany",
+				Continent:   "EU",
+				Currency:    "EUR",
+				CallingCode: "+49",
+				IsEu:        true,
+			},
+			wantOk: true,
+		},
+		{
+			name: "lower case code",
+			code: "us",
+			want: &Country{
+				Code:        "US",
+				Name:        "United States",
+				Continent:   "NA",
+				Currency:    "USD",
+				CallingCode: "+1",
+			},
+			wantOk: true,
+		},
+		{
+			name:   "unknown code",
+			code:   "ZZ",
+			want:   nil,
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Lookup(tt.code)
+			if ok != tt.wantOk {
+				t.Errorf("Lookup() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lookup() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContinentName(t *testing.T) {
+	if got := ContinentName("na"); got != "North America" {
+		t.Errorf("ContinentName() = %v, want %v", got, "North America")
+	}
+	if got := ContinentName("ZZ"); got != "" {
+		t.Errorf("ContinentName() = %v, want empty string", got)
+	}
+}
+
+func TestFlag(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{
+			name: "regular code",
+			code: "US",
+			want: "🇺🇸",
+		},
+		{
+			name: "lower case code",
+			code: "ua",
+			want: "🇺🇦",
+		},
+		{
+			name: "wrong length",
+			code: "USA",
+			want: "",
+		},
+		{
+			name: "wrong symbols",
+			code: "U1",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Flag(tt.code); got != tt.want {
+				t.Errorf("Flag() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_load(t *testing.T) {
+	if _, err := load([]byte("code,name\nUS,United States\n")); err == nil {
+		t.Error("load() should fail on a wrong record")
+	}
+	if _, err := load(nil); err == nil {
+		t.Error("load() should fail on an empty dataset")
+	}
+	if got := len(countries); got < 250 {
+		t.Errorf("embedded dataset has %d countries, want at least 250", got)
+	}
+}
@@ -0,0 +1,95 @@
+package countries
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// DefaultLanguage is used when none of the requested languages is supported.
+const DefaultLanguage = "en"
+
+// m49Continents maps the continent codes used by db-ip.com to the UN M.49 regions CLDR has names for.
+var m49Continents = map[string]string{
+	"AF": "002",
+	"AN": "AQ",
+	"AS": "142",
+	"EU": "150",
+	"NA": "003",
+	"OC": "009",
+	"SA": "005",
+}
+
+var (
+	supportedLanguages = append([]language.Tag{language.English}, display.Supported.Tags()...)
+	languageMatcher    = language.NewMatcher(supportedLanguages)
+)
+
+// MatchLanguage returns the best supported language for a language tag or an Accept-Language header value,
+// DefaultLanguage is returned when nothing matches.
+func MatchLanguage(accept string) string {
+	if strings.TrimSpace(accept) == "" {
+		return DefaultLanguage
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(accept)
+	if err != nil || len(tags) == 0 {
+		return DefaultLanguage
+	}
+
+	_, i, confidence := languageMatcher.Match(tags...)
+	if confidence == language.No {
+		return DefaultLanguage
+	}
+
+	return supportedLanguages[i].String()
+}
+
+// LocalizedName returns the name of the country in the language returned by MatchLanguage.
+func LocalizedName(code, lang string) string {
+	c, ok := Lookup(code)
+	if !ok {
+		return ""
+	}
+
+	if name := regionName(c.Code, lang); name != "" {
+		return name
+	}
+
+	return c.Name
+}
+
+// LocalizedContinentName returns the name of the continent in the language returned by MatchLanguage.
+func LocalizedContinentName(code, lang string) string {
+	code = strings.ToUpper(code)
+
+	if name := regionName(m49Continents[code], lang); name != "" {
+		return name
+	}
+
+	return ContinentName(code)
+}
+
+// regionName returns the CLDR name of the region, English names are taken from the embedded dataset instead.
+func regionName(region, lang string) string {
+	if region == "" || lang == DefaultLanguage {
+		return ""
+	}
+
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return ""
+	}
+	r, err := language.ParseRegion(region)
+	if err != nil {
+		return ""
+	}
+
+	namer := display.Regions(tag)
+	if namer == nil {
+		return ""
+	}
+
+	return namer.Name(r)
+}
@@ -0,0 +1,126 @@
+package countries
+
+import "testing"
+
+func TestMatchLanguage(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{
+			name:   "empty value",
+			accept: "",
+			want:   DefaultLanguage,
+		},
+		{
+			name:   "single language",
+			accept: "de",
+			want:   "de",
+		},
+		{
+			name:   "accept-language header",
+			accept: "fr-CH, fr;q=0.9, en;q=0.8, de;q=0.7, *;q=0.5",
+			want:   "fr-CH",
+		},
+		{
+			name:   "unsupported language",
+			accept: "tlh",
+			want:   DefaultLanguage,
+		},
+		{
+			name:   "broken value",
+			accept: "#$%",
+			want:   DefaultLanguage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchLanguage(tt.accept); got != tt.want {
+				t.Errorf("MatchLanguage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalizedName(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		lang string
+		want string
+	}{
+		{
+			name: "english name comes from the dataset",
+			code: "MK",
+			lang: "en",
+			want: "North Macedonia",
+		},
+		{
+			name: "german name",
+			code: "DE",
+			lang: "de",
+			want: "Deutschland",
+		},
+		{
+			name: "ukrainian name",
+			code: "UA",
+			lang: "uk",
+			want: "Україна",
+		},
+		{
+			name: "unknown country",
+			code: "ZZ",
+			lang: "de",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LocalizedName(tt.code, tt.lang); got != tt.want {
+				t.Errorf("LocalizedName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalizedContinentName(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		lang string
+		want string
+	}{
+		{
+			name: "english name",
+			code: "NA",
+			lang: "en",
+			want: "North America",
+		},
+		{
+			name: "french name",
+			code: "EU",
+			lang: "fr",
+			want: "Europe",
+		},
+		{
+			name: "spanish name",
+			code: "SA",
+			lang: "es",
+			want: "Sudamérica",
+		},
+		{
+			name: "unknown continent",
+			code: "ZZ",
+			lang: "es",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LocalizedContinentName(tt.code, tt.lang); got != tt.want {
+				t.Errorf("LocalizedContinentName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
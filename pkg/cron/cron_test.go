@@ -0,0 +1,126 @@
+package cron
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		wantErr bool
+	}{
+		{name: "every minute", expr: "* * * * *"},
+		{name: "lists, ranges and steps", expr: "0,30 2-5 */2 1-12/3 1-5"},
+		{name: "sunday as 7", expr: "0 3 * * 7"},
+		{name: "descriptor", expr: "@monthly"},
+		{name: "too few fields", expr: "0 3 * *", wantErr: true},
+		{name: "minute out of range", expr: "60 3 * * *", wantErr: true},
+		{name: "day of month out of range", expr: "0 3 0 * *", wantErr: true},
+		{name: "reversed range", expr: "0 5-2 * * *", wantErr: true},
+		{name: "zero step", expr: "*/0 * * * *", wantErr: true},
+		{name: "not a number", expr: "0 3 * jan *", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrWrongExpression) {
+				t.Errorf("Parse() error = %v, want %v", err, ErrWrongExpression)
+			}
+		})
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	from := time.Date(2026, 10, 19, 14, 37, 12, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "next minute",
+			expr: "* * * * *",
+			from: from,
+			want: time.Date(2026, 10, 19, 14, 38, 0, 0, time.UTC),
+		},
+		{
+			name: "daily later today",
+			expr: "0 18 * * *",
+			from: from,
+			want: time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "daily tomorrow",
+			expr: "30 3 * * *",
+			from: from,
+			want: time.Date(2026, 10, 20, 3, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "second day of the month",
+			expr: "0 3 2 * *",
+			from: from,
+			want: time.Date(2026, 11, 2, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "next year",
+			expr: "@monthly",
+			from: time.Date(2026, 12, 5, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "sunday as 7",
+			expr: "0 0 * * 7",
+			from: from,
+			want: time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "day of month or day of week",
+			expr: "0 0 1 * 1",
+			from: from,
+			want: time.Date(2026, 10, 26, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "every 15 minutes",
+			expr: "*/15 * * * *",
+			from: from,
+			want: time.Date(2026, 10, 19, 14, 45, 0, 0, time.UTC),
+		},
+		{
+			name: "exact match is skipped",
+			expr: "0 18 * * *",
+			from: time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 10, 20, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "leap day",
+			expr: "0 0 29 2 *",
+			from: from,
+			want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "never",
+			expr: "0 0 30 2 *",
+			from: from,
+			want: time.Time{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
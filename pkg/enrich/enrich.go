@@ -0,0 +1,458 @@
+// Package enrich appends the locations of the addresses found in the records of a csv, tsv or log file, streaming
+// the records through a pool of workers while keeping their order.
+package enrich
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/streamdp/ip-info/domain"
+	"github.com/streamdp/ip-info/server"
+)
+
+const (
+	FormatCsv   = "csv"
+	FormatTsv   = "tsv"
+	FormatJsonl = "jsonl"
+
+	defaultWorkers   = 8
+	progressInterval = 5 * time.Second
+	// queuedPerWorker bounds the records read ahead of the one being written
+	queuedPerWorker = 64
+	maxLineSize     = 1 << 20
+)
+
+var (
+	ErrWrongInput  = errors.New("input format should be csv or tsv")
+	ErrWrongOutput = errors.New("output format should be csv, tsv or jsonl")
+	ErrNoColumn    = errors.New("no ip column in the header")
+
+	errNoIp = errors.New("no ip address in the record")
+)
+
+// locationColumns are appended to the records, prefixed so that they don't clash with the input columns.
+var locationColumns = []string{
+	"ip_address_type", "ip_network", "ip_country", "ip_state_prov", "ip_city", "ip_latitude", "ip_longitude",
+	"ip_asn", "ip_as_org", "ip_time_zone", "ip_error",
+}
+
+type Locator interface {
+	GetIpInfo(ctx context.Context, ip string, opts *domain.LookupOptions) (*domain.IpInfo, error)
+}
+
+type Options struct {
+	// Input is the format of the records, csv or tsv with a header, it's ignored when the Regex is set.
+	Input string
+	// Output is the format the records are written in, csv, tsv or jsonl.
+	Output string
+	// Column is the name or the 1-based index of the ip column of the csv and tsv records.
+	Column string
+	// Regex finds the address in the lines of a log, it's the "ip" group, the first group or the whole match.
+	Regex *regexp.Regexp
+	// Workers is the number of the addresses located at once.
+	Workers int
+	// Progress gets the number of the written records every few seconds, nil disables the reports.
+	Progress io.Writer
+}
+
+// Summary counts the records, the failed ones are grouped by the error.
+type Summary struct {
+	Records int
+	Located int
+	Errors  []*ErrorCount
+}
+
+type ErrorCount struct {
+	Error       string
+	Count       int
+	FirstRecord int
+}
+
+func (s *Summary) Failed() int {
+	return s.Records - s.Located
+}
+
+type Enricher struct {
+	l    Locator
+	opts Options
+
+	now func() time.Time
+}
+
+func New(l Locator, opts Options) (*Enricher, error) {
+	if opts.Regex == nil && opts.Input != FormatCsv && opts.Input != FormatTsv {
+		return nil, ErrWrongInput
+	}
+	if opts.Output != FormatCsv && opts.Output != FormatTsv && opts.Output != FormatJsonl {
+		return nil, ErrWrongOutput
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWorkers
+	}
+
+	return &Enricher{
+		l:    l,
+		opts: opts,
+		now:  time.Now,
+	}, nil
+}
+
+// item is a record on its way from the reader to the writer, done is closed once it's located.
+type item struct {
+	n      int
+	fields []string
+	ip     string
+
+	ipInfo *domain.IpInfo
+	err    error
+	done   chan struct{}
+}
+
+// Run enriches the records of r and writes them to w in the same order. Records without a located address are
+// written too, with the reason in the ip_error column, only the read and write errors stop the run.
+func (e *Enricher) Run(ctx context.Context, r io.Reader, w io.Writer) (*Summary, error) {
+	header, next, err := e.source(r)
+	if err != nil {
+		return nil, err
+	}
+	out, err := e.sink(w, header)
+	if err != nil {
+		return nil, err
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		jobs  = make(chan *item)
+		queue = make(chan *item, e.opts.Workers*queuedPerWorker)
+		wg    sync.WaitGroup
+	)
+	defer wg.Wait()
+
+	for range e.opts.Workers {
+		wg.Go(func() {
+			for it := range jobs {
+				e.locate(runCtx, it)
+			}
+		})
+	}
+
+	var errRead error
+	go func() {
+		defer close(queue)
+		defer close(jobs)
+
+		for n := 1; ; n++ {
+			fields, ip, errNext := next()
+			if errors.Is(errNext, io.EOF) {
+				return
+			}
+			if errNext != nil {
+				errRead = fmt.Errorf("failed to read record %d: %w", n, errNext)
+
+				return
+			}
+
+			// the record is handed to a worker before it's queued, so that the writer never waits for a record
+			// no worker got
+			it := &item{n: n, fields: fields, ip: ip, done: make(chan struct{})}
+			select {
+			case jobs <- it:
+			case <-runCtx.Done():
+				return
+			}
+			select {
+			case queue <- it:
+			case <-runCtx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		s          = &Summary{}
+		errs       = map[string]*ErrorCount{}
+		lastReport = e.now()
+	)
+	for it := range queue {
+		<-it.done
+		s.add(errs, it)
+
+		if err = out.write(it); err != nil {
+			cancel()
+			for range queue {
+			}
+
+			return s, fmt.Errorf("failed to write record %d: %w", it.n, err)
+		}
+
+		if e.opts.Progress != nil && e.now().Sub(lastReport) >= progressInterval {
+			lastReport = e.now()
+			_, _ = fmt.Fprintf(e.opts.Progress, "enriched %d records, %d failed\n", s.Records, s.Failed())
+		}
+	}
+	s.sortErrors(errs)
+
+	if err = out.flush(); err != nil {
+		return s, fmt.Errorf("failed to write records: %w", err)
+	}
+	if errRead != nil {
+		return s, errRead
+	}
+
+	return s, ctx.Err()
+}
+
+func (e *Enricher) locate(ctx context.Context, it *item) {
+	defer close(it.done)
+
+	if it.ip == "" {
+		it.err = errNoIp
+
+		return
+	}
+	it.ipInfo, it.err = e.l.GetIpInfo(ctx, it.ip, nil)
+}
+
+func (s *Summary) add(errs map[string]*ErrorCount, it *item) {
+	s.Records++
+	if it.err == nil {
+		s.Located++
+
+		return
+	}
+
+	// the messages of the invalid addresses hold the address, they are counted as one error
+	msg := it.err.Error()
+	if errors.Is(it.err, server.ErrWrongIpAddress) {
+		msg = server.ErrWrongIpAddress.Error()
+	}
+	if ec, ok := errs[msg]; ok {
+		ec.Count++
+
+		return
+	}
+	errs[msg] = &ErrorCount{Error: msg, Count: 1, FirstRecord: it.n}
+}
+
+// sortErrors lists the most frequent errors first.
+func (s *Summary) sortErrors(errs map[string]*ErrorCount) {
+	for _, ec := range errs {
+		s.Errors = append(s.Errors, ec)
+	}
+	slices.SortFunc(s.Errors, func(a, b *ErrorCount) int {
+		return cmp.Or(b.Count-a.Count, a.FirstRecord-b.FirstRecord)
+	})
+}
+
+// source returns the header of the input and the func that reads its next record along with the address in it.
+func (e *Enricher) source(r io.Reader) ([]string, func() ([]string, string, error), error) {
+	if e.opts.Regex != nil {
+		return e.lineSource(r)
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	if e.opts.Input == FormatTsv {
+		cr.Comma = '\t'
+		cr.LazyQuotes = true
+	}
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	column := ipColumn(header, e.opts.Column)
+	if column < 0 {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNoColumn, e.opts.Column)
+	}
+
+	return header, func() ([]string, string, error) {
+		fields, errRead := cr.Read()
+		if errRead != nil {
+			return nil, "", errRead
+		}
+		if column >= len(fields) {
+			return fields, "", nil
+		}
+
+		return fields, strings.TrimSpace(fields[column]), nil
+	}, nil
+}
+
+// ipColumn returns the index of the column with the name, or the one with the 1-based index, -1 when there is none.
+func ipColumn(header []string, column string) int {
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), column) {
+			return i
+		}
+	}
+	if i, err := strconv.Atoi(column); err == nil && i > 0 && i <= len(header) {
+		return i - 1
+	}
+
+	return -1
+}
+
+func (e *Enricher) lineSource(r io.Reader) ([]string, func() ([]string, string, error), error) {
+	group := e.opts.Regex.SubexpIndex("ip")
+	if group < 0 {
+		group = min(1, e.opts.Regex.NumSubexp())
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+
+	return []string{"line"}, func() ([]string, string, error) {
+		if !sc.Scan() {
+			return nil, "", cmp.Or(sc.Err(), io.EOF)
+		}
+
+		line := sc.Text()
+		if m := e.opts.Regex.FindStringSubmatch(line); m != nil {
+			return []string{line}, m[group], nil
+		}
+
+		return []string{line}, "", nil
+	}, nil
+}
+
+type sink interface {
+	write(it *item) error
+	flush() error
+}
+
+func (e *Enricher) sink(w io.Writer, header []string) (sink, error) {
+	if e.opts.Output == FormatJsonl {
+		return &jsonlSink{w: bufio.NewWriter(w), header: header}, nil
+	}
+
+	cw := csv.NewWriter(w)
+	if e.opts.Output == FormatTsv {
+		cw.Comma = '\t'
+	}
+	if err := cw.Write(append(slices.Clone(header), locationColumns...)); err != nil {
+		return nil, fmt.Errorf("failed to write header: %w", err)
+	}
+
+	return &csvSink{w: cw, columns: len(header)}, nil
+}
+
+type csvSink struct {
+	w       *csv.Writer
+	columns int
+}
+
+func (s *csvSink) write(it *item) error {
+	// short records are padded, so that the location is always in its columns
+	record := append(slices.Clone(it.fields), make([]string, max(0, s.columns-len(it.fields)))...)
+	for _, v := range location(it) {
+		record = append(record, formatValue(v))
+	}
+
+	return s.w.Write(record)
+}
+
+func (s *csvSink) flush() error {
+	s.w.Flush()
+
+	return s.w.Error()
+}
+
+// jsonlSink writes the records as json objects keyed by the header, the keys keep the order of the columns.
+type jsonlSink struct {
+	w      *bufio.Writer
+	header []string
+}
+
+func (s *jsonlSink) write(it *item) error {
+	buf := []byte{'{'}
+	appendField := func(key string, value any) error {
+		if len(buf) > 1 {
+			buf = append(buf, ',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		v, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		buf = append(append(append(buf, k...), ':'), v...)
+
+		return nil
+	}
+
+	for i, key := range s.header {
+		if i >= len(it.fields) {
+			break
+		}
+		if err := appendField(key, it.fields[i]); err != nil {
+			return err
+		}
+	}
+	for i, v := range location(it) {
+		if v == nil {
+			continue
+		}
+		if err := appendField(locationColumns[i], v); err != nil {
+			return err
+		}
+	}
+
+	_, err := s.w.Write(append(buf, '}', '\n'))
+
+	return err
+}
+
+func (s *jsonlSink) flush() error {
+	return s.w.Flush()
+}
+
+// location returns the values of the location columns, nil for the ones that don't apply to the record.
+func location(it *item) []any {
+	values := make([]any, len(locationColumns))
+	if it.err != nil {
+		values[len(values)-1] = it.err.Error()
+
+		return values
+	}
+
+	i := it.ipInfo
+	values[0], values[1] = string(i.AddressType), i.Network
+	if i.AddressType != domain.AddressTypeGlobal {
+		return values
+	}
+	copy(values[2:], []any{i.Country, i.StateProv, i.City, i.Latitude, i.Longitude, i.Asn, i.AsOrg, i.TimeZone})
+
+	return values
+}
+
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,238 @@
+package enrich
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/streamdp/ip-info/domain"
+	"github.com/streamdp/ip-info/server"
+)
+
+var errCommon = errors.New("could not get ip location: no ip address in the database")
+
+// locatorMock locates the addresses of the locations, the later addresses of a batch are answered sooner, so that
+// the records are written out of order unless the enricher keeps it.
+type locatorMock struct {
+	locations map[string]*domain.IpInfo
+}
+
+func (m *locatorMock) GetIpInfo(_ context.Context, ip string, _ *domain.LookupOptions) (*domain.IpInfo, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", server.ErrWrongIpAddress, ip)
+	}
+	time.Sleep(time.Duration(255-addr.As16()[15]) * 10 * time.Microsecond)
+
+	ipInfo, ok := m.locations[ip]
+	if !ok {
+		return nil, errCommon
+	}
+
+	return ipInfo, nil
+}
+
+func newLocatorMock() *locatorMock {
+	return &locatorMock{locations: map[string]*domain.IpInfo{
+		"8.8.8.8": {
+			AddressType: domain.AddressTypeGlobal,
+			Network:     "8.8.8.0/24",
+			Country:     "US",
+			StateProv:   "California",
+			City:        "Mountain View",
+			Latitude:    37.4223,
+			Longitude:   -122.085,
+			Asn:         15169,
+			AsOrg:       "Google LLC",
+			TimeZone:    "America/Los_Angeles",
+		},
+		"10.0.0.1": {
+			AddressType: domain.AddressTypePrivate,
+			Network:     "10.0.0.0/8",
+		},
+	}}
+}
+
+func TestEnricher_Run(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        Options
+		input       string
+		want        string
+		wantLocated int
+		wantErrors  []*ErrorCount
+	}{
+		{
+			name:  "csv to csv",
+			opts:  Options{Input: FormatCsv, Output: FormatCsv, Column: "client_ip"},
+			input: "\ufeffuser,client_ip\nalice,8.8.8.8\nbob,10.0.0.1\ncarol\n",
+			want: "user,client_ip,ip_address_type,ip_network,ip_country,ip_state_prov,ip_city,ip_latitude," +
+				"ip_longitude,ip_asn,ip_as_org,ip_time_zone,ip_error\n" +
+				"alice,8.8.8.8,global,8.8.8.0/24,US,California,Mountain View,37.4223,-122.085,15169,Google LLC," +
+				"America/Los_Angeles,\n" +
+				"bob,10.0.0.1,private,10.0.0.0/8,,,,,,,,,\n" +
+				"carol,,,,,,,,,,,,no ip address in the record\n",
+			wantLocated: 2,
+			wantErrors:  []*ErrorCount{{Error: errNoIp.Error(), Count: 1, FirstRecord: 3}},
+		},
+		{
+			name:  "tsv column index to jsonl",
+			opts:  Options{Input: FormatTsv, Output: FormatJsonl, Column: "2"},
+			input: "user\taddr\nalice\t8.8.8.8\nbob\tlocalhost\n",
+			want: `{"user":"alice","addr":"8.8.8.8","ip_address_type":"global","ip_network":"8.8.8.0/24",` +
+				`"ip_country":"US","ip_state_prov":"California","ip_city":"Mountain View","ip_latitude":37.4223,` +
+				`"ip_longitude":-122.085,"ip_asn":15169,"ip_as_org":"Google LLC",` +
+				`"ip_time_zone":"America/Los_Angeles"}` + "\n" +
+				`{"user":"bob","addr":"localhost","ip_error":"could not parse the IP address: localhost"}` + "\n",
+			wantLocated: 1,
+			wantErrors:  []*ErrorCount{{Error: server.ErrWrongIpAddress.Error(), Count: 1, FirstRecord: 2}},
+		},
+		{
+			name: "log lines to tsv",
+			opts: Options{Output: FormatTsv, Regex: regexp.MustCompile(`^(\S+)`)},
+			input: `8.8.8.8 - - [02/Oct/2026:03:04:12 +0000] "GET / HTTP/1.1" 200 612` + "\n" +
+				`1.1.1.1 - - [02/Oct/2026:03:04:13 +0000] "GET / HTTP/1.1" 404 153` + "\n",
+			want: "line\tip_address_type\tip_network\tip_country\tip_state_prov\tip_city\tip_latitude\t" +
+				"ip_longitude\tip_asn\tip_as_org\tip_time_zone\tip_error\n" +
+				`"8.8.8.8 - - [02/Oct/2026:03:04:12 +0000] ""GET / HTTP/1.1"" 200 612"` +
+				"\tglobal\t8.8.8.0/24\tUS\tCalifornia\tMountain View\t37.4223\t-122.085\t15169\tGoogle LLC\t" +
+				"America/Los_Angeles\t\n" +
+				`"1.1.1.1 - - [02/Oct/2026:03:04:13 +0000] ""GET / HTTP/1.1"" 404 153"` +
+				"\t\t\t\t\t\t\t\t\t\t\t" + errCommon.Error() + "\n",
+			wantLocated: 1,
+			wantErrors:  []*ErrorCount{{Error: errCommon.Error(), Count: 1, FirstRecord: 2}},
+		},
+		{
+			name:  "named group",
+			opts:  Options{Output: FormatJsonl, Regex: regexp.MustCompile(`(GET|POST) .* from (?P<ip>\S+)`)},
+			input: "GET /healthz from 10.0.0.1\n",
+			want: `{"line":"GET /healthz from 10.0.0.1","ip_address_type":"private",` +
+				`"ip_network":"10.0.0.0/8"}` + "\n",
+			wantLocated: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := New(newLocatorMock(), tt.opts)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			var out bytes.Buffer
+			s, err := e.Run(context.Background(), strings.NewReader(tt.input), &out)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("Run() output =\n%s\nwant\n%s", out.String(), tt.want)
+			}
+			if s.Located != tt.wantLocated {
+				t.Errorf("Run() located = %d, want %d", s.Located, tt.wantLocated)
+			}
+			if len(s.Errors) != len(tt.wantErrors) {
+				t.Fatalf("Run() errors = %d, want %d", len(s.Errors), len(tt.wantErrors))
+			}
+			for i, ec := range s.Errors {
+				if *ec != *tt.wantErrors[i] {
+					t.Errorf("Run() error %d = %+v, want %+v", i, ec, tt.wantErrors[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEnricher_RunKeepsOrder(t *testing.T) {
+	var input strings.Builder
+	input.WriteString("n,ip\n")
+	for i := range 2000 {
+		fmt.Fprintf(&input, "%d,192.0.2.%d\n", i, i%256)
+	}
+
+	e, err := New(newLocatorMock(), Options{Input: FormatCsv, Output: FormatCsv, Column: "ip", Workers: 16})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	s, err := e.Run(context.Background(), strings.NewReader(input.String()), &out)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if s.Records != 2000 || s.Failed() != 2000 || len(s.Errors) != 1 || s.Errors[0].FirstRecord != 1 {
+		t.Errorf("Run() summary = %+v, want 2000 records failed with one error", s)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")[1:]
+	for i, line := range lines {
+		if !strings.HasPrefix(line, fmt.Sprintf("%d,192.0.2.%d,", i, i%256)) {
+			t.Fatalf("Run() line %d = %q, want record %d", i, line, i)
+		}
+	}
+}
+
+func TestEnricher_RunReadError(t *testing.T) {
+	e, err := New(newLocatorMock(), Options{Input: FormatCsv, Output: FormatCsv, Column: "ip"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	s, err := e.Run(context.Background(), strings.NewReader("ip\n8.8.8.8\n\"8.8.4.4\n"), &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "record 2") {
+		t.Errorf("Run() error = %v, want the broken record", err)
+	}
+	if s.Records != 1 {
+		t.Errorf("Run() records = %d, want the ones before the broken record", s.Records)
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr error
+	}{
+		{
+			name: "csv",
+			opts: Options{Input: FormatCsv, Output: FormatJsonl},
+		},
+		{
+			name: "regex without input format",
+			opts: Options{Output: FormatCsv, Regex: regexp.MustCompile(`^\S+`)},
+		},
+		{
+			name:    "jsonl input",
+			opts:    Options{Input: FormatJsonl, Output: FormatCsv},
+			wantErr: ErrWrongInput,
+		},
+		{
+			name:    "parquet output",
+			opts:    Options{Input: FormatCsv, Output: "parquet"},
+			wantErr: ErrWrongOutput,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(newLocatorMock(), tt.opts); !errors.Is(err, tt.wantErr) {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEnricher_RunNoColumn(t *testing.T) {
+	e, err := New(newLocatorMock(), Options{Input: FormatCsv, Output: FormatCsv, Column: "ip"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, err = e.Run(context.Background(), strings.NewReader("user,addr\n"), &bytes.Buffer{}); !errors.Is(err,
+		ErrNoColumn) {
+		t.Errorf("Run() error = %v, want %v", err, ErrNoColumn)
+	}
+}
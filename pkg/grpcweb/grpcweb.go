@@ -0,0 +1,215 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const (
+	grpcContentType    = "application/grpc"
+	grpcWebContentType = "application/grpc-web"
+	grpcWebTextSuffix  = "-text"
+
+	trailerFrameFlag = 0x80
+)
+
+var exposedHeaders = []string{"grpc-status", "grpc-message", "grpc-status-details-bin"}
+
+type handler struct {
+	grpc           http.Handler
+	allowedOrigins []string
+}
+
+// New wraps a gRPC server so that it can serve gRPC-Web requests coming from browsers over HTTP/1.1.
+// Requests are translated into regular gRPC calls and trailers are written back as the last body frame.
+// Only the pages of the allowed origins, or of any origin when they contain "*", can make cross-origin calls.
+func New(grpc http.Handler, allowedOrigins []string) http.Handler {
+	return &handler{grpc: grpc, allowedOrigins: allowedOrigins}
+}
+
+// IsGrpcWebRequest reports whether r is a gRPC-Web call or a CORS preflight for one.
+func IsGrpcWebRequest(r *http.Request) bool {
+	if r.Method == http.MethodOptions {
+		return isCorsPreflight(r)
+	}
+
+	return r.Method == http.MethodPost && strings.HasPrefix(r.Header.Get("Content-Type"), grpcWebContentType)
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Origin")
+	allowed := h.isAllowedOrigin(r.Header.Get("Origin"))
+	if allowed {
+		w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(exposedHeaders, ", "))
+	}
+
+	if r.Method == http.MethodOptions {
+		// browsers don't send the call when the preflight response has no CORS headers
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Methods", http.MethodPost)
+			w.Header().Set("Access-Control-Allow-Headers", r.Header.Get("Access-Control-Request-Headers"))
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+		return
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	isText := strings.HasPrefix(contentType, grpcWebContentType+grpcWebTextSuffix)
+
+	req := r.Clone(r.Context())
+	req.ProtoMajor, req.ProtoMinor, req.Proto = 2, 0, "HTTP/2.0"
+	req.Header.Set("Content-Type", grpcContentType+subtype(contentType))
+	req.Header.Del("Content-Length")
+	req.ContentLength = -1
+	if isText {
+		req.Body = io.NopCloser(base64.NewDecoder(base64.StdEncoding, r.Body))
+	}
+
+	rw := &responseWriter{
+		w:           w,
+		header:      make(http.Header),
+		contentType: contentType,
+		isText:      isText,
+	}
+	h.grpc.ServeHTTP(rw, req)
+	rw.finish()
+}
+
+type responseWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+
+	contentType   string
+	isText        bool
+	headerWritten bool
+	code          int
+}
+
+func (rw *responseWriter) Header() http.Header {
+	return rw.header
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	if rw.headerWritten {
+		return
+	}
+	rw.headerWritten = true
+	rw.code = code
+
+	h := rw.w.Header()
+	for k, v := range rw.header {
+		if k == "Trailer" || strings.HasPrefix(k, http.TrailerPrefix) || isDeclaredTrailer(rw.header, k) {
+			continue
+		}
+		h[k] = v
+	}
+	h.Set("Content-Type", rw.contentType)
+	h.Del("Content-Length")
+
+	rw.w.WriteHeader(code)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.WriteHeader(http.StatusOK)
+
+	if rw.isText {
+		if _, err := io.WriteString(rw.w, base64.StdEncoding.EncodeToString(b)); err != nil {
+			return 0, err
+		}
+
+		return len(b), nil
+	}
+
+	return rw.w.Write(b)
+}
+
+func (rw *responseWriter) Flush() {
+	rw.WriteHeader(http.StatusOK)
+
+	if f, ok := rw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish sends the gRPC trailers as a length-prefixed frame with the trailer flag set, which is how gRPC-Web
+// delivers them to clients that cannot read HTTP trailers.
+func (rw *responseWriter) finish() {
+	if rw.headerWritten && rw.code != http.StatusOK {
+		return
+	}
+
+	trailers := make(http.Header)
+	for k, v := range rw.header {
+		switch {
+		case strings.HasPrefix(k, http.TrailerPrefix):
+			trailers[strings.TrimPrefix(k, http.TrailerPrefix)] = v
+		case isDeclaredTrailer(rw.header, k), !rw.headerWritten && strings.HasPrefix(strings.ToLower(k), "grpc-"):
+			trailers[k] = v
+		}
+	}
+
+	var buf bytes.Buffer
+	for k, v := range trailers {
+		for _, s := range v {
+			buf.WriteString(strings.ToLower(k) + ": " + s + "\r\n")
+		}
+	}
+
+	frame := make([]byte, 5, 5+buf.Len())
+	frame[0] = trailerFrameFlag
+	binary.BigEndian.PutUint32(frame[1:], uint32(buf.Len()))
+
+	_, _ = rw.Write(append(frame, buf.Bytes()...))
+	rw.Flush()
+}
+
+func (h *handler) isAllowedOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+
+	return slices.Contains(h.allowedOrigins, "*") || slices.Contains(h.allowedOrigins, origin)
+}
+
+func isDeclaredTrailer(h http.Header, key string) bool {
+	for _, v := range h.Values("Trailer") {
+		for _, name := range strings.Split(v, ",") {
+			if http.CanonicalHeaderKey(strings.TrimSpace(name)) == key {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isCorsPreflight(r *http.Request) bool {
+	if r.Header.Get("Origin") == "" || r.Header.Get("Access-Control-Request-Method") != http.MethodPost {
+		return false
+	}
+
+	for _, h := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+		if strings.EqualFold(strings.TrimSpace(h), "x-grpc-web") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func subtype(contentType string) string {
+	contentType = strings.TrimPrefix(contentType, grpcWebContentType)
+	contentType = strings.TrimPrefix(contentType, grpcWebTextSuffix)
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+
+	return contentType
+}
@@ -0,0 +1,246 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	t.Cleanup(srv.Stop)
+
+	tests := []struct {
+		name        string
+		contentType string
+		service     string
+		wantStatus  string
+	}{
+		{
+			name:        "binary grpc-web call",
+			contentType: "application/grpc-web+proto",
+			service:     "",
+			wantStatus:  "0",
+		},
+		{
+			name:        "text grpc-web call",
+			contentType: "application/grpc-web-text",
+			service:     "",
+			wantStatus:  "0",
+		},
+		{
+			name:        "grpc error is returned in trailers",
+			contentType: "application/grpc-web+proto",
+			service:     "unknown",
+			wantStatus:  "5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			isText := strings.HasPrefix(tt.contentType, "application/grpc-web-text")
+
+			msg, err := proto.Marshal(&healthpb.HealthCheckRequest{Service: tt.service})
+			if err != nil {
+				t.Fatalf("marshal request: %v", err)
+			}
+			body := append(make([]byte, 5), msg...)
+			binary.BigEndian.PutUint32(body[1:5], uint32(len(msg)))
+			if isText {
+				body = []byte(base64.StdEncoding.EncodeToString(body))
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/grpc.health.v1.Health/Check", bytes.NewReader(body))
+			r.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+
+			New(srv, nil).ServeHTTP(w, r)
+
+			res := w.Result()
+			t.Cleanup(func() { _ = res.Body.Close() })
+
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("ServeHTTP() status = %d, want %d", res.StatusCode, http.StatusOK)
+			}
+			if got := res.Header.Get("Content-Type"); got != tt.contentType {
+				t.Errorf("ServeHTTP() content type = %s, want %s", got, tt.contentType)
+			}
+
+			out, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("read body: expected no error, got: %v", err)
+			}
+			if isText {
+				out = decodeChunks(t, out)
+			}
+
+			trailers := lastFrame(t, out)
+			if !strings.Contains(trailers, "grpc-status: "+tt.wantStatus+"\r\n") {
+				t.Errorf("ServeHTTP() trailers = %q, want grpc-status %s", trailers, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestHandler_ServeHTTP_cors(t *testing.T) {
+	tests := []struct {
+		name           string
+		allowedOrigins []string
+		origin         string
+		wantOrigin     string
+		wantMethods    string
+	}{
+		{
+			name:           "allowed origin",
+			allowedOrigins: []string{"https://app.example.com"},
+			origin:         "https://app.example.com",
+			wantOrigin:     "https://app.example.com",
+			wantMethods:    http.MethodPost,
+		},
+		{
+			name:           "any origin",
+			allowedOrigins: []string{"*"},
+			origin:         "https://other.example.com",
+			wantOrigin:     "https://other.example.com",
+			wantMethods:    http.MethodPost,
+		},
+		{
+			name:           "origin not allowed",
+			allowedOrigins: []string{"https://app.example.com"},
+			origin:         "https://evil.example.com",
+		},
+		{
+			name:   "no allowed origins",
+			origin: "https://app.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodOptions, "/IpInfo/GetIpInfo", nil)
+			r.Header.Set("Origin", tt.origin)
+			r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			r.Header.Set("Access-Control-Request-Headers", "content-type,x-grpc-web")
+			w := httptest.NewRecorder()
+
+			New(http.NotFoundHandler(), tt.allowedOrigins).ServeHTTP(w, r)
+
+			res := w.Result()
+			t.Cleanup(func() { _ = res.Body.Close() })
+
+			if got := res.Header.Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("ServeHTTP() allowed origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := res.Header.Get("Access-Control-Allow-Methods"); got != tt.wantMethods {
+				t.Errorf("ServeHTTP() allowed methods = %q, want %q", got, tt.wantMethods)
+			}
+		})
+	}
+}
+
+func TestIsGrpcWebRequest(t *testing.T) {
+	newRequest := func(method string, headers map[string]string) *http.Request {
+		r := httptest.NewRequest(method, "/IpInfo/GetIpInfo", nil)
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+
+		return r
+	}
+
+	tests := []struct {
+		name string
+		r    *http.Request
+		want bool
+	}{
+		{
+			name: "grpc-web call",
+			r:    newRequest(http.MethodPost, map[string]string{"Content-Type": "application/grpc-web+proto"}),
+			want: true,
+		},
+		{
+			name: "grpc-web preflight",
+			r: newRequest(http.MethodOptions, map[string]string{
+				"Origin":                         "http://localhost",
+				"Access-Control-Request-Method":  http.MethodPost,
+				"Access-Control-Request-Headers": "content-type,x-grpc-web",
+			}),
+			want: true,
+		},
+		{
+			name: "plain grpc call",
+			r:    newRequest(http.MethodPost, map[string]string{"Content-Type": "application/grpc"}),
+			want: false,
+		},
+		{
+			name: "rest call",
+			r:    newRequest(http.MethodGet, map[string]string{"Content-Type": "application/json"}),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsGrpcWebRequest(tt.r); got != tt.want {
+				t.Errorf("IsGrpcWebRequest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func decodeChunks(t *testing.T, b []byte) []byte {
+	t.Helper()
+
+	var out []byte
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '=')
+		for i >= 0 && i+1 < len(b) && b[i+1] == '=' {
+			i++
+		}
+		chunk := b
+		if i >= 0 {
+			chunk, b = b[:i+1], b[i+1:]
+		} else {
+			b = nil
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(string(chunk))
+		if err != nil {
+			t.Fatalf("decode base64 chunk: %v", err)
+		}
+		out = append(out, decoded...)
+	}
+
+	return out
+}
+
+func lastFrame(t *testing.T, b []byte) string {
+	t.Helper()
+
+	for len(b) >= 5 {
+		flag, size := b[0], binary.BigEndian.Uint32(b[1:5])
+		if int(size) > len(b)-5 {
+			t.Fatalf("truncated frame")
+		}
+		if flag&trailerFrameFlag != 0 {
+			return string(b[5 : 5+size])
+		}
+		b = b[5+size:]
+	}
+
+	t.Fatalf("trailer frame not found")
+
+	return ""
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/streamdp/ip-info/config"
@@ -25,6 +26,9 @@ type ipCache struct {
 	cp Cacher
 
 	cfg *config.Cache
+
+	// generation prefixes every key, moving to the next one drops all the entries at once
+	generation atomic.Uint64
 }
 
 func New(cp Cacher, cfg *config.Cache) (*ipCache, error) {
@@ -33,7 +37,7 @@ func New(cp Cacher, cfg *config.Cache) (*ipCache, error) {
 
 func (i *ipCache) Set(ctx context.Context, ipInfo *domain.IpInfo) error {
 	if err := i.cp.Set(ctx,
-		ipInfo.Ip.String(),
+		i.key(ipInfo.Ip.String()),
 		ipInfo.Bytes(),
 		i.cfg.Ttl(),
 	); err != nil {
@@ -44,7 +48,7 @@ func (i *ipCache) Set(ctx context.Context, ipInfo *domain.IpInfo) error {
 }
 
 func (i *ipCache) Get(ctx context.Context, ip string) (*domain.IpInfo, error) {
-	res, err := i.cp.Get(ctx, ip)
+	res, err := i.cp.Get(ctx, i.key(ip))
 	if err != nil {
 		return nil, fmt.Errorf("ip_cache: %w", err)
 	}
@@ -60,3 +64,16 @@ func (i *ipCache) Get(ctx context.Context, ip string) (*domain.IpInfo, error) {
 
 	return ipInfo, nil
 }
+
+// Flush moves the keys to the given generation, dropping the entries of the previous ones at once. The generation is
+// shared by the instances, so the entries they put in a shared cache are dropped as well and the ones left by an
+// earlier run aren't served after a restart.
+func (i *ipCache) Flush(_ context.Context, generation uint64) error {
+	i.generation.Store(generation)
+
+	return nil
+}
+
+func (i *ipCache) key(ip string) string {
+	return fmt.Sprintf("%d:%s", i.generation.Load(), ip)
+}
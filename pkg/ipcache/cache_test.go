@@ -0,0 +1,59 @@
+package ipcache
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/streamdp/ip-info/config"
+	"github.com/streamdp/ip-info/domain"
+)
+
+type cacherMock struct {
+	entries map[string]any
+}
+
+func (c *cacherMock) Get(_ context.Context, key string) (any, error) {
+	v, ok := c.entries[key]
+	if !ok {
+		return nil, errTypeAssertion
+	}
+
+	return v, nil
+}
+
+func (c *cacherMock) Set(_ context.Context, key string, value any, _ time.Duration) error {
+	c.entries[key] = value
+
+	return nil
+}
+
+func TestIpCache_Flush(t *testing.T) {
+	ctx := context.Background()
+
+	cp := &cacherMock{entries: map[string]any{}}
+	c, _ := New(cp, &config.Cache{})
+	if err := c.Flush(ctx, 7); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if err := c.Set(ctx, &domain.IpInfo{Ip: netip.MustParseAddr("203.0.113.10")}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	if err := c.Flush(ctx, 8); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if _, err := c.Get(ctx, "203.0.113.10"); err == nil {
+		t.Errorf("Get() after Flush() returned the cached entry")
+	}
+
+	// another instance on the same generation shares the entries of the cache
+	other, _ := New(cp, &config.Cache{})
+	if err := other.Flush(ctx, 7); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if _, err := other.Get(ctx, "203.0.113.10"); err != nil {
+		t.Errorf("Get() on the same generation error = %v", err)
+	}
+}
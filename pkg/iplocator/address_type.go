@@ -0,0 +1,70 @@
+package iplocator
+
+import (
+	"net/netip"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+type specialPurposeBlock struct {
+	prefix      netip.Prefix
+	addressType domain.AddressType
+}
+
+// specialPurposeBlocks are taken from the IANA IPv4 and IPv6 special-purpose address registries, blocks that are
+// globally reachable (AS112, AMT, NAT64 well-known prefix, etc.) are intentionally left out. More specific blocks go
+// first, IPv4-mapped IPv6 addresses are unmapped before the lookup.
+var specialPurposeBlocks = []specialPurposeBlock{
+	{netip.MustParsePrefix("0.0.0.0/8"), domain.AddressTypeReserved},
+	{netip.MustParsePrefix("10.0.0.0/8"), domain.AddressTypePrivate},
+	{netip.MustParsePrefix("100.64.0.0/10"), domain.AddressTypeCgnat},
+	{netip.MustParsePrefix("127.0.0.0/8"), domain.AddressTypeLoopback},
+	{netip.MustParsePrefix("169.254.0.0/16"), domain.AddressTypeLinkLocal},
+	{netip.MustParsePrefix("172.16.0.0/12"), domain.AddressTypePrivate},
+	{netip.MustParsePrefix("192.0.0.0/24"), domain.AddressTypeReserved},
+	{netip.MustParsePrefix("192.0.2.0/24"), domain.AddressTypeDocumentation},
+	{netip.MustParsePrefix("192.88.99.0/24"), domain.AddressTypeReserved},
+	{netip.MustParsePrefix("192.168.0.0/16"), domain.AddressTypePrivate},
+	{netip.MustParsePrefix("198.18.0.0/15"), domain.AddressTypeReserved},
+	{netip.MustParsePrefix("198.51.100.0/24"), domain.AddressTypeDocumentation},
+	{netip.MustParsePrefix("203.0.113.0/24"), domain.AddressTypeDocumentation},
+	{netip.MustParsePrefix("224.0.0.0/4"), domain.AddressTypeMulticast},
+	{netip.MustParsePrefix("255.255.255.255/32"), domain.AddressTypeReserved},
+	{netip.MustParsePrefix("240.0.0.0/4"), domain.AddressTypeReserved},
+
+	{netip.MustParsePrefix("::/128"), domain.AddressTypeReserved},
+	{netip.MustParsePrefix("::1/128"), domain.AddressTypeLoopback},
+	{netip.MustParsePrefix("64:ff9b:1::/48"), domain.AddressTypePrivate},
+	{netip.MustParsePrefix("100::/64"), domain.AddressTypeReserved},
+	{netip.MustParsePrefix("2001:2::/48"), domain.AddressTypeReserved},
+	{netip.MustParsePrefix("2001:10::/28"), domain.AddressTypeReserved},
+	{netip.MustParsePrefix("2001:db8::/32"), domain.AddressTypeDocumentation},
+	{netip.MustParsePrefix("3fff::/20"), domain.AddressTypeDocumentation},
+	{netip.MustParsePrefix("5f00::/16"), domain.AddressTypeReserved},
+	{netip.MustParsePrefix("fc00::/7"), domain.AddressTypePrivate},
+	{netip.MustParsePrefix("fe80::/10"), domain.AddressTypeLinkLocal},
+	{netip.MustParsePrefix("ff00::/8"), domain.AddressTypeMulticast},
+}
+
+var (
+	ipv6GlobalUnicast = netip.MustParsePrefix("2000::/3")
+	ipv6Nat64         = netip.MustParsePrefix("64:ff9b::/96")
+)
+
+// classify returns the type of the address and the special-purpose block it belongs to. IPv6 addresses outside of
+// the global unicast space that aren't listed in the registry are reserved by the IETF, so they are bogons too.
+func classify(addr netip.Addr) (domain.AddressType, netip.Prefix) {
+	addr = addr.Unmap()
+
+	for _, b := range specialPurposeBlocks {
+		if b.prefix.Contains(addr) {
+			return b.addressType, b.prefix
+		}
+	}
+
+	if addr.Is6() && !ipv6GlobalUnicast.Contains(addr) && !ipv6Nat64.Contains(addr) {
+		return domain.AddressTypeReserved, netip.Prefix{}
+	}
+
+	return domain.AddressTypeGlobal, netip.Prefix{}
+}
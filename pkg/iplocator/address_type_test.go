@@ -0,0 +1,67 @@
+package iplocator
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+func Test_classify(t *testing.T) {
+	tests := []struct {
+		name      string
+		ip        string
+		want      domain.AddressType
+		wantBlock string
+	}{
+		{name: "ipv4 global", ip: "8.8.8.8", want: domain.AddressTypeGlobal},
+		{name: "ipv4 private", ip: "10.1.2.3", want: domain.AddressTypePrivate, wantBlock: "10.0.0.0/8"},
+		{name: "ipv4 private 172", ip: "172.31.255.1", want: domain.AddressTypePrivate, wantBlock: "172.16.0.0/12"},
+		{name: "ipv4 next to private 172", ip: "172.32.0.1", want: domain.AddressTypeGlobal},
+		{name: "ipv4 loopback", ip: "127.0.0.1", want: domain.AddressTypeLoopback, wantBlock: "127.0.0.0/8"},
+		{name: "ipv4 link local", ip: "169.254.1.1", want: domain.AddressTypeLinkLocal, wantBlock: "169.254.0.0/16"},
+		{name: "ipv4 cgnat", ip: "100.100.1.1", want: domain.AddressTypeCgnat, wantBlock: "100.64.0.0/10"},
+		{
+			name:      "ipv4 documentation",
+			ip:        "203.0.113.7",
+			want:      domain.AddressTypeDocumentation,
+			wantBlock: "203.0.113.0/24",
+		},
+		{name: "ipv4 multicast", ip: "239.1.1.1", want: domain.AddressTypeMulticast, wantBlock: "224.0.0.0/4"},
+		{name: "ipv4 broadcast", ip: "255.255.255.255", want: domain.AddressTypeReserved, wantBlock: "255.255.255.255/32"},
+		{name: "ipv4 future use", ip: "250.1.1.1", want: domain.AddressTypeReserved, wantBlock: "240.0.0.0/4"},
+		{name: "ipv4 this network", ip: "0.1.2.3", want: domain.AddressTypeReserved, wantBlock: "0.0.0.0/8"},
+		{name: "ipv4 benchmarking", ip: "198.19.0.1", want: domain.AddressTypeReserved, wantBlock: "198.18.0.0/15"},
+		{name: "ipv4-mapped private", ip: "::ffff:192.168.1.1", want: domain.AddressTypePrivate, wantBlock: "192.168.0.0/16"},
+		{name: "ipv6 global", ip: "2001:4860:4860::8888", want: domain.AddressTypeGlobal},
+		{name: "ipv6 unspecified", ip: "::", want: domain.AddressTypeReserved, wantBlock: "::/128"},
+		{name: "ipv6 loopback", ip: "::1", want: domain.AddressTypeLoopback, wantBlock: "::1/128"},
+		{name: "ipv6 unique local", ip: "fd12:3456::1", want: domain.AddressTypePrivate, wantBlock: "fc00::/7"},
+		{name: "ipv6 link local", ip: "fe80::1", want: domain.AddressTypeLinkLocal, wantBlock: "fe80::/10"},
+		{name: "ipv6 multicast", ip: "ff02::1", want: domain.AddressTypeMulticast, wantBlock: "ff00::/8"},
+		{
+			name:      "ipv6 documentation",
+			ip:        "2001:db8::1",
+			want:      domain.AddressTypeDocumentation,
+			wantBlock: "2001:db8::/32",
+		},
+		{name: "ipv6 nat64 well-known prefix", ip: "64:ff9b::808:808", want: domain.AddressTypeGlobal},
+		{name: "ipv6 outside global unicast", ip: "4000::1", want: domain.AddressTypeReserved},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, block := classify(netip.MustParseAddr(tt.ip))
+			if got != tt.want {
+				t.Errorf("classify() = %v, want %v", got, tt.want)
+			}
+
+			gotBlock := ""
+			if block.IsValid() {
+				gotBlock = block.String()
+			}
+			if gotBlock != tt.wantBlock {
+				t.Errorf("classify() block = %v, want %v", gotBlock, tt.wantBlock)
+			}
+		})
+	}
+}
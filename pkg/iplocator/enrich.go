@@ -0,0 +1,42 @@
+package iplocator
+
+import (
+	"time"
+
+	"github.com/streamdp/ip-info/domain"
+	"github.com/streamdp/ip-info/pkg/countries"
+	"github.com/streamdp/ip-info/pkg/timezones"
+)
+
+// enrich adds the time zone and the opt-in fields to a copy of ipInfo, so the database and cache results are never
+// modified and the UTC offset is always the current one at now.
+func enrich(ipInfo *domain.IpInfo, opts *domain.LookupOptions, now time.Time) *domain.IpInfo {
+	if ipInfo == nil {
+		return nil
+	}
+
+	enriched := *ipInfo
+	enriched.TimeZone = timezones.Lookup(ipInfo.Country, ipInfo.Latitude, ipInfo.Longitude)
+	if enriched.TimeZone != "" {
+		// zone names come from the embedded tz database, so the offset can't fail to resolve
+		enriched.UtcOffset, enriched.IsDst, _ = timezones.Offset(enriched.TimeZone, now)
+	}
+
+	// the negotiated language is reported even when no name is localized, so clients can tell what lang resolved to
+	if opts != nil {
+		enriched.Lang = countries.MatchLanguage(opts.Language)
+	}
+	if opts.Expands(domain.ExpandCountry) {
+		enriched.ContinentName = countries.LocalizedContinentName(ipInfo.Continent, enriched.Lang)
+		if c, ok := countries.Lookup(ipInfo.Country); ok {
+			isEu := c.IsEu
+			enriched.CountryName = countries.LocalizedName(c.Code, enriched.Lang)
+			enriched.IsEu = &isEu
+			enriched.Currency = c.Currency
+			enriched.CallingCode = c.CallingCode
+			enriched.CountryFlag = countries.Flag(c.Code)
+		}
+	}
+
+	return &enriched
+}
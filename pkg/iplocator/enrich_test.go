@@ -0,0 +1,138 @@
+package iplocator
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+func Test_enrich(t *testing.T) {
+	now := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	isEu := true
+	ipInfo := &domain.IpInfo{
+		Ip:        netip.MustParseAddr("91.198.174.192"),
+		Continent: "EU",
+		Country:   "NL",
+		City:      "Amsterdam",
+	}
+
+	tests := []struct {
+		name   string
+		ipInfo *domain.IpInfo
+		opts   *domain.LookupOptions
+		want   *domain.IpInfo
+	}{
+		{
+			name:   "without options",
+			ipInfo: ipInfo,
+			opts:   nil,
+			want: &domain.IpInfo{
+				Ip:        netip.MustParseAddr("91.198.174.192"),
+				Continent: "EU",
+				Country:   "NL",
+				City:      "Amsterdam",
+				TimeZone:  "Europe/Amsterdam",
+				UtcOffset: "+02:00",
+				IsDst:     true,
+			},
+		},
+		{
+			name:   "nothing to expand",
+			ipInfo: ipInfo,
+			opts:   &domain.LookupOptions{},
+			want: &domain.IpInfo{
+				Ip:        netip.MustParseAddr("91.198.174.192"),
+				Continent: "EU",
+				Country:   "NL",
+				City:      "Amsterdam",
+				TimeZone:  "Europe/Amsterdam",
+				UtcOffset: "+02:00",
+				IsDst:     true,
+				Lang:      "en",
+			},
+		},
+		{
+			name:   "language without expand",
+			ipInfo: ipInfo,
+			opts:   &domain.LookupOptions{Language: "de"},
+			want: &domain.IpInfo{
+				Ip:        netip.MustParseAddr("91.198.174.192"),
+				Continent: "EU",
+				Country:   "NL",
+				City:      "Amsterdam",
+				TimeZone:  "Europe/Amsterdam",
+				UtcOffset: "+02:00",
+				IsDst:     true,
+				Lang:      "de",
+			},
+		},
+		{
+			name:   "expand country",
+			ipInfo: ipInfo,
+			opts:   &domain.LookupOptions{Expand: []string{domain.ExpandCountry}},
+			want: &domain.IpInfo{
+				Ip:            netip.MustParseAddr("91.198.174.192"),
+				Continent:     "EU",
+				Country:       "NL",
+				City:          "Amsterdam",
+				TimeZone:      "Europe/Amsterdam",
+				UtcOffset:     "+02:00",
+				IsDst:         true,
+				CountryName:   "Netherlands",
+				ContinentName: "Europe",
+				IsEu:          &isEu,
+				Currency:      "EUR",
+				CallingCode:   "+31",
+				CountryFlag:   "🇳🇱",
+				Lang:          "en",
+			},
+		},
+		{
+			name:   "expand country in german",
+			ipInfo: ipInfo,
+			opts:   &domain.LookupOptions{Expand: []string{domain.ExpandCountry}, Language: "de-AT;q=0.9, tlh"},
+			want: &domain.IpInfo{
+				Ip:            netip.MustParseAddr("91.198.174.192"),
+				Continent:     "EU",
+				Country:       "NL",
+				City:          "Amsterdam",
+				TimeZone:      "Europe/Amsterdam",
+				UtcOffset:     "+02:00",
+				IsDst:         true,
+				CountryName:   "Niederlande",
+				ContinentName: "Europa",
+				IsEu:          &isEu,
+				Currency:      "EUR",
+				CallingCode:   "+31",
+				CountryFlag:   "🇳🇱",
+				Lang:          "de-AT",
+			},
+		},
+		{
+			name:   "expand unknown country",
+			ipInfo: &domain.IpInfo{Continent: "EU", Country: "ZZ"},
+			opts:   &domain.LookupOptions{Expand: []string{domain.ExpandCountry}},
+			want:   &domain.IpInfo{Continent: "EU", Country: "ZZ", ContinentName: "Europe", Lang: "en"},
+		},
+		{
+			name:   "nil ip info",
+			ipInfo: nil,
+			opts:   &domain.LookupOptions{Expand: []string{domain.ExpandCountry}},
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := enrich(tt.ipInfo, tt.opts, now); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("enrich() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if ipInfo.CountryName != "" || ipInfo.TimeZone != "" {
+		t.Error("enrich() should not modify the source ip info")
+	}
+}
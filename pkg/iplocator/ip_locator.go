@@ -2,8 +2,10 @@ package iplocator
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
-	"net"
+	"net/netip"
+	"strings"
 	"time"
 
 	"github.com/streamdp/ip-info/domain"
@@ -14,11 +16,31 @@ const (
 	CfConnectingIp = "cf-connecting-ip"
 	XForwardedFor  = "x-forwarded-for"
 	XRealIp        = "x-real-ip"
+	AcceptLanguage = "accept-language"
+	Lang           = "lang"
+)
+
+const (
+	networkDefaultPageSize = 100
+	networkMaxPageSize     = 1000
 )
 
 type Database interface {
-	IpInfo(ctx context.Context, ip net.IP) (*domain.IpInfo, error)
-	UpdateIpDatabase(ctx context.Context) (nextUpdate time.Duration, err error)
+	IpInfo(ctx context.Context, ip netip.Addr) (*domain.IpInfo, error)
+	IpInfoAsOf(ctx context.Context, ip netip.Addr, asOf time.Time) (*domain.IpInfo, error)
+	Releases(ctx context.Context) ([]*domain.Release, error)
+	Providers() []*domain.Provider
+	NetworkInfo(ctx context.Context, network netip.Prefix, after netip.Addr, limit int) ([]*domain.IpRange, error)
+	UpdateIpDatabase(ctx context.Context, onStarted func()) (*domain.UpdateReport, error)
+	ReleaseDiff(ctx context.Context, version string) (*domain.ReleaseDiff, error)
+	ExportRanges(ctx context.Context, release *domain.Release, fn func(r *domain.IpRange) error) error
+	CacheGeneration() uint64
+
+	ListOverrides(ctx context.Context) ([]*domain.Override, error)
+	Override(ctx context.Context, id int64) (*domain.Override, error)
+	CreateOverride(ctx context.Context, o *domain.Override) (*domain.Override, error)
+	UpdateOverride(ctx context.Context, o *domain.Override) (*domain.Override, error)
+	DeleteOverride(ctx context.Context, id int64) error
 
 	Close() error
 }
@@ -26,26 +48,65 @@ type Database interface {
 type IpCache interface {
 	Set(ctx context.Context, ipInfo *domain.IpInfo) error
 	Get(ctx context.Context, ip string) (*domain.IpInfo, error)
+	Flush(ctx context.Context, generation uint64) error
 }
 
 type IpLocator struct {
 	d  Database
 	ic IpCache
+
+	mmdb mmdbCache
+	now  func() time.Time
 }
 
 func New(d Database, ic IpCache) *IpLocator {
 	return &IpLocator{
-		d:  d,
-		ic: ic,
+		d:    d,
+		ic:   ic,
+		mmdb: newMmdbCache(),
+		now:  time.Now,
 	}
 }
 
-func (l *IpLocator) GetIpInfo(ctx context.Context, ipString string) (*domain.IpInfo, error) {
-	ip := net.ParseIP(ipString)
-	if ip == nil {
-		return nil, fmt.Errorf("%w: %s", server.ErrWrongIpAddress, ipString)
+func (l *IpLocator) GetIpInfo(
+	ctx context.Context, ipString string, opts *domain.LookupOptions,
+) (*domain.IpInfo, error) {
+	ip, lookupIp, err := ParseIp(ipString)
+	if err != nil {
+		return nil, err
+	}
+
+	if addressType, block := classify(lookupIp); addressType != domain.AddressTypeGlobal {
+		// special-purpose addresses are never located, so there is no point to query the cache or the database
+		ipInfo := &domain.IpInfo{Ip: ip, AddressType: addressType}
+		if block.IsValid() {
+			ipInfo.Network = block.String()
+		}
+
+		return enrich(ipInfo, opts, l.now()), nil
+	}
+
+	now := l.now()
+
+	var ipInfo *domain.IpInfo
+	if opts != nil && !opts.AsOf.IsZero() {
+		// historical lookups are rare and answered by past releases, so they bypass the cache
+		if ipInfo, err = l.d.IpInfoAsOf(ctx, lookupIp, opts.AsOf); err != nil {
+			return nil, fmt.Errorf("could not get ip location: %w", err)
+		}
+		now = opts.AsOf
+	} else if ipInfo, err = l.ipInfo(ctx, lookupIp); err != nil {
+		return nil, err
 	}
 
+	enriched := enrich(ipInfo, opts, now)
+	enriched.Ip = ip
+	enriched.AddressType = domain.AddressTypeGlobal
+
+	return enriched, nil
+}
+
+func (l *IpLocator) ipInfo(ctx context.Context, ip netip.Addr) (*domain.IpInfo, error) {
 	if l.ic == nil {
 		ipInfo, err := l.d.IpInfo(ctx, ip)
 		if err != nil {
@@ -55,7 +116,7 @@ func (l *IpLocator) GetIpInfo(ctx context.Context, ipString string) (*domain.IpI
 		return ipInfo, nil
 	}
 
-	if ipInfo, err := l.ic.Get(ctx, ipString); err == nil {
+	if ipInfo, err := l.ic.Get(ctx, ip.String()); err == nil {
 		return ipInfo, nil
 	}
 
@@ -70,3 +131,84 @@ func (l *IpLocator) GetIpInfo(ctx context.Context, ipString string) (*domain.IpI
 
 	return ipInfo, nil
 }
+
+func (l *IpLocator) GetNetworkInfo(
+	ctx context.Context, cidr string, pageSize int, pageToken string,
+) (*domain.NetworkInfo, error) {
+	network, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", server.ErrWrongNetwork, cidr)
+	}
+	network = network.Masked()
+
+	var after netip.Addr
+	if pageToken != "" {
+		if after = decodePageToken(pageToken); !after.IsValid() {
+			return nil, fmt.Errorf("%w: %s", server.ErrWrongPageToken, pageToken)
+		}
+	}
+
+	if pageSize <= 0 {
+		pageSize = networkDefaultPageSize
+	}
+	pageSize = min(pageSize, networkMaxPageSize)
+
+	// one extra range is requested to find out whether there is a next page
+	ranges, err := l.d.NetworkInfo(ctx, network, after, pageSize+1)
+	if err != nil {
+		return nil, fmt.Errorf("could not get network location: %w", err)
+	}
+
+	networkInfo := &domain.NetworkInfo{
+		Network: network.String(),
+		Ranges:  make([]*domain.IpRange, 0, len(ranges)),
+	}
+	if len(ranges) > pageSize {
+		ranges = ranges[:pageSize]
+		networkInfo.NextPageToken = encodePageToken(ranges[pageSize-1].IpStart)
+	}
+	networkInfo.Ranges = append(networkInfo.Ranges, ranges...)
+
+	return networkInfo, nil
+}
+
+func (l *IpLocator) GetReleases(ctx context.Context) ([]*domain.Release, error) {
+	releases, err := l.d.Releases(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get releases: %w", err)
+	}
+
+	return releases, nil
+}
+
+func (l *IpLocator) GetProviders(_ context.Context) ([]*domain.Provider, error) {
+	return l.d.Providers(), nil
+}
+
+// FlushCache moves the cached lookups to the cache generation of the database once another release is activated or
+// the overrides change, so that they aren't served from the replaced data until the cache ttl expires.
+func (l *IpLocator) FlushCache(ctx context.Context) error {
+	if l.ic == nil {
+		return nil
+	}
+	if err := l.ic.Flush(ctx, l.d.CacheGeneration()); err != nil {
+		return fmt.Errorf("could not flush cache: %w", err)
+	}
+
+	return nil
+}
+
+func encodePageToken(ip netip.Addr) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(ip.String()))
+}
+
+func decodePageToken(token string) netip.Addr {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return netip.Addr{}
+	}
+
+	addr, _ := netip.ParseAddr(string(b))
+
+	return addr
+}
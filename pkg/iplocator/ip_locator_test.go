@@ -3,12 +3,11 @@ package iplocator
 import (
 	"context"
 	"errors"
-	"net"
+	"net/netip"
 	"reflect"
 	"testing"
 	"time"
 
-	"github.com/streamdp/ip-info/database"
 	"github.com/streamdp/ip-info/domain"
 	"github.com/streamdp/ip-info/server"
 )
@@ -29,26 +28,29 @@ func TestLocateIp(t *testing.T) {
 				&databaseMock{
 					err: nil,
 					ipInfo: &domain.IpInfo{
-						Ip:        net.ParseIP("82.28.25.43"),
+						Ip:        netip.MustParseAddr("82.28.25.43"),
 						Continent: "NA",
 						Country:   "US",
 						StateProv: "California",
 						City:      "Mountain View",
-						Latitude:  -122.085,
-						Longitude: 37.4223,
+						Latitude:  37.4223,
+						Longitude: -122.085,
 					},
 				},
 				nil,
 			),
 			ipString: "82.28.25.43",
 			wantIpInfo: &domain.IpInfo{
-				Ip:        net.ParseIP("82.28.25.43"),
-				Continent: "NA",
-				Country:   "US",
-				StateProv: "California",
-				City:      "Mountain View",
-				Latitude:  -122.085,
-				Longitude: 37.4223,
+				Ip:          netip.MustParseAddr("82.28.25.43"),
+				Continent:   "NA",
+				Country:     "US",
+				StateProv:   "California",
+				City:        "Mountain View",
+				Latitude:    37.4223,
+				Longitude:   -122.085,
+				TimeZone:    "America/Los_Angeles",
+				UtcOffset:   "-08:00",
+				AddressType: domain.AddressTypeGlobal,
 			},
 			wantErr: false,
 		},
@@ -58,25 +60,28 @@ func TestLocateIp(t *testing.T) {
 				&databaseMock{},
 				&cacheMock{
 					ipInfo: &domain.IpInfo{
-						Ip:        net.ParseIP("82.28.25.43"),
+						Ip:        netip.MustParseAddr("82.28.25.43"),
 						Continent: "NA",
 						Country:   "US",
 						StateProv: "California",
 						City:      "Mountain View",
-						Latitude:  -122.085,
-						Longitude: 37.4223,
+						Latitude:  37.4223,
+						Longitude: -122.085,
 					},
 				},
 			),
 			ipString: "82.28.25.43",
 			wantIpInfo: &domain.IpInfo{
-				Ip:        net.ParseIP("82.28.25.43"),
-				Continent: "NA",
-				Country:   "US",
-				StateProv: "California",
-				City:      "Mountain View",
-				Latitude:  -122.085,
-				Longitude: 37.4223,
+				Ip:          netip.MustParseAddr("82.28.25.43"),
+				Continent:   "NA",
+				Country:     "US",
+				StateProv:   "California",
+				City:        "Mountain View",
+				Latitude:    37.4223,
+				Longitude:   -122.085,
+				TimeZone:    "America/Los_Angeles",
+				UtcOffset:   "-08:00",
+				AddressType: domain.AddressTypeGlobal,
 			},
 			wantErr: false,
 		},
@@ -84,7 +89,7 @@ func TestLocateIp(t *testing.T) {
 			name: "get database error",
 			locator: New(
 				&databaseMock{
-					err:    database.ErrNoIpAddress,
+					err:    domain.ErrNoIpAddress,
 					ipInfo: nil,
 				},
 				&cacheMock{
@@ -99,7 +104,7 @@ func TestLocateIp(t *testing.T) {
 			name: "get database error, when cache uninitialized",
 			locator: New(
 				&databaseMock{
-					err:    database.ErrNoIpAddress,
+					err:    domain.ErrNoIpAddress,
 					ipInfo: nil,
 				},
 				nil,
@@ -121,6 +126,30 @@ func TestLocateIp(t *testing.T) {
 			wantIpInfo: nil,
 			wantErr:    true,
 		},
+		{
+			name: "private address is not looked up",
+			locator: New(
+				&databaseMock{err: errCommon},
+				&cacheMock{getErr: errCommon, setErr: errCommon},
+			),
+			ipString: "10.1.2.3",
+			wantIpInfo: &domain.IpInfo{
+				Ip:          netip.MustParseAddr("10.1.2.3"),
+				AddressType: domain.AddressTypePrivate,
+				Network:     "10.0.0.0/8",
+			},
+			wantErr: false,
+		},
+		{
+			name:     "ipv6 bogon is not looked up",
+			locator:  New(&databaseMock{err: errCommon}, nil),
+			ipString: "4000::1",
+			wantIpInfo: &domain.IpInfo{
+				Ip:          netip.MustParseAddr("4000::1"),
+				AddressType: domain.AddressTypeReserved,
+			},
+			wantErr: false,
+		},
 		{
 			name:       "get ip parsing error",
 			locator:    New(&databaseMock{}, &cacheMock{}),
@@ -131,7 +160,11 @@ func TestLocateIp(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotIpInfo, err := tt.locator.GetIpInfo(context.Background(), tt.ipString)
+			if l, ok := tt.locator.(*IpLocator); ok {
+				l.now = func() time.Time { return time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC) }
+			}
+
+			gotIpInfo, err := tt.locator.GetIpInfo(context.Background(), tt.ipString, nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetIpInfo() error = %v, wantErr %v", err, tt.wantErr)
 
@@ -144,17 +177,307 @@ func TestLocateIp(t *testing.T) {
 	}
 }
 
+func TestGetIpInfoAsOf(t *testing.T) {
+	current := &domain.IpInfo{Ip: netip.MustParseAddr("82.28.25.43"), Country: "US"}
+	past := &domain.IpInfo{Ip: netip.MustParseAddr("82.28.25.43"), Country: "GB"}
+	asOf := time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		d           Database
+		opts        *domain.LookupOptions
+		wantCountry string
+		wantErr     error
+	}{
+		{
+			name:        "current release",
+			d:           &databaseMock{ipInfo: current, asOf: past},
+			opts:        &domain.LookupOptions{},
+			wantCountry: "US",
+		},
+		{
+			name:        "past release",
+			d:           &databaseMock{ipInfo: current, asOf: past},
+			opts:        &domain.LookupOptions{AsOf: asOf},
+			wantCountry: "GB",
+		},
+		{
+			name:    "no release for the date",
+			d:       &databaseMock{err: domain.ErrNoRelease},
+			opts:    &domain.LookupOptions{AsOf: asOf},
+			wantErr: domain.ErrNoRelease,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the cache fails every call, so only lookups that bypass it succeed when the release is a past one
+			var c IpCache
+			if !tt.opts.AsOf.IsZero() {
+				c = &cacheMock{getErr: errCommon, setErr: errCommon}
+			}
+
+			got, err := New(tt.d, c).GetIpInfo(context.Background(), "82.28.25.43", tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetIpInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got.Country != tt.wantCountry {
+				t.Errorf("GetIpInfo() country = %v, want %v", got.Country, tt.wantCountry)
+			}
+		})
+	}
+}
+
+func TestGetReleases(t *testing.T) {
+	releases := []*domain.Release{
+		{Dataset: "city", Version: "2024-07", Active: true},
+		{Dataset: "city", Version: "2024-06"},
+	}
+
+	got, err := New(&databaseMock{releases: releases}, nil).GetReleases(context.Background())
+	if err != nil {
+		t.Fatalf("GetReleases() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, releases) {
+		t.Errorf("GetReleases() got = %v, want %v", got, releases)
+	}
+
+	if _, err = New(&databaseMock{err: errCommon}, nil).GetReleases(context.Background()); !errors.Is(err, errCommon) {
+		t.Errorf("GetReleases() error = %v, want %v", err, errCommon)
+	}
+}
+
+func TestGetProviders(t *testing.T) {
+	providers := []*domain.Provider{{Name: "dbip", Attribution: "IP Geolocation by DB-IP"}}
+
+	got, err := New(&databaseMock{providers: providers}, nil).GetProviders(context.Background())
+	if err != nil {
+		t.Fatalf("GetProviders() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, providers) {
+		t.Errorf("GetProviders() got = %v, want %v", got, providers)
+	}
+}
+
+func TestFlushCache(t *testing.T) {
+	if err := New(&databaseMock{}, nil).FlushCache(context.Background()); err != nil {
+		t.Errorf("FlushCache() without cache error = %v", err)
+	}
+
+	cache := &cacheMock{}
+	err := New(&databaseMock{generation: 42}, cache).FlushCache(context.Background())
+	if err != nil || cache.flushed != 1 {
+		t.Errorf("FlushCache() error = %v, flushed %d times, want once", err, cache.flushed)
+	}
+	if cache.generation != 42 {
+		t.Errorf("FlushCache() generation = %d, want 42", cache.generation)
+	}
+
+	err = New(&databaseMock{}, &cacheMock{setErr: errCommon}).FlushCache(context.Background())
+	if !errors.Is(err, errCommon) {
+		t.Errorf("FlushCache() error = %v, want %v", err, errCommon)
+	}
+}
+
+func TestGetNetworkInfo(t *testing.T) {
+	ranges := []*domain.IpRange{
+		{IpStart: netip.MustParseAddr("203.0.112.0"), IpEnd: netip.MustParseAddr("203.0.112.255"), Country: "AU"},
+		{IpStart: netip.MustParseAddr("203.0.113.0"), IpEnd: netip.MustParseAddr("203.0.113.255"), Country: "AU"},
+		{IpStart: netip.MustParseAddr("203.0.114.0"), IpEnd: netip.MustParseAddr("203.0.115.255"), Country: "NZ"},
+	}
+
+	tests := []struct {
+		name          string
+		d             Database
+		cidr          string
+		pageSize      int
+		pageToken     string
+		wantRanges    int
+		wantNextToken string
+		wantErr       error
+	}{
+		{
+			name:       "get all ranges",
+			d:          &databaseMock{ranges: ranges},
+			cidr:       "203.0.113.0/22",
+			wantRanges: 3,
+		},
+		{
+			name:          "get first page",
+			d:             &databaseMock{ranges: ranges},
+			cidr:          "203.0.113.0/22",
+			pageSize:      2,
+			wantRanges:    2,
+			wantNextToken: encodePageToken(netip.MustParseAddr("203.0.113.0")),
+		},
+		{
+			name:       "get last page",
+			d:          &databaseMock{ranges: ranges},
+			cidr:       "203.0.113.0/22",
+			pageSize:   2,
+			pageToken:  encodePageToken(netip.MustParseAddr("203.0.113.0")),
+			wantRanges: 1,
+		},
+		{
+			name:    "wrong network prefix",
+			d:       &databaseMock{ranges: ranges},
+			cidr:    "203.0.113.0/33",
+			wantErr: server.ErrWrongNetwork,
+		},
+		{
+			name:      "wrong page token",
+			d:         &databaseMock{ranges: ranges},
+			cidr:      "203.0.113.0/22",
+			pageToken: "wrong",
+			wantErr:   server.ErrWrongPageToken,
+		},
+		{
+			name:    "get database error",
+			d:       &databaseMock{err: errCommon},
+			cidr:    "203.0.113.0/22",
+			wantErr: errCommon,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.d, nil).GetNetworkInfo(context.Background(), tt.cidr, tt.pageSize, tt.pageToken)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetNetworkInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got.Network != "203.0.112.0/22" {
+				t.Errorf("GetNetworkInfo() network = %v, want 203.0.112.0/22", got.Network)
+			}
+			if len(got.Ranges) != tt.wantRanges {
+				t.Errorf("GetNetworkInfo() ranges = %d, want %d", len(got.Ranges), tt.wantRanges)
+			}
+			if got.NextPageToken != tt.wantNextToken {
+				t.Errorf("GetNetworkInfo() next page token = %v, want %v", got.NextPageToken, tt.wantNextToken)
+			}
+		})
+	}
+}
+
 type databaseMock struct {
-	err    error
-	ipInfo *domain.IpInfo
+	err        error
+	ipInfo     *domain.IpInfo
+	asOf       *domain.IpInfo
+	ranges     []*domain.IpRange
+	releases   []*domain.Release
+	providers  []*domain.Provider
+	overrides  []*domain.Override
+	exports    int
+	generation uint64
 }
 
-func (d *databaseMock) IpInfo(_ context.Context, _ net.IP) (*domain.IpInfo, error) {
+func (d *databaseMock) IpInfo(_ context.Context, _ netip.Addr) (*domain.IpInfo, error) {
 	return d.ipInfo, d.err
 }
 
-func (d *databaseMock) UpdateIpDatabase(_ context.Context) (time.Duration, error) {
-	return 0, nil
+func (d *databaseMock) IpInfoAsOf(_ context.Context, _ netip.Addr, _ time.Time) (*domain.IpInfo, error) {
+	return d.asOf, d.err
+}
+
+func (d *databaseMock) Releases(_ context.Context) ([]*domain.Release, error) {
+	return d.releases, d.err
+}
+
+func (d *databaseMock) Providers() []*domain.Provider {
+	return d.providers
+}
+
+func (d *databaseMock) NetworkInfo(
+	_ context.Context, _ netip.Prefix, after netip.Addr, limit int,
+) ([]*domain.IpRange, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	var ranges []*domain.IpRange
+	for _, r := range d.ranges {
+		if after.IsValid() && r.IpStart.Compare(after) <= 0 {
+			continue
+		}
+		if len(ranges) == limit {
+			break
+		}
+		ranges = append(ranges, r)
+	}
+
+	return ranges, nil
+}
+
+func (d *databaseMock) ReleaseDiff(_ context.Context, _ string) (*domain.ReleaseDiff, error) {
+	return nil, d.err
+}
+
+func (d *databaseMock) ExportRanges(_ context.Context, _ *domain.Release, fn func(r *domain.IpRange) error) error {
+	if d.err != nil {
+		return d.err
+	}
+	d.exports++
+	for _, r := range d.ranges {
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *databaseMock) ListOverrides(_ context.Context) ([]*domain.Override, error) {
+	return d.overrides, d.err
+}
+
+func (d *databaseMock) Override(_ context.Context, id int64) (*domain.Override, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	for _, o := range d.overrides {
+		if o.Id == id {
+			return o, nil
+		}
+	}
+
+	return nil, domain.ErrNoOverride
+}
+
+func (d *databaseMock) CreateOverride(_ context.Context, o *domain.Override) (*domain.Override, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	o.Id = int64(len(d.overrides) + 1)
+	d.overrides = append(d.overrides, o)
+
+	return o, nil
+}
+
+func (d *databaseMock) UpdateOverride(ctx context.Context, o *domain.Override) (*domain.Override, error) {
+	previous, err := d.Override(ctx, o.Id)
+	if err != nil {
+		return nil, err
+	}
+	d.overrides[previous.Id-1] = o
+
+	return o, nil
+}
+
+func (d *databaseMock) DeleteOverride(ctx context.Context, id int64) error {
+	_, err := d.Override(ctx, id)
+
+	return err
+}
+
+func (d *databaseMock) UpdateIpDatabase(_ context.Context, _ func()) (*domain.UpdateReport, error) {
+	return &domain.UpdateReport{}, nil
+}
+
+func (d *databaseMock) CacheGeneration() uint64 {
+	return d.generation
 }
 
 func (d *databaseMock) Close() error {
@@ -164,7 +487,9 @@ func (d *databaseMock) Close() error {
 type cacheMock struct {
 	getErr, setErr error
 
-	ipInfo *domain.IpInfo
+	ipInfo     *domain.IpInfo
+	flushed    int
+	generation uint64
 }
 
 func (c *cacheMock) Set(context.Context, *domain.IpInfo) error {
@@ -174,3 +499,10 @@ func (c *cacheMock) Set(context.Context, *domain.IpInfo) error {
 func (c *cacheMock) Get(context.Context, string) (*domain.IpInfo, error) {
 	return c.ipInfo, c.getErr
 }
+
+func (c *cacheMock) Flush(_ context.Context, generation uint64) error {
+	c.flushed++
+	c.generation = generation
+
+	return c.setErr
+}
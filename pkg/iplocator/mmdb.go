@@ -0,0 +1,278 @@
+package iplocator
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"slices"
+
+	"github.com/streamdp/ip-info/domain"
+	"github.com/streamdp/ip-info/pkg/countries"
+	"github.com/streamdp/ip-info/pkg/mmdb"
+	"github.com/streamdp/ip-info/pkg/timezones"
+)
+
+const (
+	mmdbCityDataset = "city"
+	mmdbAsnDataset  = "asn"
+)
+
+var errMmdbDataset = errors.New("only the city and asn datasets can be exported to mmdb")
+
+// mmdbCache keeps the latest city export, it's built again once the release or the overrides change. The build
+// semaphore lets a single build run at a time and guards the export.
+type mmdbCache struct {
+	build  chan struct{}
+	export *domain.MmdbExport
+}
+
+func newMmdbCache() mmdbCache {
+	return mmdbCache{build: make(chan struct{}, 1)}
+}
+
+// mmdbCity is the location of the city ranges, the values of the database follow the GeoIP2-City structure.
+type mmdbCity struct {
+	continent, country, stateProv, city string
+	latitude, longitude                 float64
+	// overridden marks the locations corrected by an override, so that the less specific overrides skip them
+	overridden bool
+}
+
+type mmdbAsn struct {
+	asn   uint32
+	asOrg string
+}
+
+// LatestMmdb returns the export of the active city release with the overrides, building it when they have changed
+// since the previous one. A single build runs at a time and the concurrent calls wait for it, or give up once their
+// context is done, while the build isn't canceled with the call that started it, so it's done once per version.
+func (l *IpLocator) LatestMmdb(ctx context.Context) (*domain.MmdbExport, error) {
+	release, overrides, err := l.mmdbSources(ctx, mmdbCityDataset)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case l.mmdb.build <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-l.mmdb.build }()
+
+	if e := l.mmdb.export; e != nil && e.Version == mmdbVersion(release, overrides) {
+		return e, nil
+	}
+
+	var buf bytes.Buffer
+	export, err := l.exportMmdb(context.WithoutCancel(ctx), release, overrides, &buf)
+	if err != nil {
+		return nil, err
+	}
+	export.Data = buf.Bytes()
+	l.mmdb.export = export
+
+	return export, nil
+}
+
+// ExportMmdb writes the active release of the city or asn dataset in the MaxMind DB format, the city one along with
+// the overrides.
+func (l *IpLocator) ExportMmdb(ctx context.Context, dataset string, w io.Writer) (*domain.MmdbExport, error) {
+	release, overrides, err := l.mmdbSources(ctx, dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.exportMmdb(ctx, release, overrides, w)
+}
+
+func (l *IpLocator) mmdbSources(ctx context.Context, dataset string) (*domain.Release, []*domain.Override, error) {
+	if dataset != mmdbCityDataset && dataset != mmdbAsnDataset {
+		return nil, nil, fmt.Errorf("%w: %s", errMmdbDataset, dataset)
+	}
+
+	releases, err := l.d.Releases(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get releases: %w", err)
+	}
+	i := slices.IndexFunc(releases, func(r *domain.Release) bool { return r.Dataset == dataset && r.Active })
+	if i < 0 {
+		return nil, nil, fmt.Errorf("%w: no active %s release", domain.ErrNoRelease, dataset)
+	}
+
+	// the overrides only correct the locations
+	if dataset != mmdbCityDataset {
+		return releases[i], nil, nil
+	}
+	overrides, err := l.d.ListOverrides(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get overrides: %w", err)
+	}
+
+	return releases[i], overrides, nil
+}
+
+func (l *IpLocator) exportMmdb(
+	ctx context.Context, release *domain.Release, overrides []*domain.Override, w io.Writer,
+) (*domain.MmdbExport, error) {
+	export := &domain.MmdbExport{
+		Dataset: release.Dataset,
+		Version: mmdbVersion(release, overrides),
+		BuiltAt: l.now().UTC(),
+	}
+	meta := mmdb.Metadata{
+		Description: fmt.Sprintf("ip-info export of the db-ip.com %s release %s", release.Dataset, export.Version),
+		Languages:   []string{countries.DefaultLanguage},
+		BuildTime:   export.BuiltAt,
+	}
+
+	var err error
+	if release.Dataset == mmdbAsnDataset {
+		meta.DatabaseType = "ip-info-ASN"
+		export.Ranges, err = writeMmdb(ctx, l.d, release, mmdb.New(meta, mmdbAsn.value), w,
+			func(r *domain.IpRange) mmdbAsn { return mmdbAsn{asn: r.Asn, asOrg: r.AsOrg} }, nil, nil,
+		)
+	} else {
+		meta.DatabaseType = "ip-info-City"
+		export.Ranges, err = writeMmdb(ctx, l.d, release, mmdb.New(meta, mmdbCity.value), w,
+			func(r *domain.IpRange) mmdbCity {
+				return mmdbCity{
+					continent: r.Continent,
+					country:   r.Country,
+					stateProv: r.StateProv,
+					city:      r.City,
+					latitude:  r.Latitude,
+					longitude: r.Longitude,
+				}
+			}, overrides, mmdbCity.override,
+		)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not export %s release to mmdb: %w", release.Dataset, err)
+	}
+
+	return export, nil
+}
+
+// writeMmdb inserts the values of the ranges of the release, applies the overrides from the most specific one and
+// writes the database.
+func writeMmdb[T comparable](
+	ctx context.Context, d Database, release *domain.Release, mw *mmdb.Writer[T], w io.Writer,
+	value func(r *domain.IpRange) T, overrides []*domain.Override, override func(v T, o *domain.Override) T,
+) (int64, error) {
+	var ranges int64
+	if err := d.ExportRanges(ctx, release, func(r *domain.IpRange) error {
+		ranges++
+
+		return mw.InsertRange(r.IpStart, r.IpEnd, value(r))
+	}); err != nil {
+		return 0, err
+	}
+
+	overrides = slices.Clone(overrides)
+	slices.SortStableFunc(overrides, func(a, b *domain.Override) int {
+		return cmp.Compare(b.Network.Bits(), a.Network.Bits())
+	})
+	for _, o := range overrides {
+		mw.Update(o.Network, func(v T) T { return override(v, o) })
+	}
+
+	if _, err := mw.WriteTo(w); err != nil {
+		return 0, err
+	}
+
+	return ranges, nil
+}
+
+// mmdbVersion identifies the exported data by the release version and, since the overrides change the data without
+// a release, by a fingerprint of their ids and update times.
+func mmdbVersion(release *domain.Release, overrides []*domain.Override) string {
+	if len(overrides) == 0 {
+		return release.Version
+	}
+
+	ids := slices.Clone(overrides)
+	slices.SortFunc(ids, func(a, b *domain.Override) int { return cmp.Compare(a.Id, b.Id) })
+
+	h := fnv.New32a()
+	for _, o := range ids {
+		_, _ = fmt.Fprintf(h, "%d:%d;", o.Id, o.UpdatedAt.UnixNano())
+	}
+
+	return fmt.Sprintf("%s-%08x", release.Version, h.Sum32())
+}
+
+// override corrects the location unless a more specific override has already corrected it.
+func (c mmdbCity) override(o *domain.Override) mmdbCity {
+	if c.overridden {
+		return c
+	}
+
+	ipInfo := &domain.IpInfo{
+		Continent: c.continent,
+		Country:   c.country,
+		StateProv: c.stateProv,
+		City:      c.city,
+		Latitude:  c.latitude,
+		Longitude: c.longitude,
+	}
+	o.Apply(ipInfo)
+
+	return mmdbCity{
+		continent:  ipInfo.Continent,
+		country:    ipInfo.Country,
+		stateProv:  ipInfo.StateProv,
+		city:       ipInfo.City,
+		latitude:   ipInfo.Latitude,
+		longitude:  ipInfo.Longitude,
+		overridden: true,
+	}
+}
+
+func (c mmdbCity) value() any {
+	location := mmdb.Map{"latitude": c.latitude, "longitude": c.longitude}
+	if timeZone := timezones.Lookup(c.country, c.latitude, c.longitude); timeZone != "" {
+		location["time_zone"] = timeZone
+	}
+
+	v := mmdb.Map{"location": location}
+	if c.continent != "" {
+		continent := mmdb.Map{"code": c.continent}
+		if name := countries.ContinentName(c.continent); name != "" {
+			continent["names"] = names(name)
+		}
+		v["continent"] = continent
+	}
+	if c.country != "" {
+		country := mmdb.Map{"iso_code": c.country}
+		if ref, ok := countries.Lookup(c.country); ok {
+			country["names"] = names(ref.Name)
+			if ref.IsEu {
+				country["is_in_european_union"] = true
+			}
+		}
+		v["country"] = country
+	}
+	if c.stateProv != "" {
+		v["subdivisions"] = []any{mmdb.Map{"names": names(c.stateProv)}}
+	}
+	if c.city != "" {
+		v["city"] = mmdb.Map{"names": names(c.city)}
+	}
+
+	return v
+}
+
+func (a mmdbAsn) value() any {
+	return mmdb.Map{
+		"autonomous_system_number":       a.asn,
+		"autonomous_system_organization": a.asOrg,
+	}
+}
+
+func names(name string) mmdb.Map {
+	return mmdb.Map{countries.DefaultLanguage: name}
+}
@@ -0,0 +1,173 @@
+package iplocator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+func newMmdbDatabaseMock() *databaseMock {
+	return &databaseMock{
+		releases: []*domain.Release{
+			{Dataset: "city", Version: "2026-09"},
+			{Dataset: "city", Version: "2026-10", Active: true},
+			{Dataset: "asn", Version: "2026-10", Active: true},
+		},
+		ranges: []*domain.IpRange{
+			{
+				IpStart:   netip.MustParseAddr("8.8.8.0"),
+				IpEnd:     netip.MustParseAddr("8.8.8.255"),
+				Continent: "NA",
+				Country:   "US",
+				StateProv: "California",
+				City:      "Mountain View",
+				Latitude:  37.4223,
+				Longitude: -122.085,
+			},
+		},
+	}
+}
+
+func TestIpLocator_LatestMmdb(t *testing.T) {
+	d := newMmdbDatabaseMock()
+	l := New(d, nil)
+	l.now = func() time.Time { return time.Date(2026, 10, 19, 3, 4, 12, 0, time.UTC) }
+
+	export, err := l.LatestMmdb(context.Background())
+	if err != nil {
+		t.Fatalf("LatestMmdb() error = %v", err)
+	}
+	if export.Dataset != "city" || export.Version != "2026-10" || export.Ranges != 1 {
+		t.Errorf("LatestMmdb() = %+v, want the active city release", export)
+	}
+	if !bytes.Contains(export.Data, []byte("\xab\xcd\xefMaxMind.com")) ||
+		!bytes.Contains(export.Data, []byte("Mountain View")) {
+		t.Errorf("LatestMmdb() data isn't the database of the release")
+	}
+
+	if cached, _ := l.LatestMmdb(context.Background()); cached != export || d.exports != 1 {
+		t.Errorf("LatestMmdb() built the unchanged export again, %d exports", d.exports)
+	}
+
+	city := "Sunnyvale"
+	d.overrides = []*domain.Override{{
+		Id:        1,
+		Network:   netip.MustParsePrefix("8.8.8.0/24"),
+		City:      &city,
+		UpdatedAt: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+	}}
+	overridden, err := l.LatestMmdb(context.Background())
+	if err != nil {
+		t.Fatalf("LatestMmdb() error = %v", err)
+	}
+	if d.exports != 2 || overridden.Version == export.Version {
+		t.Errorf("LatestMmdb() version = %s, want another export once the overrides change", overridden.Version)
+	}
+	if !bytes.Contains(overridden.Data, []byte("Sunnyvale")) || bytes.Contains(overridden.Data, []byte("Mountain View")) {
+		t.Errorf("LatestMmdb() data doesn't have the override")
+	}
+}
+
+func TestIpLocator_LatestMmdbBuilding(t *testing.T) {
+	d := newMmdbDatabaseMock()
+	l := New(d, nil)
+
+	// another call is building the export
+	l.mmdb.build <- struct{}{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.LatestMmdb(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("LatestMmdb() error = %v, want %v", err, context.Canceled)
+	}
+	if d.exports != 0 {
+		t.Errorf("LatestMmdb() built the export %d times while another build was running", d.exports)
+	}
+
+	<-l.mmdb.build
+	if _, err := l.LatestMmdb(context.Background()); err != nil || d.exports != 1 {
+		t.Errorf("LatestMmdb() error = %v, %d exports, want the export built once the build is done", err, d.exports)
+	}
+}
+
+func TestIpLocator_ExportMmdb(t *testing.T) {
+	tests := []struct {
+		name     string
+		dataset  string
+		releases []*domain.Release
+		wantErr  error
+	}{
+		{
+			name:    "asn",
+			dataset: "asn",
+		},
+		{
+			name:     "no active release",
+			dataset:  "city",
+			releases: []*domain.Release{{Dataset: "city", Version: "2026-10"}},
+			wantErr:  domain.ErrNoRelease,
+		},
+		{
+			name:    "provider",
+			dataset: "geofeed",
+			wantErr: errMmdbDataset,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newMmdbDatabaseMock()
+			if tt.releases != nil {
+				d.releases = tt.releases
+			}
+
+			var buf bytes.Buffer
+			export, err := New(d, nil).ExportMmdb(context.Background(), tt.dataset, &buf)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ExportMmdb() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (export.Dataset != tt.dataset || buf.Len() == 0 || export.Data != nil) {
+				t.Errorf("ExportMmdb() = %+v, %d bytes, want the written %s release", export, buf.Len(), tt.dataset)
+			}
+		})
+	}
+}
+
+func Test_mmdbCity_override(t *testing.T) {
+	country, city := "CA", "Toronto"
+	o := &domain.Override{Country: &country, City: &city}
+	c := mmdbCity{continent: "NA", country: "US", stateProv: "California", city: "Mountain View"}
+
+	got := c.override(o)
+	want := mmdbCity{continent: "NA", country: "CA", stateProv: "California", city: "Toronto", overridden: true}
+	if got != want {
+		t.Errorf("override() = %+v, want %+v", got, want)
+	}
+
+	other := "Ottawa"
+	if again := got.override(&domain.Override{City: &other}); again != got {
+		t.Errorf("override() = %+v, want the more specific override kept", again)
+	}
+}
+
+func Test_mmdbVersion(t *testing.T) {
+	release := &domain.Release{Dataset: "city", Version: "2026-10"}
+	updatedAt := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	overrides := []*domain.Override{{Id: 2, UpdatedAt: updatedAt}, {Id: 1, UpdatedAt: updatedAt}}
+
+	if got := mmdbVersion(release, nil); got != "2026-10" {
+		t.Errorf("mmdbVersion() = %s, want the release version without overrides", got)
+	}
+	got := mmdbVersion(release, overrides)
+	if reordered := mmdbVersion(release, []*domain.Override{overrides[1], overrides[0]}); reordered != got {
+		t.Errorf("mmdbVersion() = %s, want %s whatever the order of the overrides", reordered, got)
+	}
+	updatedOverrides := []*domain.Override{{Id: 1, UpdatedAt: updatedAt.Add(time.Second)}, overrides[0]}
+	if updated := mmdbVersion(release, updatedOverrides); updated == got {
+		t.Errorf("mmdbVersion() = %s, want another version once an override is updated", updated)
+	}
+}
@@ -0,0 +1,121 @@
+package iplocator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/streamdp/ip-info/domain"
+	"github.com/streamdp/ip-info/pkg/countries"
+	"github.com/streamdp/ip-info/server"
+)
+
+func (l *IpLocator) ListOverrides(ctx context.Context) ([]*domain.Override, error) {
+	overrides, err := l.d.ListOverrides(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list overrides: %w", err)
+	}
+
+	return overrides, nil
+}
+
+func (l *IpLocator) GetOverride(ctx context.Context, id int64) (*domain.Override, error) {
+	o, err := l.d.Override(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("could not get override: %w", err)
+	}
+
+	return o, nil
+}
+
+func (l *IpLocator) CreateOverride(ctx context.Context, o *domain.Override) (*domain.Override, error) {
+	if err := validateOverride(o); err != nil {
+		return nil, err
+	}
+
+	created, err := l.d.CreateOverride(ctx, o)
+	if err != nil {
+		return nil, fmt.Errorf("could not create override: %w", err)
+	}
+
+	if err = l.FlushCache(ctx); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (l *IpLocator) UpdateOverride(ctx context.Context, o *domain.Override) (*domain.Override, error) {
+	if err := validateOverride(o); err != nil {
+		return nil, err
+	}
+
+	updated, err := l.d.UpdateOverride(ctx, o)
+	if err != nil {
+		return nil, fmt.Errorf("could not update override: %w", err)
+	}
+
+	if err = l.FlushCache(ctx); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (l *IpLocator) DeleteOverride(ctx context.Context, id int64) error {
+	if err := l.d.DeleteOverride(ctx, id); err != nil {
+		return fmt.Errorf("could not delete override: %w", err)
+	}
+
+	return l.FlushCache(ctx)
+}
+
+func (l *IpLocator) ReleaseDiff(ctx context.Context, version string) (*domain.ReleaseDiff, error) {
+	diff, err := l.d.ReleaseDiff(ctx, version)
+	if err != nil {
+		return nil, fmt.Errorf("could not get release diff: %w", err)
+	}
+
+	return diff, nil
+}
+
+// validateOverride checks the override and normalizes its network and codes.
+func validateOverride(o *domain.Override) error {
+	if !o.Network.IsValid() {
+		return fmt.Errorf("%w: network is required", server.ErrWrongOverride)
+	}
+	if o.Network.Addr().Is4In6() || o.Network.Addr().Zone() != "" {
+		return fmt.Errorf("%w: network %s isn't canonical", server.ErrWrongOverride, o.Network)
+	}
+	o.Network = o.Network.Masked()
+
+	if o.Continent == nil && o.Country == nil && o.StateProv == nil && o.City == nil && o.Latitude == nil &&
+		o.Longitude == nil {
+		return fmt.Errorf("%w: at least one location field is required", server.ErrWrongOverride)
+	}
+
+	if o.Continent != nil {
+		if countries.ContinentName(*o.Continent) == "" {
+			return fmt.Errorf("%w: unknown continent %s", server.ErrWrongOverride, *o.Continent)
+		}
+		*o.Continent = strings.ToUpper(*o.Continent)
+	}
+	if o.Country != nil {
+		if _, ok := countries.Lookup(*o.Country); !ok {
+			return fmt.Errorf("%w: unknown country %s", server.ErrWrongOverride, *o.Country)
+		}
+		*o.Country = strings.ToUpper(*o.Country)
+	}
+
+	if (o.Latitude == nil) != (o.Longitude == nil) {
+		return fmt.Errorf("%w: latitude and longitude are set together", server.ErrWrongOverride)
+	}
+	if o.Latitude != nil && (*o.Latitude < -90 || *o.Latitude > 90) {
+		return fmt.Errorf("%w: latitude %v is out of range", server.ErrWrongOverride, *o.Latitude)
+	}
+	if o.Longitude != nil && (*o.Longitude < -180 || *o.Longitude > 180) {
+		return fmt.Errorf("%w: longitude %v is out of range", server.ErrWrongOverride, *o.Longitude)
+	}
+
+	return nil
+}
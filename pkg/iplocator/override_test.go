@@ -0,0 +1,130 @@
+package iplocator
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/streamdp/ip-info/domain"
+	"github.com/streamdp/ip-info/server"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func Test_validateOverride(t *testing.T) {
+	tests := []struct {
+		name        string
+		o           *domain.Override
+		wantNetwork string
+		wantErr     bool
+	}{
+		{
+			name:        "city override",
+			o:           &domain.Override{Network: netip.MustParsePrefix("203.0.113.7/24"), City: ptr("Auckland")},
+			wantNetwork: "203.0.113.0/24",
+		},
+		{
+			name:        "lower case codes",
+			o:           &domain.Override{Network: netip.MustParsePrefix("2001:db8::/32"), Country: ptr("nz")},
+			wantNetwork: "2001:db8::/32",
+		},
+		{
+			name:    "missing network",
+			o:       &domain.Override{City: ptr("Auckland")},
+			wantErr: true,
+		},
+		{
+			name:    "IPv4-mapped network",
+			o:       &domain.Override{Network: netip.MustParsePrefix("::ffff:203.0.113.0/120"), City: ptr("Auckland")},
+			wantErr: true,
+		},
+		{
+			name:    "no location fields",
+			o:       &domain.Override{Network: netip.MustParsePrefix("203.0.113.0/24")},
+			wantErr: true,
+		},
+		{
+			name:    "unknown country",
+			o:       &domain.Override{Network: netip.MustParsePrefix("203.0.113.0/24"), Country: ptr("XX")},
+			wantErr: true,
+		},
+		{
+			name:    "unknown continent",
+			o:       &domain.Override{Network: netip.MustParsePrefix("203.0.113.0/24"), Continent: ptr("XX")},
+			wantErr: true,
+		},
+		{
+			name:    "latitude without longitude",
+			o:       &domain.Override{Network: netip.MustParsePrefix("203.0.113.0/24"), Latitude: ptr(-36.85)},
+			wantErr: true,
+		},
+		{
+			name: "longitude out of range",
+			o: &domain.Override{
+				Network:   netip.MustParsePrefix("203.0.113.0/24"),
+				Latitude:  ptr(-36.85),
+				Longitude: ptr(181.0),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOverride(tt.o)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateOverride() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, server.ErrWrongOverride) {
+					t.Errorf("validateOverride() error = %v, want %v", err, server.ErrWrongOverride)
+				}
+
+				return
+			}
+			if tt.o.Network.String() != tt.wantNetwork {
+				t.Errorf("validateOverride() network = %v, want %v", tt.o.Network, tt.wantNetwork)
+			}
+			if tt.o.Country != nil && *tt.o.Country != "NZ" {
+				t.Errorf("validateOverride() country = %v, want NZ", *tt.o.Country)
+			}
+		})
+	}
+}
+
+func TestIpLocator_overrides(t *testing.T) {
+	d := &databaseMock{}
+	c := &cacheMock{}
+	l := New(d, c)
+
+	created, err := l.CreateOverride(context.Background(), &domain.Override{
+		Network: netip.MustParsePrefix("203.0.113.0/24"),
+		City:    ptr("Auckland"),
+	})
+	if err != nil {
+		t.Fatalf("CreateOverride() error = %v", err)
+	}
+
+	if _, err = l.UpdateOverride(context.Background(), &domain.Override{
+		Id:      created.Id,
+		Network: netip.MustParsePrefix("198.51.100.0/24"),
+		City:    ptr("Wellington"),
+	}); err != nil {
+		t.Fatalf("UpdateOverride() error = %v", err)
+	}
+
+	if err = l.DeleteOverride(context.Background(), created.Id); err != nil {
+		t.Fatalf("DeleteOverride() error = %v", err)
+	}
+
+	// every change drops the cached lookups of the instance
+	if c.flushed != 3 {
+		t.Errorf("flushed = %d, want 3", c.flushed)
+	}
+
+	if err = l.DeleteOverride(context.Background(), 42); !errors.Is(err, domain.ErrNoOverride) {
+		t.Errorf("DeleteOverride() error = %v, want %v", err, domain.ErrNoOverride)
+	}
+}
@@ -0,0 +1,46 @@
+package iplocator
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/streamdp/ip-info/server"
+)
+
+var (
+	prefix6to4   = netip.MustParsePrefix("2002::/16")
+	prefixTeredo = netip.MustParsePrefix("2001::/32")
+)
+
+// ParseIp parses an IPv4 or IPv6 address and returns its canonical form, without a zone and with IPv4-mapped IPv6
+// addresses unmapped, and the address to look up, which is the IPv4 address embedded into 6to4, Teredo and NAT64
+// addresses or the canonical one for the rest.
+func ParseIp(s string) (canonical, lookup netip.Addr, err error) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(s))
+	if err != nil {
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("%w: %s", server.ErrWrongIpAddress, s)
+	}
+
+	canonical = addr.WithZone("").Unmap()
+
+	return canonical, embeddedIpv4(canonical), nil
+}
+
+func embeddedIpv4(addr netip.Addr) netip.Addr {
+	b := addr.As16()
+
+	switch {
+	case prefix6to4.Contains(addr):
+		// 2002:AABB:CCDD::/48 carries the IPv4 address right after the prefix
+		return netip.AddrFrom4([4]byte(b[2:6]))
+	case prefixTeredo.Contains(addr):
+		// the last 32 bits of a Teredo address are the obfuscated (inverted) public IPv4 address of the client
+		return netip.AddrFrom4([4]byte{^b[12], ^b[13], ^b[14], ^b[15]})
+	case ipv6Nat64.Contains(addr):
+		// the well-known NAT64 prefix 64:ff9b::/96 carries the IPv4 address in the last 32 bits
+		return netip.AddrFrom4([4]byte(b[12:16]))
+	}
+
+	return addr
+}
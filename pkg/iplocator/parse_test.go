@@ -0,0 +1,171 @@
+package iplocator
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/streamdp/ip-info/server"
+)
+
+func TestParseIp(t *testing.T) {
+	tests := []struct {
+		name          string
+		s             string
+		wantCanonical string
+		wantLookup    string
+		wantErr       error
+	}{
+		{
+			name:          "ipv4",
+			s:             "8.8.8.8",
+			wantCanonical: "8.8.8.8",
+			wantLookup:    "8.8.8.8",
+		},
+		{
+			name:          "ipv4 with spaces",
+			s:             " 8.8.8.8 ",
+			wantCanonical: "8.8.8.8",
+			wantLookup:    "8.8.8.8",
+		},
+		{
+			name:          "ipv6",
+			s:             "2001:4860:4860:0:0:0:0:8888",
+			wantCanonical: "2001:4860:4860::8888",
+			wantLookup:    "2001:4860:4860::8888",
+		},
+		{
+			name:          "ipv6 upper case",
+			s:             "2A03:2880:F12F:83:FACE:B00C::25DE",
+			wantCanonical: "2a03:2880:f12f:83:face:b00c:0:25de",
+			wantLookup:    "2a03:2880:f12f:83:face:b00c:0:25de",
+		},
+		{
+			name:          "ipv4-mapped ipv6",
+			s:             "::ffff:8.8.8.8",
+			wantCanonical: "8.8.8.8",
+			wantLookup:    "8.8.8.8",
+		},
+		{
+			name:          "ipv4-mapped ipv6 in hex",
+			s:             "::ffff:808:808",
+			wantCanonical: "8.8.8.8",
+			wantLookup:    "8.8.8.8",
+		},
+		{
+			name:          "ipv6 with zone",
+			s:             "fe80::1%eth0",
+			wantCanonical: "fe80::1",
+			wantLookup:    "fe80::1",
+		},
+		{
+			name:          "6to4",
+			s:             "2002:521c:192b::1",
+			wantCanonical: "2002:521c:192b::1",
+			wantLookup:    "82.28.25.43",
+		},
+		{
+			name:          "teredo",
+			s:             "2001:0000:4136:e378:8000:63bf:3fff:fdd2",
+			wantCanonical: "2001:0:4136:e378:8000:63bf:3fff:fdd2",
+			wantLookup:    "192.0.2.45",
+		},
+		{
+			name:          "nat64",
+			s:             "64:ff9b::808:808",
+			wantCanonical: "64:ff9b::808:808",
+			wantLookup:    "8.8.8.8",
+		},
+		{
+			name:    "ipv4 with zone",
+			s:       "8.8.8.8%eth0",
+			wantErr: server.ErrWrongIpAddress,
+		},
+		{
+			name:    "ipv4 out of range",
+			s:       "256.28.25.43",
+			wantErr: server.ErrWrongIpAddress,
+		},
+		{
+			name:    "ipv4 with leading zeros",
+			s:       "08.8.8.8",
+			wantErr: server.ErrWrongIpAddress,
+		},
+		{
+			name:    "empty string",
+			s:       "",
+			wantErr: server.ErrWrongIpAddress,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			canonical, lookup, err := ParseIp(tt.s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseIp() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if canonical.String() != tt.wantCanonical {
+				t.Errorf("ParseIp() canonical = %v, want %v", canonical, tt.wantCanonical)
+			}
+			if lookup.String() != tt.wantLookup {
+				t.Errorf("ParseIp() lookup = %v, want %v", lookup, tt.wantLookup)
+			}
+		})
+	}
+}
+
+func FuzzParseIp(f *testing.F) {
+	for _, s := range []string{
+		"8.8.8.8", "::ffff:8.8.8.8", "fe80::1%eth0", "2002:521c:192b::1", "2001:0:4136:e378:8000:63bf:3fff:fdd2",
+		"64:ff9b::808:808", "::", "256.1.1.1", "1.2.3.4%", "[::1]", "",
+	} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		canonical, lookup, err := ParseIp(s)
+		if err != nil {
+			if !errors.Is(err, server.ErrWrongIpAddress) {
+				t.Fatalf("ParseIp(%q) unexpected error %v", s, err)
+			}
+
+			return
+		}
+
+		if canonical.Zone() != "" || canonical.Is4In6() {
+			t.Fatalf("ParseIp(%q) canonical %v is not canonical", s, canonical)
+		}
+		if lookup != canonical && !lookup.Is4() {
+			t.Fatalf("ParseIp(%q) lookup %v is neither canonical %v nor ipv4", s, lookup, canonical)
+		}
+
+		// the canonical form must be stable
+		again, againLookup, err := ParseIp(canonical.String())
+		if err != nil || again != canonical || againLookup != lookup {
+			t.Fatalf("ParseIp(%q) = %v, %v, %v, want %v, %v", canonical, again, againLookup, err, canonical, lookup)
+		}
+	})
+}
+
+func Test_embeddedIpv4(t *testing.T) {
+	tests := []struct {
+		name string
+		addr string
+		want string
+	}{
+		{name: "regular ipv6", addr: "2001:db8::1", want: "2001:db8::1"},
+		{name: "6to4 private", addr: "2002:c0a8:101::", want: "192.168.1.1"},
+		{name: "teredo", addr: "2001::ffff:ffff", want: "0.0.0.0"},
+		{name: "nat64 private", addr: "64:ff9b::c0a8:101", want: "192.168.1.1"},
+		{name: "nat64 local-use", addr: "64:ff9b:1::c0a8:101", want: "64:ff9b:1::c0a8:101"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := embeddedIpv4(netip.MustParseAddr(tt.addr)); got.String() != tt.want {
+				t.Errorf("embeddedIpv4() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package mmdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"slices"
+)
+
+// Data types of the data section, the ones above 7 are extended and take a second byte.
+const (
+	typeString = 2
+	typeDouble = 3
+	typeUint16 = 5
+	typeUint32 = 6
+	typeMap    = 7
+	typeUint64 = 9
+	typeArray  = 11
+	typeBool   = 14
+)
+
+// maxSize is the size the control bytes can hold.
+const maxSize = 65821 + 1<<24 - 1
+
+var (
+	errUnsupportedType = errors.New("unsupported data type")
+	errTooLarge        = errors.New("value is too large")
+)
+
+// Map is a map of the data section, its keys are written sorted so that equal maps are encoded the same way.
+type Map = map[string]any
+
+// appendValue encodes v to the data section format: string, float64, uint16, uint32, uint64, bool, Map, []any and
+// []string values are supported.
+func appendValue(b []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case string:
+		b, err := appendControl(b, typeString, len(v))
+		if err != nil {
+			return nil, err
+		}
+
+		return append(b, v...), nil
+	case float64:
+		b, _ = appendControl(b, typeDouble, 8)
+
+		return binary.BigEndian.AppendUint64(b, math.Float64bits(v)), nil
+	case uint16:
+		return appendUint(b, typeUint16, uint64(v)), nil
+	case uint32:
+		return appendUint(b, typeUint32, uint64(v)), nil
+	case uint64:
+		return appendUint(b, typeUint64, v), nil
+	case bool:
+		size := 0
+		if v {
+			size = 1
+		}
+
+		return appendControl(b, typeBool, size)
+	case Map:
+		return appendMap(b, v)
+	case []any:
+		return appendArray(b, v)
+	case []string:
+		values := make([]any, len(v))
+		for i, s := range v {
+			values[i] = s
+		}
+
+		return appendArray(b, values)
+	default:
+		return nil, fmt.Errorf("%w: %T", errUnsupportedType, v)
+	}
+}
+
+func appendMap(b []byte, m Map) ([]byte, error) {
+	b, err := appendControl(b, typeMap, len(m))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		if b, err = appendValue(b, k); err != nil {
+			return nil, err
+		}
+		if b, err = appendValue(b, m[k]); err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+	}
+
+	return b, nil
+}
+
+func appendArray(b []byte, values []any) ([]byte, error) {
+	b, err := appendControl(b, typeArray, len(values))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range values {
+		if b, err = appendValue(b, v); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// appendUint writes the unsigned integers with the fewest big-endian bytes, zero takes none.
+func appendUint(b []byte, typ int, v uint64) []byte {
+	size := (64 - bits.LeadingZeros64(v) + 7) / 8
+	b, _ = appendControl(b, typ, size)
+	for i := size - 1; i >= 0; i-- {
+		b = append(b, byte(v>>(8*i)))
+	}
+
+	return b
+}
+
+// appendControl writes the control byte of the type and the size, followed by the extended type and the bytes of the
+// sizes from 29 on.
+func appendControl(b []byte, typ, size int) ([]byte, error) {
+	if size > maxSize {
+		return nil, errTooLarge
+	}
+
+	ctrl := byte(typ << 5)
+	if typ > typeMap {
+		ctrl = 0
+	}
+
+	var sizeBytes []byte
+	switch {
+	case size < 29:
+		ctrl |= byte(size)
+	case size < 285:
+		ctrl |= 29
+		sizeBytes = []byte{byte(size - 29)}
+	case size < 65821:
+		ctrl |= 30
+		sizeBytes = binary.BigEndian.AppendUint16(nil, uint16(size-285))
+	default:
+		ctrl |= 31
+		s := size - 65821
+		sizeBytes = []byte{byte(s >> 16), byte(s >> 8), byte(s)}
+	}
+
+	b = append(b, ctrl)
+	if typ > typeMap {
+		b = append(b, byte(typ-typeMap))
+	}
+
+	return append(b, sizeBytes...), nil
+}
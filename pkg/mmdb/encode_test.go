@@ -0,0 +1,98 @@
+package mmdb
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_appendValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       any
+		want    []byte
+		wantErr error
+	}{
+		{
+			name: "empty string",
+			v:    "",
+			want: []byte{0x40},
+		},
+		{
+			name: "string",
+			v:    "abc",
+			want: []byte{0x43, 'a', 'b', 'c'},
+		},
+		{
+			name: "string of 29 bytes",
+			v:    strings.Repeat("a", 29),
+			want: append([]byte{0x5d, 0x00}, strings.Repeat("a", 29)...),
+		},
+		{
+			name: "string of 300 bytes",
+			v:    strings.Repeat("a", 300),
+			want: append([]byte{0x5e, 0x00, 0x0f}, strings.Repeat("a", 300)...),
+		},
+		{
+			name: "double",
+			v:    37.4223,
+			want: []byte{0x68, 0x40, 0x42, 0xb6, 0x0d, 0xed, 0x28, 0x8c, 0xe7},
+		},
+		{
+			name: "zero uint16",
+			v:    uint16(0),
+			want: []byte{0xa0},
+		},
+		{
+			name: "uint16",
+			v:    uint16(300),
+			want: []byte{0xa2, 0x01, 0x2c},
+		},
+		{
+			name: "uint32",
+			v:    uint32(15169),
+			want: []byte{0xc2, 0x3b, 0x41},
+		},
+		{
+			name: "uint64",
+			v:    uint64(1790910252),
+			want: []byte{0x04, 0x02, 0x6a, 0xbf, 0x1f, 0x2c},
+		},
+		{
+			name: "true",
+			v:    true,
+			want: []byte{0x01, 0x07},
+		},
+		{
+			name: "map with sorted keys",
+			v:    Map{"iso_code": "US", "geoname_id": uint32(1)},
+			want: []byte{
+				0xe2,
+				0x4a, 'g', 'e', 'o', 'n', 'a', 'm', 'e', '_', 'i', 'd', 0xc1, 0x01,
+				0x48, 'i', 's', 'o', '_', 'c', 'o', 'd', 'e', 0x42, 'U', 'S',
+			},
+		},
+		{
+			name: "array of strings",
+			v:    []string{"en"},
+			want: []byte{0x01, 0x04, 0x42, 'e', 'n'},
+		},
+		{
+			name:    "unsupported type",
+			v:       Map{"asn": 15169},
+			wantErr: errUnsupportedType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := appendValue(nil, tt.v)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("appendValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("appendValue() = % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
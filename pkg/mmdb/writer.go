@@ -0,0 +1,408 @@
+// Package mmdb writes databases in the MaxMind DB format, the one read by the GeoIP2 libraries and the geoip2 modules
+// of the proxies, see https://maxmind.github.io/MaxMind-DB/.
+package mmdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"net/netip"
+	"slices"
+	"time"
+)
+
+const (
+	// dataSectionSeparator is the number of the zero bytes between the search tree and the data section
+	dataSectionSeparator = 16
+	// ipv4Depth is the depth of the IPv4 subtree, IPv4 addresses are stored as ::a.b.c.d
+	ipv4Depth = 96
+)
+
+var metadataStartMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// aliases point the IPv4-mapped and 6to4 networks to the IPv4 subtree, like the MaxMind databases do.
+var aliases = []netip.Prefix{
+	netip.MustParsePrefix("::ffff:0:0/96"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+var (
+	ErrWrongRange = errors.New("range should start before its end and both should be of the same family")
+	ErrTooLarge   = errors.New("database is too large for the record size")
+)
+
+// record is a child of a node while the tree is built: empty, the index of a node or dataFlag with the index of a
+// value. The root is never a child, so the zero index is free for the empty records.
+type record uint32
+
+const (
+	emptyRecord record = 0
+	dataFlag    record = 1 << 31
+)
+
+func (r record) isNode() bool {
+	return r != emptyRecord && r&dataFlag == 0
+}
+
+func (r record) isData() bool {
+	return r&dataFlag != 0
+}
+
+type Metadata struct {
+	// DatabaseType names the structure of the values, e.g. GeoIP2-City.
+	DatabaseType string
+	// Description is the English description of the database.
+	Description string
+	// Languages are the ones the values have names in.
+	Languages []string
+	BuildTime time.Time
+}
+
+// Writer builds an IPv6 database with the IPv4 networks in ::/96. Values of type T are stored once however many
+// networks they are inserted for, encode turns them into the data section values, see appendValue.
+type Writer[T comparable] struct {
+	meta   Metadata
+	encode func(v T) any
+
+	nodes  [][2]record
+	values []T
+	index  map[T]record
+
+	aliased bool
+}
+
+func New[T comparable](meta Metadata, encode func(v T) any) *Writer[T] {
+	return &Writer[T]{
+		meta:   meta,
+		encode: encode,
+		nodes:  make([][2]record, 1),
+		index:  map[T]record{},
+	}
+}
+
+// InsertRange sets the value of the addresses from start to end, the range is stored as the networks covering it.
+func (w *Writer[T]) InsertRange(start, end netip.Addr, v T) error {
+	start, end = start.Unmap(), end.Unmap()
+	if !start.IsValid() || !end.IsValid() || start.Is4() != end.Is4() || end.Less(start) {
+		return fmt.Errorf("%w: %s-%s", ErrWrongRange, start, end)
+	}
+
+	// the networks of the range are aligned at their first address, the biggest that fits is taken each time, and
+	// the whole address space is split in halves since the root has no record of its own
+	maxSize := 127
+	if start.Is4() {
+		maxSize = 128 - ipv4Depth
+	}
+	data := w.intern(v)
+	for s, e := toUint128(start), toUint128(end); ; {
+		size := min(s.trailingZeros(), maxSize)
+		for size > 0 && e.less(s.add(mask(size))) {
+			size--
+		}
+
+		w.insert(s, 128-size, func(record) record { return data })
+
+		last := s.add(mask(size))
+		if last == e {
+			return nil
+		}
+		s = last.add(uint128{lo: 1})
+	}
+}
+
+// Update replaces the values of the addresses in the network with the ones returned by fn, the addresses without a
+// value are left as they are.
+func (w *Writer[T]) Update(network netip.Prefix, fn func(v T) T) {
+	addr, depth := network.Masked().Addr(), network.Bits()
+	switch {
+	case addr.Is4():
+		depth += ipv4Depth
+	case addr.Is4In6() && depth >= ipv4Depth:
+		// the IPv4-mapped networks are aliases of the IPv4 ones
+		addr = addr.Unmap()
+	}
+
+	update := func(r record) record { return w.update(r, fn) }
+	if depth == 0 {
+		w.nodes[0] = [2]record{update(w.nodes[0][0]), update(w.nodes[0][1])}
+
+		return
+	}
+	w.insert(toUint128(addr), depth, update)
+}
+
+func (w *Writer[T]) update(r record, fn func(v T) T) record {
+	switch {
+	case r == emptyRecord:
+		return r
+	case r.isData():
+		return w.intern(fn(w.values[r&^dataFlag]))
+	default:
+		n := int(r)
+		w.nodes[n][0] = w.update(w.nodes[n][0], fn)
+		w.nodes[n][1] = w.update(w.nodes[n][1], fn)
+
+		return r
+	}
+}
+
+// insert walks the first depth bits of addr, splitting the networks met on the way, and sets the record of the
+// network to the one returned by set.
+func (w *Writer[T]) insert(addr uint128, depth int, set func(r record) record) {
+	node := 0
+	for i := range depth - 1 {
+		b := addr.bit(i)
+		r := w.nodes[node][b]
+		if !r.isNode() {
+			// the value of a bigger network is kept by both halves
+			w.nodes = append(w.nodes, [2]record{r, r})
+			r = record(len(w.nodes) - 1)
+			w.nodes[node][b] = r
+		}
+		node = int(r)
+	}
+
+	b := addr.bit(depth - 1)
+	w.nodes[node][b] = set(w.nodes[node][b])
+}
+
+func (w *Writer[T]) intern(v T) record {
+	if r, ok := w.index[v]; ok {
+		return r
+	}
+
+	r := dataFlag | record(len(w.values))
+	w.values = append(w.values, v)
+	w.index[v] = r
+
+	return r
+}
+
+// alias points the aliases to the IPv4 subtree, unless they already have their own networks.
+func (w *Writer[T]) alias() {
+	if w.aliased {
+		return
+	}
+	w.aliased = true
+
+	ipv4 := record(0)
+	for range ipv4Depth {
+		if ipv4 = w.nodes[ipv4][0]; !ipv4.isNode() {
+			return
+		}
+	}
+
+next:
+	for _, alias := range aliases {
+		addr, node := toUint128(alias.Addr()), 0
+		for i := range alias.Bits() - 1 {
+			b := addr.bit(i)
+			r := w.nodes[node][b]
+			if r.isData() {
+				continue next
+			}
+			if r == emptyRecord {
+				w.nodes = append(w.nodes, [2]record{})
+				r = record(len(w.nodes) - 1)
+				w.nodes[node][b] = r
+			}
+			node = int(r)
+		}
+
+		if b := addr.bit(alias.Bits() - 1); w.nodes[node][b] == emptyRecord {
+			w.nodes[node][b] = ipv4
+		}
+	}
+}
+
+// WriteTo writes the database: the search tree, the data section and the metadata.
+func (w *Writer[T]) WriteTo(out io.Writer) (int64, error) {
+	w.alias()
+
+	// the values replaced by Update are left out, they aren't referenced by the tree anymore
+	used := make([]bool, len(w.values))
+	for _, n := range w.nodes {
+		for _, r := range n {
+			if r.isData() {
+				used[r&^dataFlag] = true
+			}
+		}
+	}
+
+	// equal values may come from different ones of T, e.g. when T has fields that aren't encoded
+	var (
+		data    []byte
+		offsets = make([]uint64, len(w.values))
+		encoded = map[string]uint64{}
+	)
+	for i, v := range w.values {
+		if !used[i] {
+			continue
+		}
+		b, err := appendValue(nil, w.encode(v))
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode value: %w", err)
+		}
+		if offset, ok := encoded[string(b)]; ok {
+			offsets[i] = offset
+
+			continue
+		}
+		offsets[i] = uint64(len(data))
+		encoded[string(b)] = offsets[i]
+		data = append(data, b...)
+	}
+
+	nodeCount := uint64(len(w.nodes))
+	recordSize, err := recordSize(nodeCount + dataSectionSeparator + uint64(len(data)))
+	if err != nil {
+		return 0, err
+	}
+	value := func(r record) uint64 {
+		switch {
+		case r == emptyRecord:
+			return nodeCount
+		case r.isData():
+			return nodeCount + dataSectionSeparator + offsets[r&^dataFlag]
+		default:
+			return uint64(r)
+		}
+	}
+
+	cw := &countingWriter{w: out}
+	bw := bufio.NewWriter(cw)
+	node := make([]byte, recordSize/4)
+	for _, n := range w.nodes {
+		putNode(node, value(n[0]), value(n[1]), recordSize)
+		_, _ = bw.Write(node)
+	}
+	_, _ = bw.Write(make([]byte, dataSectionSeparator))
+	_, _ = bw.Write(data)
+
+	metadata, err := appendValue(slices.Clone(metadataStartMarker), w.metadata(nodeCount, recordSize))
+	if err != nil {
+		return cw.n, fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	_, _ = bw.Write(metadata)
+
+	if err = bw.Flush(); err != nil {
+		return cw.n, fmt.Errorf("failed to write database: %w", err)
+	}
+
+	return cw.n, nil
+}
+
+func (w *Writer[T]) metadata(nodeCount uint64, recordSize int) Map {
+	languages := w.meta.Languages
+	if languages == nil {
+		languages = []string{}
+	}
+
+	return Map{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(w.meta.BuildTime.Unix()),
+		"database_type":               w.meta.DatabaseType,
+		"description":                 Map{"en": w.meta.Description},
+		"ip_version":                  uint16(6),
+		"languages":                   languages,
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(recordSize),
+	}
+}
+
+// recordSize returns the smallest record size, in bits, that holds the biggest record value.
+func recordSize(maxValue uint64) (int, error) {
+	for _, size := range []int{24, 28, 32} {
+		if maxValue < 1<<size {
+			return size, nil
+		}
+	}
+
+	return 0, ErrTooLarge
+}
+
+// putNode packs the records of a node, the middle byte of the 28-bit nodes holds the top bits of both records.
+func putNode(b []byte, left, right uint64, recordSize int) {
+	switch recordSize {
+	case 24:
+		b[0], b[1], b[2] = byte(left>>16), byte(left>>8), byte(left)
+		b[3], b[4], b[5] = byte(right>>16), byte(right>>8), byte(right)
+	case 28:
+		b[0], b[1], b[2] = byte(left>>16), byte(left>>8), byte(left)
+		b[3] = byte(left>>24&0x0f)<<4 | byte(right>>24&0x0f)
+		b[4], b[5], b[6] = byte(right>>16), byte(right>>8), byte(right)
+	default:
+		binary.BigEndian.PutUint32(b[0:4], uint32(left))
+		binary.BigEndian.PutUint32(b[4:8], uint32(right))
+	}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+
+	return n, err
+}
+
+// uint128 is an IPv6 address, or an IPv4 one in ::/96, as a number.
+type uint128 struct {
+	hi, lo uint64
+}
+
+func toUint128(addr netip.Addr) uint128 {
+	b := addr.As16()
+	if addr.Is4() {
+		b = [16]byte{12: b[12], 13: b[13], 14: b[14], 15: b[15]}
+	}
+
+	return uint128{hi: binary.BigEndian.Uint64(b[:8]), lo: binary.BigEndian.Uint64(b[8:])}
+}
+
+// mask returns the number with the size lowest bits set.
+func mask(size int) uint128 {
+	switch {
+	case size == 0:
+		return uint128{}
+	case size <= 64:
+		return uint128{lo: 1<<size - 1}
+	default:
+		return uint128{hi: 1<<(size-64) - 1, lo: ^uint64(0)}
+	}
+}
+
+func (u uint128) add(v uint128) uint128 {
+	lo, carry := bits.Add64(u.lo, v.lo, 0)
+	hi, _ := bits.Add64(u.hi, v.hi, carry)
+
+	return uint128{hi: hi, lo: lo}
+}
+
+func (u uint128) less(v uint128) bool {
+	return u.hi < v.hi || u.hi == v.hi && u.lo < v.lo
+}
+
+func (u uint128) trailingZeros() int {
+	if u.lo != 0 {
+		return bits.TrailingZeros64(u.lo)
+	}
+
+	return 64 + bits.TrailingZeros64(u.hi)
+}
+
+// bit returns the bit at the position counted from the most significant one.
+func (u uint128) bit(i int) int {
+	if i < 64 {
+		return int(u.hi >> (63 - i) & 1)
+	}
+
+	return int(u.lo >> (127 - i) & 1)
+}
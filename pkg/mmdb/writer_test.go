@@ -0,0 +1,394 @@
+package mmdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// reader looks up the databases written by the tests, it follows the spec on its own to check the writer against it.
+type reader struct {
+	b          []byte
+	meta       Map
+	nodeCount  uint64
+	recordSize int
+	data       []byte
+}
+
+func newReader(t *testing.T, b []byte) *reader {
+	t.Helper()
+
+	i := bytes.LastIndex(b, metadataStartMarker)
+	if i < 0 {
+		t.Fatal("no metadata")
+	}
+	meta, _, err := decode(b[i+len(metadataStartMarker):], 0)
+	if err != nil {
+		t.Fatalf("failed to decode metadata: %v", err)
+	}
+
+	r := &reader{b: b, meta: meta.(Map)}
+	r.nodeCount = uint64(r.meta["node_count"].(uint32))
+	r.recordSize = int(r.meta["record_size"].(uint16))
+	treeSize := int(r.nodeCount) * r.recordSize / 4
+	if !bytes.Equal(b[treeSize:treeSize+dataSectionSeparator], make([]byte, dataSectionSeparator)) {
+		t.Fatal("no data section separator")
+	}
+	r.data = b[treeSize+dataSectionSeparator : i]
+
+	return r
+}
+
+func (r *reader) lookup(t *testing.T, ip string) any {
+	t.Helper()
+
+	addr := netip.MustParseAddr(ip)
+	b := addr.As16()
+	if addr.Is4() {
+		b = [16]byte{12: b[12], 13: b[13], 14: b[14], 15: b[15]}
+	}
+
+	node := uint64(0)
+	for i := 0; i < 128 && node < r.nodeCount; i++ {
+		node = r.record(node, int(b[i/8]>>(7-i%8)&1))
+	}
+	switch {
+	case node == r.nodeCount:
+		return nil
+	case node < r.nodeCount:
+		t.Fatalf("lookup(%s) ended on node %d", ip, node)
+	}
+
+	v, _, err := decode(r.data, int(node-r.nodeCount-dataSectionSeparator))
+	if err != nil {
+		t.Fatalf("lookup(%s) failed to decode: %v", ip, err)
+	}
+
+	return v
+}
+
+func (r *reader) record(node uint64, bit int) uint64 {
+	n := r.b[node*uint64(r.recordSize)/4:]
+	switch r.recordSize {
+	case 24:
+		n = n[bit*3:]
+
+		return uint64(n[0])<<16 | uint64(n[1])<<8 | uint64(n[2])
+	case 28:
+		if bit == 0 {
+			return uint64(n[3]>>4)<<24 | uint64(n[0])<<16 | uint64(n[1])<<8 | uint64(n[2])
+		}
+
+		return uint64(n[3]&0x0f)<<24 | uint64(n[4])<<16 | uint64(n[5])<<8 | uint64(n[6])
+	default:
+		return uint64(binary.BigEndian.Uint32(n[bit*4:]))
+	}
+}
+
+func decode(b []byte, offset int) (any, int, error) {
+	ctrl := b[offset]
+	offset++
+	typ := int(ctrl >> 5)
+	if typ == 0 {
+		typ = 7 + int(b[offset])
+		offset++
+	}
+
+	size := int(ctrl & 0x1f)
+	switch size {
+	case 29:
+		size = 29 + int(b[offset])
+		offset++
+	case 30:
+		size = 285 + int(binary.BigEndian.Uint16(b[offset:]))
+		offset += 2
+	case 31:
+		size = 65821 + int(b[offset])<<16 | int(b[offset+1])<<8 | int(b[offset+2])
+		offset += 3
+	}
+
+	readUint := func() uint64 {
+		var v uint64
+		for _, c := range b[offset : offset+size] {
+			v = v<<8 | uint64(c)
+		}
+
+		return v
+	}
+
+	switch typ {
+	case typeString:
+		return string(b[offset : offset+size]), offset + size, nil
+	case typeDouble:
+		return math.Float64frombits(binary.BigEndian.Uint64(b[offset:])), offset + 8, nil
+	case typeUint16:
+		return uint16(readUint()), offset + size, nil
+	case typeUint32:
+		return uint32(readUint()), offset + size, nil
+	case typeUint64:
+		return readUint(), offset + size, nil
+	case typeBool:
+		return size == 1, offset, nil
+	case typeMap:
+		m := Map{}
+		for range size {
+			k, next, err := decode(b, offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			v, next, err := decode(b, next)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[k.(string)], offset = v, next
+		}
+
+		return m, offset, nil
+	case typeArray:
+		a := make([]any, 0, size)
+		for range size {
+			v, next, err := decode(b, offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			a, offset = append(a, v), next
+		}
+
+		return a, offset, nil
+	default:
+		return nil, 0, fmt.Errorf("unexpected type %d", typ)
+	}
+}
+
+type testLocation struct {
+	country, city string
+	// hidden isn't written, so the locations that only differ by it share the data
+	hidden bool
+}
+
+func encodeTestLocation(l testLocation) any {
+	m := Map{"country": Map{"iso_code": l.country}}
+	if l.city != "" {
+		m["city"] = Map{"names": Map{"en": l.city}}
+	}
+
+	return m
+}
+
+func location(country, city string) any {
+	return encodeTestLocation(testLocation{country: country, city: city})
+}
+
+func TestWriter(t *testing.T) {
+	w := New(Metadata{
+		DatabaseType: "ip-info-City",
+		Description:  "test database",
+		Languages:    []string{"en"},
+		BuildTime:    time.Unix(1790910252, 0),
+	}, encodeTestLocation)
+
+	ranges := []struct {
+		start, end string
+		location   testLocation
+	}{
+		{"1.0.0.0", "1.0.0.255", testLocation{country: "AU"}},
+		{"8.8.4.1", "8.8.4.6", testLocation{country: "US", city: "Ashburn"}},
+		{"8.8.8.0", "8.8.8.255", testLocation{country: "US", city: "Mountain View"}},
+		{"2001:4860::", "2001:4860:ffff:ffff:ffff:ffff:ffff:ffff", testLocation{country: "US", hidden: true}},
+	}
+	for _, r := range ranges {
+		if err := w.InsertRange(netip.MustParseAddr(r.start), netip.MustParseAddr(r.end), r.location); err != nil {
+			t.Fatalf("InsertRange() error = %v", err)
+		}
+	}
+	w.Update(netip.MustParsePrefix("8.8.8.128/25"), func(l testLocation) testLocation {
+		l.city = "Override"
+
+		return l
+	})
+	w.Update(netip.MustParsePrefix("9.0.0.0/8"), func(l testLocation) testLocation {
+		t.Error("Update() called fn for the addresses without a value")
+
+		return l
+	})
+
+	var buf bytes.Buffer
+	n, err := w.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo() = %d, want %d written bytes", n, buf.Len())
+	}
+
+	r := newReader(t, buf.Bytes())
+	lookups := []struct {
+		ip   string
+		want any
+	}{
+		{"1.0.0.7", location("AU", "")},
+		{"1.0.1.0", nil},
+		{"8.8.4.0", nil},
+		{"8.8.4.1", location("US", "Ashburn")},
+		{"8.8.4.6", location("US", "Ashburn")},
+		{"8.8.4.7", nil},
+		{"8.8.8.8", location("US", "Mountain View")},
+		{"8.8.8.200", location("US", "Override")},
+		{"::ffff:8.8.8.8", location("US", "Mountain View")},
+		{"2002:808:808::", location("US", "Mountain View")},
+		{"2001:4860:4860::8888", location("US", "")},
+		{"2001:4861::", nil},
+		{"9.9.9.9", nil},
+	}
+	for _, l := range lookups {
+		if got := r.lookup(t, l.ip); !reflect.DeepEqual(got, l.want) {
+			t.Errorf("lookup(%s) = %v, want %v", l.ip, got, l.want)
+		}
+	}
+
+	wantMeta := Map{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(1790910252),
+		"database_type":               "ip-info-City",
+		"description":                 Map{"en": "test database"},
+		"ip_version":                  uint16(6),
+		"languages":                   []any{"en"},
+		"node_count":                  uint32(len(w.nodes)),
+		"record_size":                 uint16(24),
+	}
+	if !reflect.DeepEqual(r.meta, wantMeta) {
+		t.Errorf("WriteTo() metadata = %v, want %v", r.meta, wantMeta)
+	}
+}
+
+func TestWriter_sharedValues(t *testing.T) {
+	w := New(Metadata{DatabaseType: "ip-info-City"}, encodeTestLocation)
+	for i := range 1000 {
+		addr := netip.AddrFrom4([4]byte{10, byte(i >> 8), byte(i), 0})
+		l := testLocation{country: "US", hidden: i%2 == 0}
+		if err := w.InsertRange(addr, netip.AddrFrom4([4]byte{10, byte(i >> 8), byte(i), 255}), l); err != nil {
+			t.Fatalf("InsertRange() error = %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+
+	want, _ := appendValue(nil, location("US", ""))
+	if r := newReader(t, buf.Bytes()); !bytes.Equal(r.data, want) {
+		t.Errorf("WriteTo() data section = % x, want a single value % x", r.data, want)
+	}
+}
+
+func TestWriter_updatedValues(t *testing.T) {
+	w := New(Metadata{DatabaseType: "ip-info-City"}, encodeTestLocation)
+	if err := w.InsertRange(netip.MustParseAddr("8.8.8.0"), netip.MustParseAddr("8.8.8.255"),
+		testLocation{country: "US", city: "Mountain View"}); err != nil {
+		t.Fatalf("InsertRange() error = %v", err)
+	}
+	w.Update(netip.MustParsePrefix("8.8.8.0/24"), func(l testLocation) testLocation {
+		l.city = "Override"
+
+		return l
+	})
+
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+
+	want, _ := appendValue(nil, location("US", "Override"))
+	if r := newReader(t, buf.Bytes()); !bytes.Equal(r.data, want) {
+		t.Errorf("WriteTo() data section = % x, want only the updated value % x", r.data, want)
+	}
+}
+
+func TestWriter_InsertRange(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end string
+		wantErr    error
+	}{
+		{
+			name:  "whole IPv4 space",
+			start: "0.0.0.0",
+			end:   "255.255.255.255",
+		},
+		{
+			name:  "whole IPv6 space",
+			start: "::",
+			end:   "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff",
+		},
+		{
+			name:  "IPv4-mapped addresses",
+			start: "::ffff:1.0.0.0",
+			end:   "1.0.0.255",
+		},
+		{
+			name:    "end before start",
+			start:   "1.0.0.255",
+			end:     "1.0.0.0",
+			wantErr: ErrWrongRange,
+		},
+		{
+			name:    "mixed families",
+			start:   "1.0.0.0",
+			end:     "2001:db8::",
+			wantErr: ErrWrongRange,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := New(Metadata{}, encodeTestLocation)
+			err := w.InsertRange(netip.MustParseAddr(tt.start), netip.MustParseAddr(tt.end), testLocation{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("InsertRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_putNode(t *testing.T) {
+	for _, size := range []int{24, 28, 32} {
+		t.Run(fmt.Sprintf("%d bits", size), func(t *testing.T) {
+			left, right := uint64(1)<<(size-1)|0x0a0b0c, uint64(1)<<(size-2)|0x0d0e0f
+			r := &reader{b: make([]byte, size/4), recordSize: size}
+			putNode(r.b, left, right, size)
+
+			if got := r.record(0, 0); got != left {
+				t.Errorf("putNode() left = %x, want %x", got, left)
+			}
+			if got := r.record(0, 1); got != right {
+				t.Errorf("putNode() right = %x, want %x", got, right)
+			}
+		})
+	}
+}
+
+func Test_recordSize(t *testing.T) {
+	tests := []struct {
+		maxValue uint64
+		want     int
+		wantErr  error
+	}{
+		{maxValue: 1<<24 - 1, want: 24},
+		{maxValue: 1 << 24, want: 28},
+		{maxValue: 1 << 28, want: 32},
+		{maxValue: 1 << 32, wantErr: ErrTooLarge},
+	}
+	for _, tt := range tests {
+		got, err := recordSize(tt.maxValue)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("recordSize(%d) = %d, %v, want %d, %v", tt.maxValue, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package timezones
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata"
+)
+
+type zone struct {
+	name      string
+	country   string
+	latitude  float64
+	longitude float64
+}
+
+// zone.tab from the IANA time zone database lists the principal city of every zone, it is used as a nearest-city table.
+//
+//go:embed zone.tab
+var zoneTab []byte
+
+var zones, zonesByCountry = mustLoad(zoneTab)
+
+// locations caches the loaded zones by name, time.LoadLocation parses the embedded tzdata on every call.
+var locations sync.Map
+
+// Lookup returns the IANA name of the time zone whose principal city is the nearest to the coordinates among the
+// zones of the country. Coordinates are ignored for countries with a single zone, unknown countries are looked up
+// among all zones. It returns an empty string when the zone can't be resolved.
+func Lookup(country string, latitude, longitude float64) string {
+	candidates := zonesByCountry[strings.ToUpper(country)]
+	switch {
+	case len(candidates) == 1:
+		return candidates[0].name
+	case len(candidates) == 0 && latitude == 0 && longitude == 0:
+		return ""
+	case len(candidates) == 0:
+		candidates = zones
+	}
+
+	nearest, minDistance := "", math.MaxFloat64
+	for _, z := range candidates {
+		if d := distance(latitude, longitude, z.latitude, z.longitude); d < minDistance {
+			nearest, minDistance = z.name, d
+		}
+	}
+
+	return nearest
+}
+
+// Offset returns the UTC offset formatted as ±hh:mm and whether daylight saving time is in effect in the zone at t.
+func Offset(name string, t time.Time) (string, bool, error) {
+	loc, err := location(name)
+	if err != nil {
+		return "", false, err
+	}
+
+	t = t.In(loc)
+
+	return t.Format("-07:00"), t.IsDST(), nil
+}
+
+// location returns the zone loaded once, the unknown names aren't cached.
+func location(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load time zone: %w", err)
+	}
+	locations.Store(name, loc)
+
+	return loc, nil
+}
+
+// distance returns the central angle between two points, which is enough to compare distances.
+func distance(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1, lon1, lat2, lon2 = radians(lat1), radians(lon1), radians(lat2), radians(lon2)
+
+	a := math.Pow(math.Sin((lat2-lat1)/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin((lon2-lon1)/2), 2)
+
+	return 2 * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func mustLoad(b []byte) ([]*zone, map[string][]*zone) {
+	all, err := load(b)
+	if err != nil {
+		panic(err)
+	}
+
+	byCountry := make(map[string][]*zone)
+	for _, z := range all {
+		byCountry[z.country] = append(byCountry[z.country], z)
+	}
+
+	return all, byCountry
+}
+
+func load(b []byte) ([]*zone, error) {
+	var all []*zone
+
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := s.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("failed to read time zones: wrong line %q", line)
+		}
+
+		latitude, longitude, err := parseCoordinates(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read time zones: %w", err)
+		}
+
+		all = append(all, &zone{
+			name:      fields[2],
+			country:   fields[0],
+			latitude:  latitude,
+			longitude: longitude,
+		})
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read time zones: %w", err)
+	}
+
+	return all, nil
+}
+
+// parseCoordinates parses ISO 6709 coordinates in the ±DDMM±DDDMM or ±DDMMSS±DDDMMSS form.
+func parseCoordinates(s string) (float64, float64, error) {
+	i := strings.IndexAny(s[1:], "+-") + 1
+	if i == 0 {
+		return 0, 0, fmt.Errorf("wrong coordinates %q", s)
+	}
+
+	latitude, err := parseDegrees(s[:i], 2)
+	if err != nil {
+		return 0, 0, err
+	}
+	longitude, err := parseDegrees(s[i:], 3)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return latitude, longitude, nil
+}
+
+func parseDegrees(s string, degreeDigits int) (float64, error) {
+	if len(s) != 1+degreeDigits+2 && len(s) != 1+degreeDigits+4 {
+		return 0, fmt.Errorf("wrong coordinate %q", s)
+	}
+
+	var deg float64
+	for i, div := 1, 1.0; i < len(s); div *= 60 {
+		n := degreeDigits
+		if i > 1 {
+			n = 2
+		}
+		v, err := strconv.Atoi(s[i : i+n])
+		if err != nil {
+			return 0, fmt.Errorf("wrong coordinate %q", s)
+		}
+		deg += float64(v) / div
+		i += n
+	}
+
+	if s[0] == '-' {
+		deg = -deg
+	}
+
+	return deg, nil
+}
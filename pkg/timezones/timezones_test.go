@@ -0,0 +1,174 @@
+package timezones
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name      string
+		country   string
+		latitude  float64
+		longitude float64
+		want      string
+	}{
+		{
+			name:      "country with a single zone",
+			country:   "NL",
+			latitude:  0,
+			longitude: 0,
+			want:      "Europe/Amsterdam",
+		},
+		{
+			name:      "us west coast",
+			country:   "US",
+			latitude:  37.4223,
+			longitude: -122.085,
+			want:      "America/Los_Angeles",
+		},
+		{
+			name:      "us east coast",
+			country:   "us",
+			latitude:  40.7128,
+			longitude: -74.006,
+			want:      "America/New_York",
+		},
+		{
+			name:      "russian far east",
+			country:   "RU",
+			latitude:  43.1155,
+			longitude: 131.8855,
+			want:      "Asia/Vladivostok",
+		},
+		{
+			name:      "unknown country",
+			country:   "ZZ",
+			latitude:  -33.8688,
+			longitude: 151.209,
+			want:      "Australia/Sydney",
+		},
+		{
+			name:      "unknown country without coordinates",
+			country:   "",
+			latitude:  0,
+			longitude: 0,
+			want:      "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Lookup(tt.country, tt.latitude, tt.longitude); got != tt.want {
+				t.Errorf("Lookup() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOffset(t *testing.T) {
+	tests := []struct {
+		name       string
+		zone       string
+		t          time.Time
+		wantOffset string
+		wantDst    bool
+		wantErr    bool
+	}{
+		{
+			name:       "summer time",
+			zone:       "Europe/Kyiv",
+			t:          time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+			wantOffset: "+03:00",
+			wantDst:    true,
+		},
+		{
+			name:       "standard time",
+			zone:       "America/New_York",
+			t:          time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantOffset: "-05:00",
+			wantDst:    false,
+		},
+		{
+			name:       "half hour offset",
+			zone:       "Asia/Kolkata",
+			t:          time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantOffset: "+05:30",
+			wantDst:    false,
+		},
+		{
+			name:    "unknown zone",
+			zone:    "Mars/Olympus_Mons",
+			t:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offset, dst, err := Offset(tt.zone, tt.t)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Offset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if offset != tt.wantOffset || dst != tt.wantDst {
+				t.Errorf("Offset() = %v, %v, want %v, %v", offset, dst, tt.wantOffset, tt.wantDst)
+			}
+		})
+	}
+}
+
+func Test_location(t *testing.T) {
+	first, err := location("Europe/Kyiv")
+	if err != nil {
+		t.Fatalf("location() error = %v", err)
+	}
+	if second, _ := location("Europe/Kyiv"); second != first {
+		t.Errorf("location() loaded the zone again, want the cached one")
+	}
+
+	if _, err = location("Mars/Olympus_Mons"); err == nil {
+		t.Fatal("location() error = nil, want an error for the unknown zone")
+	}
+	if _, ok := locations.Load("Mars/Olympus_Mons"); ok {
+		t.Errorf("location() cached the unknown zone")
+	}
+}
+
+func Test_parseCoordinates(t *testing.T) {
+	tests := []struct {
+		name          string
+		s             string
+		wantLatitude  float64
+		wantLongitude float64
+		wantErr       bool
+	}{
+		{
+			name:          "degrees and minutes",
+			s:             "+4230+00131",
+			wantLatitude:  42.5,
+			wantLongitude: 1.516666,
+		},
+		{
+			name:          "degrees, minutes and seconds",
+			s:             "+404251-0740023",
+			wantLatitude:  40.714166,
+			wantLongitude: -74.006388,
+		},
+		{
+			name:    "broken coordinates",
+			s:       "+4230",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			latitude, longitude, err := parseCoordinates(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCoordinates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if math.Abs(latitude-tt.wantLatitude) > 1e-5 || math.Abs(longitude-tt.wantLongitude) > 1e-5 {
+				t.Errorf("parseCoordinates() = %v, %v, want %v, %v",
+					latitude, longitude, tt.wantLatitude, tt.wantLongitude)
+			}
+		})
+	}
+}
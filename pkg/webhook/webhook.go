@@ -0,0 +1,190 @@
+// Package webhook delivers the update events to the configured urls, signing the payloads and retrying the failed
+// deliveries.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/streamdp/ip-info/config"
+	"github.com/streamdp/ip-info/domain"
+)
+
+const (
+	EventHeader     = "X-Ip-Info-Event"
+	TimestampHeader = "X-Ip-Info-Timestamp"
+	// SignatureHeader holds "sha256=" and the hex HMAC-SHA256 of the timestamp, a dot and the body, see Signature.
+	SignatureHeader = "X-Ip-Info-Signature"
+
+	defaultBackoff = time.Second
+)
+
+var errDelivery = errors.New("webhook delivery failed")
+
+// funcs are available in the payload templates, json quotes a value so that it can be embedded in a json payload.
+var funcs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+
+		return string(b), err
+	},
+}
+
+type Notifier struct {
+	cfg    *config.Webhook
+	l      *log.Logger
+	client *http.Client
+	tmpl   *template.Template
+
+	// backoff is the delay before the first retry, doubled on every next one
+	backoff time.Duration
+	now     func() time.Time
+
+	wg sync.WaitGroup
+}
+
+func New(cfg *config.Webhook, l *log.Logger) (*Notifier, error) {
+	n := &Notifier{
+		cfg:     cfg,
+		l:       l,
+		client:  &http.Client{Timeout: cfg.Timeout()},
+		backoff: defaultBackoff,
+		now:     time.Now,
+	}
+
+	if cfg.Template() != "" {
+		tmpl, err := template.New("webhook").Funcs(funcs).Parse(cfg.Template())
+		if err != nil {
+			return nil, fmt.Errorf("webhook: failed to parse template: %w", err)
+		}
+		n.tmpl = tmpl
+	}
+
+	return n, nil
+}
+
+// Notify delivers the event to every webhook in the background, so that the update runs don't wait for the slow
+// receivers.
+func (n *Notifier) Notify(ctx context.Context, event *domain.UpdateEvent) {
+	if len(n.cfg.Urls()) == 0 {
+		return
+	}
+
+	body, err := n.payload(event)
+	if err != nil {
+		n.l.Printf("webhook: failed to render %s payload: %v", event.Event, err)
+
+		return
+	}
+
+	for _, u := range n.cfg.Urls() {
+		n.wg.Go(func() {
+			if errDeliver := n.deliver(ctx, u, event.Event, body); errDeliver != nil {
+				n.l.Printf("webhook: %v", errDeliver)
+			}
+		})
+	}
+}
+
+// Wait blocks until the pending deliveries are over.
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+// payload renders the event with the template, or encodes it to json without one.
+func (n *Notifier) payload(event *domain.UpdateEvent) ([]byte, error) {
+	if n.tmpl == nil {
+		return json.Marshal(event)
+	}
+
+	var buf bytes.Buffer
+	if err := n.tmpl.Execute(&buf, event); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// deliver posts the payload until the receiver accepts it or the attempts are exhausted. Only the network errors,
+// 429 and 5xx responses are retried.
+func (n *Notifier) deliver(ctx context.Context, webhookUrl, event string, body []byte) error {
+	backoff := n.backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := n.post(ctx, webhookUrl, event, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= n.cfg.RetryAttempts() {
+			return fmt.Errorf("%s to %s: %w", event, host(webhookUrl), err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s to %s: %w", event, host(webhookUrl), ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (n *Notifier) post(ctx context.Context, webhookUrl, event string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookUrl, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", errDelivery, err)
+	}
+
+	timestamp := n.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	if n.cfg.Secret() != "" {
+		req.Header.Set(SignatureHeader, Signature(n.cfg.Secret(), timestamp, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("%w: %w", errDelivery, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return false, nil
+	}
+
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+
+	return retry, fmt.Errorf("%w: responded %d", errDelivery, resp.StatusCode)
+}
+
+// Signature returns the value of the signature header, receivers compute it the same way with the shared secret
+// and compare it with the received one.
+func Signature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// host keeps the path of the webhook urls out of the logs, since the urls of Slack hooks are secrets.
+func host(webhookUrl string) string {
+	u, err := url.Parse(webhookUrl)
+	if err != nil {
+		return "webhook"
+	}
+
+	return u.Host
+}
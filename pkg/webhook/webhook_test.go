@@ -0,0 +1,192 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/streamdp/ip-info/config"
+	"github.com/streamdp/ip-info/domain"
+)
+
+var testEvent = &domain.UpdateEvent{
+	Event:      domain.UpdateEventSucceeded,
+	Instance:   "ip-info-7d9f",
+	Time:       time.Date(2026, 10, 2, 3, 4, 12, 0, time.UTC),
+	DurationMs: 95000,
+	Releases:   []*domain.ImportedRelease{{Dataset: "city", Version: "2026-10", Rows: 3245870}},
+}
+
+// receiver records the requests of a webhook and answers them with the statuses in turn, the last one repeats.
+type receiver struct {
+	statuses []int
+	requests atomic.Int32
+
+	body   []byte
+	header http.Header
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := int(rc.requests.Add(1))
+	rc.body, _ = io.ReadAll(r.Body)
+	rc.header = r.Header.Clone()
+
+	w.WriteHeader(rc.statuses[min(n, len(rc.statuses))-1])
+}
+
+func newTestNotifier(t *testing.T, cfg *config.Webhook) *Notifier {
+	t.Helper()
+
+	n, err := New(cfg, log.New(io.Discard, "", log.LstdFlags))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	n.backoff = time.Millisecond
+	n.now = func() time.Time { return time.Unix(1790910252, 0) }
+
+	return n
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusNoContent}}
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+
+	n := newTestNotifier(t, (&config.Webhook{}).SetUrls(srv.URL).SetSecret("s3cr3t").SetRetryAttempts(3))
+	n.Notify(context.Background(), testEvent)
+	n.Wait()
+
+	if got := rc.requests.Load(); got != 1 {
+		t.Fatalf("Notify() requests = %d, want 1", got)
+	}
+
+	got := &domain.UpdateEvent{}
+	if err := json.Unmarshal(rc.body, got); err != nil {
+		t.Fatalf("Notify() payload isn't json: %v", err)
+	}
+	if got.Event != testEvent.Event || got.Releases[0].Rows != 3245870 || got.DurationMs != 95000 {
+		t.Errorf("Notify() payload = %s, want the event", rc.body)
+	}
+
+	if got := rc.header.Get(EventHeader); got != domain.UpdateEventSucceeded {
+		t.Errorf("Notify() %s = %q, want %q", EventHeader, got, domain.UpdateEventSucceeded)
+	}
+	timestamp, _ := strconv.ParseInt(rc.header.Get(TimestampHeader), 10, 64)
+	if timestamp != 1790910252 {
+		t.Errorf("Notify() %s = %d, want 1790910252", TimestampHeader, timestamp)
+	}
+	if got, want := rc.header.Get(SignatureHeader), Signature("s3cr3t", timestamp, rc.body); got != want {
+		t.Errorf("Notify() %s = %q, want %q", SignatureHeader, got, want)
+	}
+}
+
+func TestNotifier_NotifyUnsigned(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusOK}}
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+
+	n := newTestNotifier(t, (&config.Webhook{}).SetUrls(srv.URL).SetRetryAttempts(1))
+	n.Notify(context.Background(), testEvent)
+	n.Wait()
+
+	if got := rc.header.Get(SignatureHeader); got != "" {
+		t.Errorf("Notify() %s = %q, want none without the secret", SignatureHeader, got)
+	}
+}
+
+func TestNotifier_NotifyRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		attempts     int
+		wantRequests int32
+	}{
+		{
+			name:         "retry server errors until accepted",
+			statuses:     []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK},
+			attempts:     5,
+			wantRequests: 3,
+		},
+		{
+			name:         "retry too many requests",
+			statuses:     []int{http.StatusTooManyRequests, http.StatusAccepted},
+			attempts:     5,
+			wantRequests: 2,
+		},
+		{
+			name:         "give up when the attempts are exhausted",
+			statuses:     []int{http.StatusServiceUnavailable},
+			attempts:     3,
+			wantRequests: 3,
+		},
+		{
+			name:         "client errors aren't retried",
+			statuses:     []int{http.StatusBadRequest},
+			attempts:     5,
+			wantRequests: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &receiver{statuses: tt.statuses}
+			srv := httptest.NewServer(rc)
+			t.Cleanup(srv.Close)
+
+			n := newTestNotifier(t, (&config.Webhook{}).SetUrls(srv.URL).SetRetryAttempts(tt.attempts))
+			n.Notify(context.Background(), testEvent)
+			n.Wait()
+
+			if got := rc.requests.Load(); got != tt.wantRequests {
+				t.Errorf("Notify() requests = %d, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestNotifier_NotifyEveryUrl(t *testing.T) {
+	rc1, rc2 := &receiver{statuses: []int{http.StatusOK}}, &receiver{statuses: []int{http.StatusOK}}
+	srv1, srv2 := httptest.NewServer(rc1), httptest.NewServer(rc2)
+	t.Cleanup(srv1.Close)
+	t.Cleanup(srv2.Close)
+
+	n := newTestNotifier(t, (&config.Webhook{}).SetUrls(srv1.URL, srv2.URL).SetRetryAttempts(1))
+	n.Notify(context.Background(), testEvent)
+	n.Wait()
+
+	if rc1.requests.Load() != 1 || rc2.requests.Load() != 1 {
+		t.Errorf("Notify() requests = %d and %d, want one per url", rc1.requests.Load(), rc2.requests.Load())
+	}
+}
+
+func TestNotifier_NotifyTemplate(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusOK}}
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+
+	tmpl := `{"text": {{printf "ip-info %s on %s, %d releases" .Event .Instance (len .Releases) | json}}}`
+	n := newTestNotifier(t, (&config.Webhook{}).SetUrls(srv.URL).SetRetryAttempts(1).SetTemplate(tmpl))
+	n.Notify(context.Background(), &domain.UpdateEvent{
+		Event:    domain.UpdateEventFailed,
+		Instance: "ip-info-7d9f",
+		Error:    `city dataset: "copy" failed`,
+	})
+	n.Wait()
+
+	want := `{"text": "ip-info update.failed on ip-info-7d9f, 0 releases"}`
+	if string(rc.body) != want {
+		t.Errorf("Notify() payload = %s, want %s", rc.body, want)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New((&config.Webhook{}).SetTemplate(`{"text": {{.Event}`), log.Default()); err == nil {
+		t.Errorf("New() with a broken template error = nil, want an error")
+	}
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+// Error codes are a part of the public API, clients rely on them, so they must never change once released.
+const (
+	CodeInvalidIpAddress  = "INVALID_IP_ADDRESS"
+	CodeInvalidNetwork    = "INVALID_NETWORK"
+	CodeInvalidPageToken  = "INVALID_PAGE_TOKEN"
+	CodeInvalidExpand     = "INVALID_EXPAND"
+	CodeInvalidAsOf       = "INVALID_AS_OF"
+	CodeInvalidOverride   = "INVALID_OVERRIDE"
+	CodeIpAddressNotFound = "IP_ADDRESS_NOT_FOUND"
+	CodeReleaseNotFound   = "RELEASE_NOT_FOUND"
+	CodeOverrideNotFound  = "OVERRIDE_NOT_FOUND"
+	CodeOverrideExists    = "OVERRIDE_EXISTS"
+	CodeRateLimitExceeded = "RATE_LIMIT_EXCEEDED"
+	CodeUnauthorized      = "UNAUTHORIZED"
+	CodeInternal          = "INTERNAL"
+)
+
+// ErrorDomain identifies the service in machine-readable error details.
+const ErrorDomain = "ip-info"
+
+var errInternal = errors.New("internal server error")
+
+// PublicError maps err to a stable error code and a message that is safe to show to clients, internal wording of
+// the wrapped errors is never exposed.
+func PublicError(err error) (code string, message string) {
+	switch {
+	case errors.Is(err, ErrWrongIpAddress):
+		return CodeInvalidIpAddress, ErrWrongIpAddress.Error()
+	case errors.Is(err, ErrWrongNetwork):
+		return CodeInvalidNetwork, ErrWrongNetwork.Error()
+	case errors.Is(err, ErrWrongPageToken):
+		return CodeInvalidPageToken, ErrWrongPageToken.Error()
+	case errors.Is(err, ErrWrongExpand):
+		return CodeInvalidExpand, ErrWrongExpand.Error()
+	case errors.Is(err, ErrWrongAsOf):
+		return CodeInvalidAsOf, ErrWrongAsOf.Error()
+	case errors.Is(err, ErrWrongOverride):
+		// validation messages only describe the override the client sent
+		return CodeInvalidOverride, err.Error()
+	case errors.Is(err, domain.ErrNoOverride):
+		return CodeOverrideNotFound, domain.ErrNoOverride.Error()
+	case errors.Is(err, domain.ErrOverrideExists):
+		return CodeOverrideExists, domain.ErrOverrideExists.Error()
+	case errors.Is(err, domain.ErrNoRelease):
+		return CodeReleaseNotFound, domain.ErrNoRelease.Error()
+	case errors.Is(err, domain.ErrNoReleaseDiff):
+		return CodeReleaseNotFound, domain.ErrNoReleaseDiff.Error()
+	case errors.Is(err, domain.ErrNoIpAddress):
+		return CodeIpAddressNotFound, domain.ErrNoIpAddress.Error()
+	case errors.Is(err, ErrRateLimitExceeded):
+		return CodeRateLimitExceeded, ErrRateLimitExceeded.Error()
+	case errors.Is(err, ErrUnauthorized):
+		return CodeUnauthorized, ErrUnauthorized.Error()
+	default:
+		return CodeInternal, errInternal.Error()
+	}
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+var errCommon = errors.New("some error")
+
+func TestPublicError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    string
+		wantMessage string
+	}{
+		{
+			name:        "wrong ip address",
+			err:         fmt.Errorf("%w: %s", ErrWrongIpAddress, "8.8.8.A"),
+			wantCode:    CodeInvalidIpAddress,
+			wantMessage: "could not parse the IP address",
+		},
+		{
+			name:        "unknown expand field",
+			err:         fmt.Errorf("%w: %s", ErrWrongExpand, "weather"),
+			wantCode:    CodeInvalidExpand,
+			wantMessage: "unknown expand field",
+		},
+		{
+			name:        "no ip address in the database",
+			err:         fmt.Errorf("could not get ip location: %w", domain.ErrNoIpAddress),
+			wantCode:    CodeIpAddressNotFound,
+			wantMessage: "no ip address in the database",
+		},
+		{
+			name:        "wrong as of date",
+			err:         fmt.Errorf("%w: %s", ErrWrongAsOf, "15.06.2026"),
+			wantCode:    CodeInvalidAsOf,
+			wantMessage: "could not parse the as_of date",
+		},
+		{
+			name:        "no release for the date",
+			err:         fmt.Errorf("could not get ip location: %w", domain.ErrNoRelease),
+			wantCode:    CodeReleaseNotFound,
+			wantMessage: "no database release for the date",
+		},
+		{
+			name:        "no diff report for the release",
+			err:         domain.ErrNoReleaseDiff,
+			wantCode:    CodeReleaseNotFound,
+			wantMessage: "no diff report for the release",
+		},
+		{
+			name:        "invalid override",
+			err:         fmt.Errorf("%w: unknown country XX", ErrWrongOverride),
+			wantCode:    CodeInvalidOverride,
+			wantMessage: "invalid override: unknown country XX",
+		},
+		{
+			name:        "override not found",
+			err:         fmt.Errorf("could not get override: %w", domain.ErrNoOverride),
+			wantCode:    CodeOverrideNotFound,
+			wantMessage: "no override with the id",
+		},
+		{
+			name:        "override exists",
+			err:         fmt.Errorf("could not create override: %w", domain.ErrOverrideExists),
+			wantCode:    CodeOverrideExists,
+			wantMessage: "override for the network already exists",
+		},
+		{
+			name:        "rate limit exceeded",
+			err:         ErrRateLimitExceeded,
+			wantCode:    CodeRateLimitExceeded,
+			wantMessage: "rate limit exceeded",
+		},
+		{
+			name:        "unauthorized",
+			err:         ErrUnauthorized,
+			wantCode:    CodeUnauthorized,
+			wantMessage: "missing or wrong admin token",
+		},
+		{
+			name:        "internal error wording is hidden",
+			err:         fmt.Errorf("could not get ip location: %w", errCommon),
+			wantCode:    CodeInternal,
+			wantMessage: "internal server error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, message := PublicError(tt.err)
+			if code != tt.wantCode {
+				t.Errorf("PublicError() code = %v, want %v", code, tt.wantCode)
+			}
+			if message != tt.wantMessage {
+				t.Errorf("PublicError() message = %v, want %v", message, tt.wantMessage)
+			}
+		})
+	}
+}
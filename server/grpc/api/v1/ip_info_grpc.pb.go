@@ -20,16 +20,30 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	IpInfo_GetIpInfo_FullMethodName   = "/IpInfo/GetIpInfo"
-	IpInfo_GetClientIp_FullMethodName = "/IpInfo/GetClientIp"
+	IpInfo_GetIpInfo_FullMethodName      = "/IpInfo/GetIpInfo"
+	IpInfo_GetNetworkInfo_FullMethodName = "/IpInfo/GetNetworkInfo"
+	IpInfo_GetClientIp_FullMethodName    = "/IpInfo/GetClientIp"
+	IpInfo_ListReleases_FullMethodName   = "/IpInfo/ListReleases"
+	IpInfo_ListProviders_FullMethodName  = "/IpInfo/ListProviders"
+	IpInfo_GetVersion_FullMethodName     = "/IpInfo/GetVersion"
 )
 
 // IpInfoClient is the client API for IpInfo service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type IpInfoClient interface {
+	// Return info for the specified ip address.
 	GetIpInfo(ctx context.Context, in *Ip, opts ...grpc.CallOption) (*Response, error)
-	GetClientIp(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Response, error)
+	// Return every database range overlapping the network prefix with its location, paginated.
+	GetNetworkInfo(ctx context.Context, in *Network, opts ...grpc.CallOption) (*NetworkResponse, error)
+	// Return client ip address info, works like other "my ip" services.
+	GetClientIp(ctx context.Context, in *ClientIp, opts ...grpc.CallOption) (*Response, error)
+	// Return the monthly releases available for historical lookups.
+	ListReleases(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ReleasesResponse, error)
+	// Return the providers of the location fields with their attributions.
+	ListProviders(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ProvidersResponse, error)
+	// Return app version.
+	GetVersion(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Version, error)
 }
 
 type ipInfoClient struct {
@@ -50,7 +64,17 @@ func (c *ipInfoClient) GetIpInfo(ctx context.Context, in *Ip, opts ...grpc.CallO
 	return out, nil
 }
 
-func (c *ipInfoClient) GetClientIp(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Response, error) {
+func (c *ipInfoClient) GetNetworkInfo(ctx context.Context, in *Network, opts ...grpc.CallOption) (*NetworkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NetworkResponse)
+	err := c.cc.Invoke(ctx, IpInfo_GetNetworkInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ipInfoClient) GetClientIp(ctx context.Context, in *ClientIp, opts ...grpc.CallOption) (*Response, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(Response)
 	err := c.cc.Invoke(ctx, IpInfo_GetClientIp_FullMethodName, in, out, cOpts...)
@@ -60,12 +84,52 @@ func (c *ipInfoClient) GetClientIp(ctx context.Context, in *emptypb.Empty, opts
 	return out, nil
 }
 
+func (c *ipInfoClient) ListReleases(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ReleasesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReleasesResponse)
+	err := c.cc.Invoke(ctx, IpInfo_ListReleases_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ipInfoClient) ListProviders(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ProvidersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProvidersResponse)
+	err := c.cc.Invoke(ctx, IpInfo_ListProviders_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ipInfoClient) GetVersion(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Version, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Version)
+	err := c.cc.Invoke(ctx, IpInfo_GetVersion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // IpInfoServer is the server API for IpInfo service.
 // All implementations must embed UnimplementedIpInfoServer
 // for forward compatibility.
 type IpInfoServer interface {
+	// Return info for the specified ip address.
 	GetIpInfo(context.Context, *Ip) (*Response, error)
-	GetClientIp(context.Context, *emptypb.Empty) (*Response, error)
+	// Return every database range overlapping the network prefix with its location, paginated.
+	GetNetworkInfo(context.Context, *Network) (*NetworkResponse, error)
+	// Return client ip address info, works like other "my ip" services.
+	GetClientIp(context.Context, *ClientIp) (*Response, error)
+	// Return the monthly releases available for historical lookups.
+	ListReleases(context.Context, *emptypb.Empty) (*ReleasesResponse, error)
+	// Return the providers of the location fields with their attributions.
+	ListProviders(context.Context, *emptypb.Empty) (*ProvidersResponse, error)
+	// Return app version.
+	GetVersion(context.Context, *emptypb.Empty) (*Version, error)
 	mustEmbedUnimplementedIpInfoServer()
 }
 
@@ -79,9 +143,21 @@ type UnimplementedIpInfoServer struct{}
 func (UnimplementedIpInfoServer) GetIpInfo(context.Context, *Ip) (*Response, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetIpInfo not implemented")
 }
-func (UnimplementedIpInfoServer) GetClientIp(context.Context, *emptypb.Empty) (*Response, error) {
+func (UnimplementedIpInfoServer) GetNetworkInfo(context.Context, *Network) (*NetworkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNetworkInfo not implemented")
+}
+func (UnimplementedIpInfoServer) GetClientIp(context.Context, *ClientIp) (*Response, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetClientIp not implemented")
 }
+func (UnimplementedIpInfoServer) ListReleases(context.Context, *emptypb.Empty) (*ReleasesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListReleases not implemented")
+}
+func (UnimplementedIpInfoServer) ListProviders(context.Context, *emptypb.Empty) (*ProvidersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListProviders not implemented")
+}
+func (UnimplementedIpInfoServer) GetVersion(context.Context, *emptypb.Empty) (*Version, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVersion not implemented")
+}
 func (UnimplementedIpInfoServer) mustEmbedUnimplementedIpInfoServer() {}
 func (UnimplementedIpInfoServer) testEmbeddedByValue()                {}
 
@@ -121,8 +197,26 @@ func _IpInfo_GetIpInfo_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _IpInfo_GetNetworkInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Network)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IpInfoServer).GetNetworkInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IpInfo_GetNetworkInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IpInfoServer).GetNetworkInfo(ctx, req.(*Network))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _IpInfo_GetClientIp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(emptypb.Empty)
+	in := new(ClientIp)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -134,7 +228,61 @@ func _IpInfo_GetClientIp_Handler(srv interface{}, ctx context.Context, dec func(
 		FullMethod: IpInfo_GetClientIp_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(IpInfoServer).GetClientIp(ctx, req.(*emptypb.Empty))
+		return srv.(IpInfoServer).GetClientIp(ctx, req.(*ClientIp))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IpInfo_ListReleases_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IpInfoServer).ListReleases(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IpInfo_ListReleases_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IpInfoServer).ListReleases(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IpInfo_ListProviders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IpInfoServer).ListProviders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IpInfo_ListProviders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IpInfoServer).ListProviders(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IpInfo_GetVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IpInfoServer).GetVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IpInfo_GetVersion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IpInfoServer).GetVersion(ctx, req.(*emptypb.Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -150,10 +298,26 @@ var IpInfo_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetIpInfo",
 			Handler:    _IpInfo_GetIpInfo_Handler,
 		},
+		{
+			MethodName: "GetNetworkInfo",
+			Handler:    _IpInfo_GetNetworkInfo_Handler,
+		},
 		{
 			MethodName: "GetClientIp",
 			Handler:    _IpInfo_GetClientIp_Handler,
 		},
+		{
+			MethodName: "ListReleases",
+			Handler:    _IpInfo_ListReleases_Handler,
+		},
+		{
+			MethodName: "ListProviders",
+			Handler:    _IpInfo_ListProviders_Handler,
+		},
+		{
+			MethodName: "GetVersion",
+			Handler:    _IpInfo_GetVersion_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/proto/ip_info.proto",
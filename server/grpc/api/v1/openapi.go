@@ -0,0 +1,6 @@
+package v1
+
+import _ "embed"
+
+//go:embed ip_info.swagger.json
+var OpenApiSpec []byte
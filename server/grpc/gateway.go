@@ -0,0 +1,75 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/streamdp/ip-info/domain"
+	"github.com/streamdp/ip-info/pkg/iplocator"
+	"github.com/streamdp/ip-info/server"
+	v1 "github.com/streamdp/ip-info/server/grpc/api/v1"
+	rpccode "google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Gateway returns the REST surface generated from the http annotations in ip_info.proto. Calls are served in
+// process by the gRPC handlers, so both protocols share the same validation and error mapping.
+func (s *Server) Gateway(ctx context.Context) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions: protojson.MarshalOptions{
+				UseProtoNames:   true,
+				EmitUnpopulated: true,
+			},
+			UnmarshalOptions: protojson.UnmarshalOptions{
+				DiscardUnknown: true,
+			},
+		}),
+		runtime.WithIncomingHeaderMatcher(incomingHeaderMatcher),
+		runtime.WithErrorHandler(s.problemErrorHandler),
+	)
+
+	if err := v1.RegisterIpInfoHandlerServer(ctx, mux, s); err != nil {
+		return nil, fmt.Errorf("failed to register gateway handlers: %w", err)
+	}
+
+	return mux, nil
+}
+
+// problemErrorHandler writes gateway errors as RFC 7807 problem details, the stable error code is taken from the
+// ErrorInfo attached by grpcError or derived from the gRPC status code for errors raised by the gateway itself.
+func (s *Server) problemErrorHandler(
+	_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error,
+) {
+	st := status.Convert(err)
+
+	code := rpccode.Code(st.Code()).String()
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.GetDomain() == server.ErrorDomain {
+			code = info.GetReason()
+		}
+	}
+
+	httpStatus := runtime.HTTPStatusFromCode(st.Code())
+	problem := domain.NewProblem(httpStatus, code, st.Message(), r.URL.Path)
+
+	w.Header().Set("Content-Type", domain.ProblemContentType)
+	w.WriteHeader(httpStatus)
+	if _, errWrite := w.Write(problem.Bytes()); errWrite != nil {
+		s.l.Println(fmt.Errorf("failed to write response: %w", errWrite))
+	}
+}
+
+func incomingHeaderMatcher(key string) (string, bool) {
+	switch k := strings.ToLower(key); k {
+	case iplocator.XRealIp, iplocator.CfConnectingIp, iplocator.AcceptLanguage:
+		return k, true
+	}
+
+	return runtime.DefaultHeaderMatcher(key)
+}
@@ -0,0 +1,245 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/streamdp/ip-info/domain"
+	"github.com/streamdp/ip-info/pkg/iplocator"
+	"github.com/streamdp/ip-info/server"
+)
+
+func TestServer_Gateway(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		path           string
+		header         map[string]string
+		locator        server.Locator
+		wantStatusCode int
+		wantIp         string
+		wantCode       string
+	}{
+		{
+			name:           "get ip info",
+			path:           "/v1/ip-info?ip=8.8.8.8",
+			locator:        &mockLocator{ipInfo: &domain.IpInfo{Ip: netip.MustParseAddr("8.8.8.8")}},
+			wantStatusCode: http.StatusOK,
+			wantIp:         "8.8.8.8",
+		},
+		{
+			name:           "get client ip info",
+			path:           "/v1/client-ip",
+			header:         map[string]string{iplocator.XRealIp: "1.1.1.1"},
+			locator:        &mockLocator{},
+			wantStatusCode: http.StatusOK,
+			wantIp:         "1.1.1.1",
+		},
+		{
+			name:           "get client ip info with expanded fields",
+			path:           "/v1/client-ip?expand=country",
+			header:         map[string]string{iplocator.XRealIp: "1.1.1.1"},
+			locator:        &mockLocator{},
+			wantStatusCode: http.StatusOK,
+			wantIp:         "1.1.1.1",
+		},
+		{
+			name:           "unknown expand field",
+			path:           "/v1/ip-info?ip=8.8.8.8&expand=weather",
+			locator:        &mockLocator{},
+			wantStatusCode: http.StatusBadRequest,
+			wantCode:       server.CodeInvalidExpand,
+		},
+		{
+			name:           "wrong ip address",
+			path:           "/v1/ip-info?ip=8.8.8.A",
+			locator:        &mockLocator{err: server.ErrWrongIpAddress},
+			wantStatusCode: http.StatusBadRequest,
+			wantCode:       server.CodeInvalidIpAddress,
+		},
+		{
+			name:           "ip address not found",
+			path:           "/v1/ip-info?ip=8.8.8.8",
+			locator:        &mockLocator{err: fmt.Errorf("could not get ip location: %w", domain.ErrNoIpAddress)},
+			wantStatusCode: http.StatusNotFound,
+			wantCode:       server.CodeIpAddressNotFound,
+		},
+		{
+			name:           "get ip info as of a date",
+			path:           "/v1/ip-info?ip=8.8.8.8&as_of=2024-06-15",
+			locator:        &mockLocator{},
+			wantStatusCode: http.StatusOK,
+			wantIp:         "8.8.8.8",
+		},
+		{
+			name:           "wrong as_of date",
+			path:           "/v1/ip-info?ip=8.8.8.8&as_of=15.06.2024",
+			locator:        &mockLocator{},
+			wantStatusCode: http.StatusBadRequest,
+			wantCode:       server.CodeInvalidAsOf,
+		},
+		{
+			name:           "release not found",
+			path:           "/v1/ip-info?ip=8.8.8.8&as_of=2001-01-01",
+			locator:        &mockLocator{err: fmt.Errorf("could not get ip location: %w", domain.ErrNoRelease)},
+			wantStatusCode: http.StatusNotFound,
+			wantCode:       server.CodeReleaseNotFound,
+		},
+		{
+			name: "list releases",
+			path: "/v1/releases",
+			locator: &mockLocator{releases: []*domain.Release{
+				{Dataset: "city", Version: "2024-07", ImportedAt: time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC), Active: true},
+			}},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "list providers",
+			path: "/v1/providers",
+			locator: &mockLocator{providers: []*domain.Provider{
+				{Name: "dbip", Attribution: "IP Geolocation by DB-IP", Fields: domain.LocationFields},
+			}},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "unknown route",
+			path:           "/v1/unknown",
+			locator:        &mockLocator{},
+			wantStatusCode: http.StatusNotFound,
+			wantCode:       "NOT_FOUND",
+		},
+		{
+			name: "get network info",
+			path: "/v1/ip-info/network?cidr=203.0.113.0/22&page_size=1",
+			locator: &mockLocator{networkInfo: &domain.NetworkInfo{
+				Network: "203.0.112.0/22",
+				Ranges:  []*domain.IpRange{{IpStart: netip.MustParseAddr("203.0.112.0"), IpEnd: netip.MustParseAddr("203.0.112.255")}},
+			}},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "wrong network prefix",
+			path:           "/v1/ip-info/network?cidr=203.0.113.0/33",
+			locator:        &mockLocator{err: server.ErrWrongNetwork},
+			wantStatusCode: http.StatusBadRequest,
+			wantCode:       server.CodeInvalidNetwork,
+		},
+		{
+			name:           "get version",
+			path:           "/v1/app/version",
+			locator:        &mockLocator{},
+			wantStatusCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := &Server{
+				locator:    tt.locator,
+				l:          log.New(io.Discard, "", log.LstdFlags),
+				appVersion: "v0.0.1",
+			}
+
+			gw, err := s.Gateway(t.Context())
+			if err != nil {
+				t.Fatalf("Gateway() error = %v", err)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+
+			gw.ServeHTTP(w, r)
+
+			res := w.Result()
+			t.Cleanup(func() { _ = res.Body.Close() })
+
+			if res.StatusCode != tt.wantStatusCode {
+				t.Errorf("Gateway() status = %d, want %d", res.StatusCode, tt.wantStatusCode)
+			}
+
+			if tt.wantCode != "" {
+				if got := res.Header.Get("Content-Type"); got != domain.ProblemContentType {
+					t.Errorf("Gateway() content type = %s, want %s", got, domain.ProblemContentType)
+				}
+
+				problem := domain.Problem{}
+				if err = json.NewDecoder(res.Body).Decode(&problem); err != nil {
+					t.Fatalf("decode body: expected no error, got: %v", err)
+				}
+				if problem.Code != tt.wantCode || problem.Status != tt.wantStatusCode {
+					t.Errorf("Gateway() problem = %+v, want code %s", problem, tt.wantCode)
+				}
+			}
+
+			if tt.wantIp != "" {
+				body := map[string]any{}
+				if err = json.NewDecoder(res.Body).Decode(&body); err != nil {
+					t.Fatalf("decode body: expected no error, got: %v", err)
+				}
+				if body["ip"] != tt.wantIp {
+					t.Errorf("Gateway() ip = %v, want %v", body["ip"], tt.wantIp)
+				}
+			}
+		})
+	}
+}
+
+type mockLocator struct {
+	ipInfo      *domain.IpInfo
+	networkInfo *domain.NetworkInfo
+	releases    []*domain.Release
+	providers   []*domain.Provider
+	err         error
+}
+
+func (ml *mockLocator) GetIpInfo(
+	_ context.Context, ipString string, _ *domain.LookupOptions,
+) (*domain.IpInfo, error) {
+	if ml.err != nil {
+		return nil, ml.err
+	}
+	if ml.ipInfo == nil {
+		addr, _ := netip.ParseAddr(ipString)
+
+		return &domain.IpInfo{Ip: addr}, nil
+	}
+
+	return ml.ipInfo, nil
+}
+
+func (ml *mockLocator) GetNetworkInfo(_ context.Context, _ string, _ int, _ string) (*domain.NetworkInfo, error) {
+	if ml.err != nil {
+		return nil, ml.err
+	}
+
+	return ml.networkInfo, nil
+}
+
+func (ml *mockLocator) GetReleases(_ context.Context) ([]*domain.Release, error) {
+	if ml.err != nil {
+		return nil, ml.err
+	}
+
+	return ml.releases, nil
+}
+
+func (ml *mockLocator) GetProviders(_ context.Context) ([]*domain.Provider, error) {
+	if ml.err != nil {
+		return nil, ml.err
+	}
+
+	return ml.providers, nil
+}
@@ -3,60 +3,183 @@ package grpc
 import (
 	"context"
 	"errors"
-	"net"
+	"net/netip"
+	"strings"
 
-	"github.com/streamdp/ip-info/database"
 	"github.com/streamdp/ip-info/domain"
 	"github.com/streamdp/ip-info/pkg/iplocator"
 	"github.com/streamdp/ip-info/server"
 	v1 "github.com/streamdp/ip-info/server/grpc/api/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func (s *Server) GetIpInfo(ctx context.Context, in *v1.Ip) (*v1.Response, error) {
-	response, err := s.locator.GetIpInfo(ctx, in.GetIp())
+	opts, err := server.NewLookupOptions(in.GetExpand(), grpcLanguage(ctx, in.GetLang()), in.GetAsOf())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	response, err := s.locator.GetIpInfo(ctx, in.GetIp(), opts)
 	if err != nil {
 		s.l.Println(err)
 
-		return nil, status.Error(getGrpcCode(err), err.Error())
+		return nil, grpcError(err)
 	}
 
 	return convertIpInfoDto(response), nil
 }
 
-func (s *Server) GetClientIp(ctx context.Context, _ *emptypb.Empty) (*v1.Response, error) {
-	response, err := s.locator.GetIpInfo(ctx, grpcClientIp(ctx))
+func (s *Server) GetClientIp(ctx context.Context, in *v1.ClientIp) (*v1.Response, error) {
+	opts, err := server.NewLookupOptions(in.GetExpand(), grpcLanguage(ctx, in.GetLang()), "")
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	response, err := s.locator.GetIpInfo(ctx, grpcClientIp(ctx), opts)
 	if err != nil {
 		s.l.Println(err)
 
-		return nil, status.Error(getGrpcCode(err), err.Error())
+		return nil, grpcError(err)
 	}
 
 	return convertIpInfoDto(response), nil
 }
 
+func (s *Server) GetNetworkInfo(ctx context.Context, in *v1.Network) (*v1.NetworkResponse, error) {
+	response, err := s.locator.GetNetworkInfo(ctx, in.GetCidr(), int(in.GetPageSize()), in.GetPageToken())
+	if err != nil {
+		s.l.Println(err)
+
+		return nil, grpcError(err)
+	}
+
+	return convertNetworkInfoDto(response), nil
+}
+
+func (s *Server) ListReleases(ctx context.Context, _ *emptypb.Empty) (*v1.ReleasesResponse, error) {
+	releases, err := s.locator.GetReleases(ctx)
+	if err != nil {
+		s.l.Println(err)
+
+		return nil, grpcError(err)
+	}
+
+	return convertReleasesDto(releases), nil
+}
+
+func (s *Server) ListProviders(ctx context.Context, _ *emptypb.Empty) (*v1.ProvidersResponse, error) {
+	providers, err := s.locator.GetProviders(ctx)
+	if err != nil {
+		s.l.Println(err)
+
+		return nil, grpcError(err)
+	}
+
+	return convertProvidersDto(providers), nil
+}
+
+func (s *Server) GetVersion(_ context.Context, _ *emptypb.Empty) (*v1.Version, error) {
+	return &v1.Version{Version: s.appVersion}, nil
+}
+
 func convertIpInfoDto(dto *domain.IpInfo) *v1.Response {
+	var dataUpdatedAt *timestamppb.Timestamp
+	if !dto.DataUpdatedAt.IsZero() {
+		dataUpdatedAt = timestamppb.New(dto.DataUpdatedAt)
+	}
+
 	return &v1.Response{
-		Ip: func(ip net.IP) string {
-			if ip == nil {
-				return ""
-			}
-
-			return ip.String()
-		}(dto.Ip),
-		Continent: dto.Continent,
-		Country:   dto.Country,
-		StateProv: dto.StateProv,
-		City:      dto.City,
-		Latitude:  dto.Latitude,
-		Longitude: dto.Longitude,
+		Ip:            ipToString(dto.Ip),
+		Continent:     dto.Continent,
+		Country:       dto.Country,
+		StateProv:     dto.StateProv,
+		City:          dto.City,
+		Latitude:      dto.Latitude,
+		Longitude:     dto.Longitude,
+		AddressType:   string(dto.AddressType),
+		Network:       dto.Network,
+		Source:        dto.Source,
+		Sources:       dto.Sources,
+		DataVersion:   dto.DataVersion,
+		DataUpdatedAt: dataUpdatedAt,
+		Asn:           dto.Asn,
+		AsOrg:         dto.AsOrg,
+		TimeZone:      dto.TimeZone,
+		UtcOffset:     dto.UtcOffset,
+		IsDst:         dto.IsDst,
+		CountryName:   dto.CountryName,
+		ContinentName: dto.ContinentName,
+		IsEu:          dto.IsEu,
+		Currency:      dto.Currency,
+		CallingCode:   dto.CallingCode,
+		CountryFlag:   dto.CountryFlag,
+		Lang:          dto.Lang,
 	}
 }
 
+func convertNetworkInfoDto(dto *domain.NetworkInfo) *v1.NetworkResponse {
+	ranges := make([]*v1.IpRange, 0, len(dto.Ranges))
+	for _, r := range dto.Ranges {
+		ranges = append(ranges, &v1.IpRange{
+			IpStart:   ipToString(r.IpStart),
+			IpEnd:     ipToString(r.IpEnd),
+			Continent: r.Continent,
+			Country:   r.Country,
+			StateProv: r.StateProv,
+			City:      r.City,
+			Latitude:  r.Latitude,
+			Longitude: r.Longitude,
+		})
+	}
+
+	return &v1.NetworkResponse{
+		Network:       dto.Network,
+		Ranges:        ranges,
+		NextPageToken: dto.NextPageToken,
+	}
+}
+
+func convertReleasesDto(dto []*domain.Release) *v1.ReleasesResponse {
+	releases := make([]*v1.Release, 0, len(dto))
+	for _, r := range dto {
+		releases = append(releases, &v1.Release{
+			Dataset:    r.Dataset,
+			Version:    r.Version,
+			ImportedAt: timestamppb.New(r.ImportedAt),
+			Active:     r.Active,
+		})
+	}
+
+	return &v1.ReleasesResponse{Releases: releases}
+}
+
+func convertProvidersDto(dto []*domain.Provider) *v1.ProvidersResponse {
+	providers := make([]*v1.Provider, 0, len(dto))
+	for _, p := range dto {
+		providers = append(providers, &v1.Provider{
+			Name:        p.Name,
+			Attribution: p.Attribution,
+			Fields:      p.Fields,
+		})
+	}
+
+	return &v1.ProvidersResponse{Providers: providers}
+}
+
+func ipToString(ip netip.Addr) string {
+	if !ip.IsValid() {
+		return ""
+	}
+
+	return ip.String()
+}
+
 func grpcClientIp(ctx context.Context) string {
 	if md, ok := metadata.FromIncomingContext(ctx); ok {
 		if ipArr := md.Get(iplocator.XRealIp); len(ipArr) != 0 && ipArr[0] != "" {
@@ -64,7 +187,9 @@ func grpcClientIp(ctx context.Context) string {
 		}
 
 		if ipArr := md.Get(iplocator.XForwardedFor); len(ipArr) != 0 && ipArr[0] != "" {
-			return ipArr[0]
+			ip, _, _ := strings.Cut(ipArr[0], ",")
+
+			return strings.TrimSpace(ip)
 		}
 		if ipArr := md.Get(iplocator.CfConnectingIp); len(ipArr) != 0 && ipArr[0] != "" {
 			return ipArr[0]
@@ -78,6 +203,39 @@ func grpcClientIp(ctx context.Context) string {
 	return ""
 }
 
+// grpcLanguage returns the language requested in the message, or in the lang or accept-language metadata.
+func grpcLanguage(ctx context.Context, lang string) string {
+	if lang != "" {
+		return lang
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if langArr := md.Get(iplocator.Lang); len(langArr) != 0 && langArr[0] != "" {
+			return langArr[0]
+		}
+		if langArr := md.Get(iplocator.AcceptLanguage); len(langArr) != 0 {
+			return strings.Join(langArr, ",")
+		}
+	}
+
+	return ""
+}
+
+// grpcError hides internal error wording from clients and attaches the stable error code as ErrorInfo details.
+func grpcError(err error) error {
+	code, message := server.PublicError(err)
+
+	st := status.New(getGrpcCode(err), message)
+	if stWithDetails, errDetails := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: code,
+		Domain: server.ErrorDomain,
+	}); errDetails == nil {
+		st = stWithDetails
+	}
+
+	return st.Err()
+}
+
 func getGrpcCode(err error) codes.Code {
 	if err == nil {
 		return codes.OK
@@ -85,10 +243,12 @@ func getGrpcCode(err error) codes.Code {
 	if errors.Is(err, server.ErrRateLimitExceeded) {
 		return codes.ResourceExhausted
 	}
-	if errors.Is(err, server.ErrWrongIpAddress) {
+	if errors.Is(err, server.ErrWrongIpAddress) || errors.Is(err, server.ErrWrongNetwork) ||
+		errors.Is(err, server.ErrWrongPageToken) || errors.Is(err, server.ErrWrongExpand) ||
+		errors.Is(err, server.ErrWrongAsOf) {
 		return codes.InvalidArgument
 	}
-	if errors.Is(err, database.ErrNoIpAddress) {
+	if errors.Is(err, domain.ErrNoIpAddress) || errors.Is(err, domain.ErrNoRelease) {
 		return codes.NotFound
 	}
 
@@ -3,18 +3,24 @@ package grpc
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
+	"net/netip"
 	"reflect"
 	"testing"
+	"time"
 
-	"github.com/streamdp/ip-info/database"
 	"github.com/streamdp/ip-info/domain"
 	"github.com/streamdp/ip-info/pkg/iplocator"
 	"github.com/streamdp/ip-info/server"
 	v1 "github.com/streamdp/ip-info/server/grpc/api/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var errCommon = errors.New("some error")
@@ -28,22 +34,48 @@ func Test_convertIpInfoDto(t *testing.T) {
 		{
 			name: "regular conversion",
 			dto: &domain.IpInfo{
-				Ip:        net.ParseIP("8.8.8.8"),
-				Continent: "NA",
-				Country:   "US",
-				StateProv: "California",
-				City:      "Mountain View",
-				Latitude:  -122.085,
-				Longitude: 37.4223,
+				Ip:            netip.MustParseAddr("8.8.8.8"),
+				Continent:     "NA",
+				Country:       "US",
+				StateProv:     "California",
+				City:          "Mountain View",
+				Latitude:      -122.085,
+				Longitude:     37.4223,
+				AddressType:   domain.AddressTypeGlobal,
+				Network:       "8.8.8.0/24",
+				Source:        domain.SourceOverride,
+				Sources:       domain.Sources{domain.FieldCity: domain.SourceOverride, domain.FieldCountry: "acme"},
+				DataVersion:   "2024-09",
+				DataUpdatedAt: time.Date(2024, 9, 2, 0, 0, 0, 0, time.UTC),
+				Asn:           15169,
+				AsOrg:         "Google LLC",
+				TimeZone:      "America/Los_Angeles",
+				UtcOffset:     "-07:00",
+				IsDst:         true,
+				CountryName:   "Vereinigte Staaten",
+				Lang:          "de",
 			},
 			want: &v1.Response{
-				Ip:        "8.8.8.8",
-				Continent: "NA",
-				Country:   "US",
-				StateProv: "California",
-				City:      "Mountain View",
-				Latitude:  -122.085,
-				Longitude: 37.4223,
+				Ip:            "8.8.8.8",
+				Continent:     "NA",
+				Country:       "US",
+				StateProv:     "California",
+				City:          "Mountain View",
+				Latitude:      -122.085,
+				Longitude:     37.4223,
+				AddressType:   "global",
+				Network:       "8.8.8.0/24",
+				Source:        "override",
+				Sources:       map[string]string{"city": "override", "country": "acme"},
+				DataVersion:   "2024-09",
+				DataUpdatedAt: timestamppb.New(time.Date(2024, 9, 2, 0, 0, 0, 0, time.UTC)),
+				Asn:           15169,
+				AsOrg:         "Google LLC",
+				TimeZone:      "America/Los_Angeles",
+				UtcOffset:     "-07:00",
+				IsDst:         true,
+				CountryName:   "Vereinigte Staaten",
+				Lang:          "de",
 			},
 		},
 		{
@@ -61,6 +93,102 @@ func Test_convertIpInfoDto(t *testing.T) {
 	}
 }
 
+func Test_convertNetworkInfoDto(t *testing.T) {
+	tests := []struct {
+		name string
+		dto  *domain.NetworkInfo
+		want *v1.NetworkResponse
+	}{
+		{
+			name: "regular conversion",
+			dto: &domain.NetworkInfo{
+				Network: "203.0.112.0/22",
+				Ranges: []*domain.IpRange{
+					{
+						IpStart:   netip.MustParseAddr("203.0.112.0"),
+						IpEnd:     netip.MustParseAddr("203.0.113.255"),
+						Continent: "OC",
+						Country:   "AU",
+						StateProv: "New South Wales",
+						City:      "Sydney",
+						Latitude:  -33.8688,
+						Longitude: 151.209,
+					},
+				},
+				NextPageToken: "token",
+			},
+			want: &v1.NetworkResponse{
+				Network: "203.0.112.0/22",
+				Ranges: []*v1.IpRange{
+					{
+						IpStart:   "203.0.112.0",
+						IpEnd:     "203.0.113.255",
+						Continent: "OC",
+						Country:   "AU",
+						StateProv: "New South Wales",
+						City:      "Sydney",
+						Latitude:  -33.8688,
+						Longitude: 151.209,
+					},
+				},
+				NextPageToken: "token",
+			},
+		},
+		{
+			name: "empty conversion",
+			dto:  &domain.NetworkInfo{},
+			want: &v1.NetworkResponse{Ranges: []*v1.IpRange{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := convertNetworkInfoDto(tt.dto); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("convertNetworkInfoDto() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_convertReleasesDto(t *testing.T) {
+	importedAt := time.Date(2024, 7, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		dto  []*domain.Release
+		want *v1.ReleasesResponse
+	}{
+		{
+			name: "regular conversion",
+			dto:  []*domain.Release{{Dataset: "city", Version: "2024-07", ImportedAt: importedAt, Active: true}},
+			want: &v1.ReleasesResponse{Releases: []*v1.Release{
+				{Dataset: "city", Version: "2024-07", ImportedAt: timestamppb.New(importedAt), Active: true},
+			}},
+		},
+		{
+			name: "empty conversion",
+			dto:  nil,
+			want: &v1.ReleasesResponse{Releases: []*v1.Release{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := convertReleasesDto(tt.dto); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("convertReleasesDto() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_convertProvidersDto(t *testing.T) {
+	dto := []*domain.Provider{{Name: "dbip", Attribution: "IP Geolocation by DB-IP", Fields: []string{"city"}}}
+	want := &v1.ProvidersResponse{Providers: []*v1.Provider{
+		{Name: "dbip", Attribution: "IP Geolocation by DB-IP", Fields: []string{"city"}},
+	}}
+	if got := convertProvidersDto(dto); !reflect.DeepEqual(got, want) {
+		t.Errorf("convertProvidersDto() = %v, want %v", got, want)
+	}
+}
+
 func Test_grpcClientIp(t *testing.T) {
 	makeContextWithHeader := func(header, value string) context.Context {
 		return metadata.NewIncomingContext(context.TODO(), metadata.MD{
@@ -83,6 +211,11 @@ func Test_grpcClientIp(t *testing.T) {
 			ctx:  makeContextWithHeader(iplocator.XForwardedFor, "8.8.8.8"),
 			want: "8.8.8.8",
 		},
+		{
+			name: "get first ip from x-forwarded-for list",
+			ctx:  makeContextWithHeader(iplocator.XForwardedFor, "8.8.8.8, 10.0.0.1"),
+			want: "8.8.8.8",
+		},
 		{
 			name: "get ip from x-real-ip header",
 			ctx:  makeContextWithHeader(iplocator.XRealIp, "12.12.23.14"),
@@ -108,6 +241,49 @@ func Test_grpcClientIp(t *testing.T) {
 	}
 }
 
+func Test_grpcLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		lang string
+		want string
+	}{
+		{
+			name: "language from the message",
+			ctx:  metadata.NewIncomingContext(context.TODO(), metadata.MD{iplocator.Lang: []string{"fr"}}),
+			lang: "de",
+			want: "de",
+		},
+		{
+			name: "language from lang metadata",
+			ctx: metadata.NewIncomingContext(context.TODO(), metadata.MD{
+				iplocator.Lang:           []string{"fr"},
+				iplocator.AcceptLanguage: []string{"uk"},
+			}),
+			want: "fr",
+		},
+		{
+			name: "language from accept-language metadata",
+			ctx: metadata.NewIncomingContext(context.TODO(), metadata.MD{
+				iplocator.AcceptLanguage: []string{"uk", "en;q=0.5"},
+			}),
+			want: "uk,en;q=0.5",
+		},
+		{
+			name: "no language",
+			ctx:  context.TODO(),
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := grpcLanguage(tt.ctx, tt.lang); got != tt.want {
+				t.Errorf("grpcLanguage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_getGrpcCode(t *testing.T) {
 	tests := []struct {
 		name string
@@ -129,9 +305,24 @@ func Test_getGrpcCode(t *testing.T) {
 			err:  server.ErrWrongIpAddress,
 			want: codes.InvalidArgument,
 		},
+		{
+			name: "get codes.InvalidArgument on unknown expand field",
+			err:  server.ErrWrongExpand,
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "get codes.InvalidArgument on wrong as_of date",
+			err:  server.ErrWrongAsOf,
+			want: codes.InvalidArgument,
+		},
 		{
 			name: "get codes.NotFound",
-			err:  database.ErrNoIpAddress,
+			err:  domain.ErrNoIpAddress,
+			want: codes.NotFound,
+		},
+		{
+			name: "get codes.NotFound on missing release",
+			err:  domain.ErrNoRelease,
 			want: codes.NotFound,
 		},
 		{
@@ -148,3 +339,76 @@ func Test_getGrpcCode(t *testing.T) {
 		})
 	}
 }
+
+func Test_grpcError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    codes.Code
+		wantReason  string
+		wantMessage string
+	}{
+		{
+			name:        "wrong ip address",
+			err:         fmt.Errorf("%w: 8.8.8.A", server.ErrWrongIpAddress),
+			wantCode:    codes.InvalidArgument,
+			wantReason:  server.CodeInvalidIpAddress,
+			wantMessage: server.ErrWrongIpAddress.Error(),
+		},
+		{
+			name:        "internal error wording is hidden",
+			err:         fmt.Errorf("could not get ip location: %w", errCommon),
+			wantCode:    codes.Internal,
+			wantReason:  server.CodeInternal,
+			wantMessage: "internal server error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(grpcError(tt.err))
+			if st.Code() != tt.wantCode {
+				t.Errorf("grpcError() code = %v, want %v", st.Code(), tt.wantCode)
+			}
+			if st.Message() != tt.wantMessage {
+				t.Errorf("grpcError() message = %v, want %v", st.Message(), tt.wantMessage)
+			}
+
+			details := st.Details()
+			if len(details) != 1 {
+				t.Fatalf("grpcError() details = %v, want ErrorInfo", details)
+			}
+			if info, ok := details[0].(*errdetails.ErrorInfo); !ok || info.GetReason() != tt.wantReason {
+				t.Errorf("grpcError() details = %v, want reason %v", details[0], tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestServer_GetVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+	}{
+		{
+			name:    "version v0.0.1",
+			version: "v0.0.1",
+		},
+		{
+			name:    "empty version",
+			version: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{appVersion: tt.version}
+
+			got, err := s.GetVersion(context.TODO(), &emptypb.Empty{})
+			if err != nil {
+				t.Fatalf("GetVersion() error = %v", err)
+			}
+			if got.GetVersion() != tt.version {
+				t.Errorf("GetVersion() = %v, want %v", got.GetVersion(), tt.version)
+			}
+		})
+	}
+}
@@ -5,13 +5,12 @@ import (
 
 	"github.com/streamdp/ip-info/server"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/status"
 )
 
 func rateLimiterUSI(l server.Limiter) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
 		if err := l.Limit(ctx, grpcClientIp(ctx)); err != nil {
-			return nil, status.Error(getGrpcCode(err), err.Error())
+			return nil, grpcError(err)
 		}
 
 		return handler(ctx, req)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 
 	"github.com/streamdp/ip-info/config"
 	"github.com/streamdp/ip-info/server"
@@ -13,7 +14,7 @@ import (
 	"google.golang.org/grpc/reflection"
 )
 
-//go:generate protoc ./api/proto/ip_info.proto --go_out=api/ --go-grpc_out=api/
+//go:generate protoc -I . -I ./api/proto/third_party ./api/proto/ip_info.proto --go_out=api/ --go-grpc_out=api/ --grpc-gateway_out=api/ --openapiv2_out=api/v1/ --openapiv2_opt=allow_merge=true,merge_file_name=ip_info,json_names_for_fields=false,disable_default_errors=true
 
 type Server struct {
 	v1.IpInfoServer
@@ -22,6 +23,8 @@ type Server struct {
 	locator server.Locator
 	cfg     *config.App
 	l       *log.Logger
+
+	appVersion string
 }
 
 func NewServer(locator server.Locator, l *log.Logger, limiter server.Limiter, cfg *config.App) *Server {
@@ -38,6 +41,8 @@ func NewServer(locator server.Locator, l *log.Logger, limiter server.Limiter, cf
 		srv:     gRpcSrv,
 		cfg:     cfg,
 		l:       l,
+
+		appVersion: cfg.Version(),
 	}
 
 	v1.RegisterIpInfoServer(gRpcSrv, ipInfoSrv)
@@ -61,6 +66,10 @@ func (s *Server) Run() {
 	}
 }
 
+func (s *Server) Handler() http.Handler {
+	return s.srv
+}
+
 func (s *Server) Close() {
 	s.srv.GracefulStop()
 }
@@ -0,0 +1,225 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/streamdp/ip-info/domain"
+	"github.com/streamdp/ip-info/server"
+)
+
+const (
+	maxOverrideBodySize = 1 << 20
+	// mmdbWriteTimeout bounds sending the mmdb export, it's much bigger than the responses the server write timeout
+	// is set for
+	mmdbWriteTimeout = 5 * time.Minute
+)
+
+type statusResponse struct {
+	Version string                `json:"version"`
+	Updater *domain.UpdaterStatus `json:"updater"`
+}
+
+func writeJson(w http.ResponseWriter, code int, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+
+	w.Header().Set(contentTypeHeader, jsonContentType)
+	w.WriteHeader(code)
+	if _, err = w.Write(b); err != nil {
+		return fmt.Errorf("failed to write response: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Server) writeAdminError(w http.ResponseWriter, r *http.Request, err error) {
+	s.l.Println(err)
+
+	if err = writeProblemResponse(w, r, getHttpStatus(err), err); err != nil {
+		s.l.Println(err)
+	}
+}
+
+func (s *Server) status() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		if err := writeJson(w, http.StatusOK, &statusResponse{
+			Version: s.appVersion,
+			Updater: s.updater.UpdaterStatus(),
+		}); err != nil {
+			s.l.Println(err)
+		}
+	}
+}
+
+func (s *Server) releaseDiff() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		diff, err := s.admin.ReleaseDiff(r.Context(), r.PathValue("version"))
+		if err != nil {
+			s.writeAdminError(w, r, err)
+
+			return
+		}
+
+		if err = writeJson(w, http.StatusOK, diff); err != nil {
+			s.l.Println(err)
+		}
+	}
+}
+
+func (s *Server) listOverrides() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		overrides, err := s.admin.ListOverrides(r.Context())
+		if err != nil {
+			s.writeAdminError(w, r, err)
+
+			return
+		}
+
+		if err = writeJson(w, http.StatusOK, overrides); err != nil {
+			s.l.Println(err)
+		}
+	}
+}
+
+func (s *Server) getOverride() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := overrideId(r)
+		if err != nil {
+			s.writeAdminError(w, r, err)
+
+			return
+		}
+
+		o, err := s.admin.GetOverride(r.Context(), id)
+		if err != nil {
+			s.writeAdminError(w, r, err)
+
+			return
+		}
+
+		if err = writeJson(w, http.StatusOK, o); err != nil {
+			s.l.Println(err)
+		}
+	}
+}
+
+func (s *Server) createOverride() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		o, err := decodeOverride(w, r)
+		if err != nil {
+			s.writeAdminError(w, r, err)
+
+			return
+		}
+
+		if o, err = s.admin.CreateOverride(r.Context(), o); err != nil {
+			s.writeAdminError(w, r, err)
+
+			return
+		}
+
+		if err = writeJson(w, http.StatusCreated, o); err != nil {
+			s.l.Println(err)
+		}
+	}
+}
+
+func (s *Server) updateOverride() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := overrideId(r)
+		if err != nil {
+			s.writeAdminError(w, r, err)
+
+			return
+		}
+
+		o, err := decodeOverride(w, r)
+		if err != nil {
+			s.writeAdminError(w, r, err)
+
+			return
+		}
+		o.Id = id
+
+		if o, err = s.admin.UpdateOverride(r.Context(), o); err != nil {
+			s.writeAdminError(w, r, err)
+
+			return
+		}
+
+		if err = writeJson(w, http.StatusOK, o); err != nil {
+			s.l.Println(err)
+		}
+	}
+}
+
+func (s *Server) deleteOverride() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := overrideId(r)
+		if err != nil {
+			s.writeAdminError(w, r, err)
+
+			return
+		}
+
+		if err = s.admin.DeleteOverride(r.Context(), id); err != nil {
+			s.writeAdminError(w, r, err)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// overrideId returns the id from the path, ids that aren't numbers can't match any override.
+func overrideId(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", domain.ErrNoOverride, r.PathValue("id"))
+	}
+
+	return id, nil
+}
+
+func decodeOverride(w http.ResponseWriter, r *http.Request) (*domain.Override, error) {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxOverrideBodySize))
+	dec.DisallowUnknownFields()
+
+	o := &domain.Override{}
+	if err := dec.Decode(o); err != nil {
+		return nil, fmt.Errorf("%w: %v", server.ErrWrongOverride, err)
+	}
+
+	return o, nil
+}
+
+// latestMmdb serves the MMDB export of the active city release, the ETag changes with the release and the overrides
+// so the proxies only download it again once it has changed.
+func (s *Server) latestMmdb() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		export, err := s.admin.LatestMmdb(r.Context())
+		if err != nil {
+			s.writeAdminError(w, r, err)
+
+			return
+		}
+
+		// the build may have used up the write timeout, the export is sent within a deadline of its own
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(mmdbWriteTimeout))
+
+		w.Header().Set("ETag", fmt.Sprintf("%q", export.Dataset+"-"+export.Version))
+		w.Header().Set(contentTypeHeader, "application/octet-stream")
+		w.Header().Set("Content-Disposition",
+			fmt.Sprintf("attachment; filename=ip-info-%s-%s.mmdb", export.Dataset, export.Version),
+		)
+		http.ServeContent(w, r, "latest.mmdb", export.BuiltAt, bytes.NewReader(export.Data))
+	}
+}
@@ -4,11 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
-	"github.com/streamdp/ip-info/database"
 	"github.com/streamdp/ip-info/domain"
 	"github.com/streamdp/ip-info/pkg/iplocator"
 	"github.com/streamdp/ip-info/server"
+	v1 "github.com/streamdp/ip-info/server/grpc/api/v1"
 )
 
 func writeJsonResponse(w http.ResponseWriter, code int, response *domain.Response) error {
@@ -21,6 +22,18 @@ func writeJsonResponse(w http.ResponseWriter, code int, response *domain.Respons
 	return nil
 }
 
+func writeProblemResponse(w http.ResponseWriter, r *http.Request, status int, err error) error {
+	code, message := server.PublicError(err)
+
+	w.Header().Set(contentTypeHeader, domain.ProblemContentType)
+	w.WriteHeader(status)
+	if _, errWrite := w.Write(domain.NewProblem(status, code, message, r.URL.Path).Bytes()); errWrite != nil {
+		return fmt.Errorf("failed to write response: %w", errWrite)
+	}
+
+	return nil
+}
+
 func (s *Server) ipInfo(useClientIp bool) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ipString := r.URL.Query().Get("ip")
@@ -28,7 +41,18 @@ func (s *Server) ipInfo(useClientIp bool) func(http.ResponseWriter, *http.Reques
 			ipString = httpClientIp(r)
 		}
 
-		ipInfo, err := s.locator.GetIpInfo(r.Context(), ipString)
+		opts, err := server.NewLookupOptions(
+			r.URL.Query().Get("expand"), httpLanguage(r), r.URL.Query().Get("as_of"),
+		)
+		if err != nil {
+			if err = writeJsonResponse(w, getHttpStatus(err), domain.NewResponse(err, nil)); err != nil {
+				s.l.Println(err)
+			}
+
+			return
+		}
+
+		ipInfo, err := s.locator.GetIpInfo(r.Context(), ipString, opts)
 		if err != nil {
 			s.l.Println(err)
 		}
@@ -58,6 +82,16 @@ func (s *Server) version() func(http.ResponseWriter, *http.Request) {
 	}
 }
 
+func (s *Server) openApi() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentTypeHeader, jsonContentType)
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(v1.OpenApiSpec); err != nil {
+			s.l.Println(err)
+		}
+	}
+}
+
 func getHttpStatus(err error) int {
 	if err == nil {
 		return http.StatusOK
@@ -68,16 +102,38 @@ func getHttpStatus(err error) int {
 	if errors.Is(err, server.ErrRateLimitExceeded) {
 		return http.StatusTooManyRequests
 	}
-	if errors.Is(err, server.ErrWrongIpAddress) {
+	if errors.Is(err, server.ErrUnauthorized) {
+		return http.StatusUnauthorized
+	}
+	if errors.Is(err, server.ErrWrongIpAddress) || errors.Is(err, server.ErrWrongNetwork) ||
+		errors.Is(err, server.ErrWrongPageToken) || errors.Is(err, server.ErrWrongExpand) ||
+		errors.Is(err, server.ErrWrongAsOf) || errors.Is(err, server.ErrWrongOverride) {
 		return http.StatusBadRequest
 	}
-	if errors.Is(err, database.ErrNoIpAddress) {
+	if errors.Is(err, domain.ErrNoIpAddress) || errors.Is(err, domain.ErrNoRelease) ||
+		errors.Is(err, domain.ErrNoReleaseDiff) || errors.Is(err, domain.ErrNoOverride) {
 		return http.StatusNotFound
 	}
+	if errors.Is(err, domain.ErrOverrideExists) {
+		return http.StatusConflict
+	}
 
 	return http.StatusInternalServerError
 }
 
+func isVersionedPath(path string) bool {
+	return strings.HasPrefix(path, "/v1/")
+}
+
+// httpLanguage returns the language requested in the lang query parameter or in the Accept-Language header.
+func httpLanguage(r *http.Request) string {
+	if lang := r.URL.Query().Get(iplocator.Lang); lang != "" {
+		return lang
+	}
+
+	return r.Header.Get(iplocator.AcceptLanguage)
+}
+
 func httpClientIp(r *http.Request) string {
 	if ip := r.Header.Get(iplocator.XRealIp); ip != "" {
 		return ip
@@ -1,18 +1,21 @@
 package rest
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"io"
 	"log"
-	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
-	"github.com/streamdp/ip-info/database"
 	"github.com/streamdp/ip-info/domain"
 	"github.com/streamdp/ip-info/pkg/iplocator"
 	"github.com/streamdp/ip-info/server"
@@ -20,6 +23,8 @@ import (
 
 var errCommon = errors.New("some_error")
 
+const testAdminToken = "s3cr3t"
+
 func Test_httpClientIp(t *testing.T) {
 	createRequestWithHeader := func(header string, value string) *http.Request {
 		r := &http.Request{
@@ -81,6 +86,45 @@ func Test_httpClientIp(t *testing.T) {
 	}
 }
 
+func Test_httpLanguage(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		accept string
+		want   string
+	}{
+		{
+			name:   "language from the query",
+			url:    "/v1/ip-info?lang=de",
+			accept: "uk",
+			want:   "de",
+		},
+		{
+			name:   "language from the accept-language header",
+			url:    "/v1/ip-info",
+			accept: "uk, en;q=0.5",
+			want:   "uk, en;q=0.5",
+		},
+		{
+			name: "no language",
+			url:  "/v1/ip-info",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept-Language", tt.accept)
+			}
+
+			if got := httpLanguage(r); got != tt.want {
+				t.Errorf("httpLanguage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_getHttpStatus(t *testing.T) {
 	tests := []struct {
 		name string
@@ -102,9 +146,19 @@ func Test_getHttpStatus(t *testing.T) {
 			err:  server.ErrWrongIpAddress,
 			want: http.StatusBadRequest,
 		},
+		{
+			name: "get http.StatusBadRequest on wrong as_of date",
+			err:  server.ErrWrongAsOf,
+			want: http.StatusBadRequest,
+		},
 		{
 			name: "get http.StatusNotFound",
-			err:  database.ErrNoIpAddress,
+			err:  domain.ErrNoIpAddress,
+			want: http.StatusNotFound,
+		},
+		{
+			name: "get http.StatusNotFound on missing release",
+			err:  domain.ErrNoRelease,
 			want: http.StatusNotFound,
 		},
 		{
@@ -153,6 +207,8 @@ func TestServer_ipInfo(t *testing.T) {
 	tests := []struct {
 		name           string
 		ip             string
+		expand         string
+		asOf           string
 		locator        server.Locator
 		useClientIp    bool
 		wantStatusCode int
@@ -168,14 +224,46 @@ func TestServer_ipInfo(t *testing.T) {
 		{
 			name:           "get ip info",
 			ip:             "8.8.8.8",
-			locator:        &mockLocator{ipInfo: &domain.IpInfo{Ip: net.ParseIP("8.8.8.8")}},
+			locator:        &mockLocator{ipInfo: &domain.IpInfo{Ip: netip.MustParseAddr("8.8.8.8")}},
+			wantStatusCode: http.StatusOK,
+			wantError:      nil,
+		},
+		{
+			name:           "get ip info with expanded fields",
+			ip:             "8.8.8.8",
+			expand:         "country",
+			locator:        &mockLocator{ipInfo: &domain.IpInfo{Ip: netip.MustParseAddr("8.8.8.8")}},
+			wantStatusCode: http.StatusOK,
+			wantError:      nil,
+		},
+		{
+			name:           "unknown expand field",
+			ip:             "8.8.8.8",
+			expand:         "weather",
+			locator:        &mockLocator{ipInfo: &domain.IpInfo{Ip: netip.MustParseAddr("8.8.8.8")}},
+			wantStatusCode: http.StatusBadRequest,
+			wantError:      server.ErrWrongExpand,
+		},
+		{
+			name:           "get ip info as of a date",
+			ip:             "8.8.8.8",
+			asOf:           "2024-06-15",
+			locator:        &mockLocator{ipInfo: &domain.IpInfo{Ip: netip.MustParseAddr("8.8.8.8")}},
 			wantStatusCode: http.StatusOK,
 			wantError:      nil,
 		},
+		{
+			name:           "wrong as_of date",
+			ip:             "8.8.8.8",
+			asOf:           "15.06.2024",
+			locator:        &mockLocator{ipInfo: &domain.IpInfo{Ip: netip.MustParseAddr("8.8.8.8")}},
+			wantStatusCode: http.StatusBadRequest,
+			wantError:      server.ErrWrongAsOf,
+		},
 		{
 			name:           "get client ip info",
 			ip:             "127.0.0.1",
-			locator:        &mockLocator{ipInfo: &domain.IpInfo{Ip: net.ParseIP("127.0.0.1")}},
+			locator:        &mockLocator{ipInfo: &domain.IpInfo{Ip: netip.MustParseAddr("127.0.0.1")}},
 			useClientIp:    true,
 			wantStatusCode: http.StatusOK,
 			wantError:      nil,
@@ -199,7 +287,7 @@ func TestServer_ipInfo(t *testing.T) {
 				r = httptest.NewRequest(http.MethodGet, "/client-ip", nil)
 				r.Header.Set(iplocator.XRealIp, tt.ip)
 			} else {
-				r = httptest.NewRequest(http.MethodGet, "/ip-info?ip="+tt.ip, nil)
+				r = httptest.NewRequest(http.MethodGet, "/ip-info?ip="+tt.ip+"&expand="+tt.expand+"&as_of="+tt.asOf, nil)
 			}
 
 			handler(w, r)
@@ -298,15 +386,580 @@ func TestServer_version(t *testing.T) {
 	}
 }
 
+func TestServer_metrics(t *testing.T) {
+	handler := (&Server{pools: &poolStaterMock{stats: []*domain.PoolStats{
+		{
+			DBStats: sql.DBStats{MaxOpenConnections: 25, OpenConnections: 3, InUse: 1, Idle: 2},
+			Pool:    "primary",
+			Healthy: true,
+		},
+		{DBStats: sql.DBStats{WaitDuration: 1500 * time.Millisecond}, Pool: "replica_0", Healthy: false},
+	}}}).metrics()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+
+	handler(w, r)
+
+	res := w.Result()
+	t.Cleanup(func() { _ = res.Body.Close() })
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("metrics() = %d, want %d", res.StatusCode, http.StatusOK)
+	}
+	if got := res.Header.Get(contentTypeHeader); got != metricsContentType {
+		t.Errorf("metrics() content type = %s, want %s", got, metricsContentType)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("read body: expected no error, got: %v", err)
+	}
+
+	for _, line := range []string{
+		"# TYPE ip_info_db_pool_open_connections gauge",
+		`ip_info_db_pool_max_open_connections{pool="primary"} 25`,
+		`ip_info_db_pool_open_connections{pool="primary"} 3`,
+		`ip_info_db_pool_in_use_connections{pool="primary"} 1`,
+		`ip_info_db_pool_idle_connections{pool="primary"} 2`,
+		`ip_info_db_pool_wait_duration_seconds_total{pool="replica_0"} 1.5`,
+		`ip_info_db_pool_healthy{pool="primary"} 1`,
+		`ip_info_db_pool_healthy{pool="replica_0"} 0`,
+	} {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Errorf("metrics() body doesn't contain %q", line)
+		}
+	}
+}
+
+func TestServer_status(t *testing.T) {
+	nextRun := time.Date(2026, 11, 2, 0, 3, 17, 0, time.UTC)
+	handler := (&Server{
+		l:          log.New(io.Discard, "", log.LstdFlags),
+		appVersion: "1.2.3",
+		updater: &updaterStaterMock{status: &domain.UpdaterStatus{
+			Mode:     domain.UpdateModeImport,
+			Schedule: "0 0 2 * *",
+			NextRun:  nextRun,
+		}},
+	}).status()
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/admin/status", nil))
+
+	res := w.Result()
+	t.Cleanup(func() { _ = res.Body.Close() })
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("status() = %d, want %d", res.StatusCode, http.StatusOK)
+	}
+
+	got := &statusResponse{}
+	if err := json.NewDecoder(res.Body).Decode(got); err != nil {
+		t.Fatalf("decode body: expected no error, got: %v", err)
+	}
+	if got.Version != "1.2.3" || got.Updater == nil || !got.Updater.NextRun.Equal(nextRun) ||
+		got.Updater.Mode != domain.UpdateModeImport {
+		t.Errorf("status() = %+v, want version 1.2.3 and the next run at %v", got, nextRun)
+	}
+}
+
+func TestServer_initRouter_updaterRole(t *testing.T) {
+	router := (&Server{
+		l:       log.New(io.Discard, "", log.LstdFlags),
+		updater: &updaterStaterMock{status: &domain.UpdaterStatus{Mode: domain.UpdateModeImport}},
+	}).WithAdminToken(testAdminToken).initRouter()
+
+	tests := []struct {
+		path       string
+		token      string
+		wantStatus int
+	}{
+		{path: "/healthz", wantStatus: http.StatusOK},
+		{path: "/admin/status", token: testAdminToken, wantStatus: http.StatusOK},
+		{path: "/admin/status", wantStatus: http.StatusUnauthorized},
+		{path: "/ip-info?ip=8.8.8.8", wantStatus: http.StatusNotFound},
+		{path: "/client-ip", wantStatus: http.StatusNotFound},
+		{path: "/v1/ip-info?ip=8.8.8.8", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			r.Header.Set(contentTypeHeader, textPlainContentType)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			router.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("initRouter() %s = %d, want %d", tt.path, w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestServer_initRouter_adminWithoutToken(t *testing.T) {
+	router := (&Server{l: log.New(io.Discard, "", log.LstdFlags)}).WithAdmin(&adminMock{}).
+		WithUpdater(&updaterStaterMock{status: &domain.UpdaterStatus{}}).initRouter()
+
+	for _, r := range []*http.Request{
+		httptest.NewRequest(http.MethodDelete, "/admin/overrides/1", nil),
+		httptest.NewRequest(http.MethodGet, "/admin/status", nil),
+	} {
+		w := httptest.NewRecorder()
+		r.Header.Set("Authorization", "Bearer ")
+		router.ServeHTTP(w, r)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("initRouter() %s %s = %d, want %d", r.Method, r.URL.Path, w.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestServer_releaseDiff(t *testing.T) {
+	diff := &domain.ReleaseDiff{
+		Dataset:        "city",
+		Version:        "2024-09",
+		PreviousRanges: 100,
+		Added:          2,
+		Countries:      []*domain.CountryDiff{{Country: "NZ", Added: 2}},
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		admin          server.Admin
+		wantStatusCode int
+		wantCode       string
+	}{
+		{
+			name:           "get release diff",
+			path:           "/admin/releases/2024-09/diff",
+			admin:          &adminMock{diff: diff},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "no diff report",
+			path:           "/admin/releases/2024-08/diff",
+			admin:          &adminMock{diff: diff},
+			wantStatusCode: http.StatusNotFound,
+			wantCode:       server.CodeReleaseNotFound,
+		},
+		{
+			name:           "database error",
+			path:           "/admin/releases/2024-09/diff",
+			admin:          &adminMock{err: errCommon},
+			wantStatusCode: http.StatusInternalServerError,
+			wantCode:       server.CodeInternal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{l: log.New(io.Discard, "", log.LstdFlags)}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			r.Header.Set("Authorization", "Bearer "+testAdminToken)
+
+			s.WithAdmin(tt.admin).WithAdminToken(testAdminToken).initRouter().ServeHTTP(w, r)
+
+			res := w.Result()
+			t.Cleanup(func() { _ = res.Body.Close() })
+
+			if res.StatusCode != tt.wantStatusCode {
+				t.Errorf("releaseDiff() = %d, want %d", res.StatusCode, tt.wantStatusCode)
+			}
+
+			if tt.wantCode != "" {
+				problem := domain.Problem{}
+				if err := json.NewDecoder(res.Body).Decode(&problem); err != nil {
+					t.Fatalf("decode body: expected no error, got: %v", err)
+				}
+				if problem.Code != tt.wantCode {
+					t.Errorf("releaseDiff() code = %s, want %s", problem.Code, tt.wantCode)
+				}
+
+				return
+			}
+
+			got := &domain.ReleaseDiff{}
+			if err := json.NewDecoder(res.Body).Decode(got); err != nil {
+				t.Fatalf("decode body: expected no error, got: %v", err)
+			}
+			if !reflect.DeepEqual(got, diff) {
+				t.Errorf("releaseDiff() = %+v, want %+v", got, diff)
+			}
+		})
+	}
+}
+
+func TestServer_latestMmdb(t *testing.T) {
+	export := &domain.MmdbExport{
+		Dataset: "city",
+		Version: "2026-10",
+		Ranges:  1,
+		BuiltAt: time.Date(2026, 10, 19, 3, 4, 12, 0, time.UTC),
+		Data:    []byte("\xab\xcd\xefMaxMind.com"),
+	}
+
+	tests := []struct {
+		name           string
+		ifNoneMatch    string
+		admin          server.Admin
+		wantStatusCode int
+		wantCode       string
+	}{
+		{
+			name:           "latest export",
+			admin:          &adminMock{mmdb: export},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "unchanged export",
+			ifNoneMatch:    `"city-2026-10"`,
+			admin:          &adminMock{mmdb: export},
+			wantStatusCode: http.StatusNotModified,
+		},
+		{
+			name:           "changed export",
+			ifNoneMatch:    `"city-2026-09"`,
+			admin:          &adminMock{mmdb: export},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "no active release",
+			admin:          &adminMock{},
+			wantStatusCode: http.StatusNotFound,
+			wantCode:       server.CodeReleaseNotFound,
+		},
+		{
+			name:           "database error",
+			admin:          &adminMock{err: errCommon},
+			wantStatusCode: http.StatusInternalServerError,
+			wantCode:       server.CodeInternal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{l: log.New(io.Discard, "", log.LstdFlags)}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/admin/export/latest.mmdb", nil)
+			if tt.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			r.Header.Set("Authorization", "Bearer "+testAdminToken)
+
+			s.WithAdmin(tt.admin).WithAdminToken(testAdminToken).initRouter().ServeHTTP(w, r)
+
+			res := w.Result()
+			t.Cleanup(func() { _ = res.Body.Close() })
+
+			if res.StatusCode != tt.wantStatusCode {
+				t.Fatalf("latestMmdb() = %d, want %d", res.StatusCode, tt.wantStatusCode)
+			}
+
+			if tt.wantCode != "" {
+				problem := domain.Problem{}
+				if err := json.NewDecoder(res.Body).Decode(&problem); err != nil {
+					t.Fatalf("decode body: expected no error, got: %v", err)
+				}
+				if problem.Code != tt.wantCode {
+					t.Errorf("latestMmdb() code = %s, want %s", problem.Code, tt.wantCode)
+				}
+
+				return
+			}
+
+			if got := res.Header.Get("ETag"); got != `"city-2026-10"` {
+				t.Errorf("latestMmdb() ETag = %s, want %s", got, `"city-2026-10"`)
+			}
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("read body: expected no error, got: %v", err)
+			}
+			if tt.wantStatusCode == http.StatusOK && !bytes.Equal(body, export.Data) {
+				t.Errorf("latestMmdb() body = %q, want %q", body, export.Data)
+			}
+		})
+	}
+}
+
+func TestServer_overrides(t *testing.T) {
+	city := "Auckland"
+	overrides := []*domain.Override{{Id: 1, Network: netip.MustParsePrefix("203.0.113.0/24"), City: &city}}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		admin          server.Admin
+		wantStatusCode int
+		wantCode       string
+	}{
+		{
+			name:           "list overrides",
+			method:         http.MethodGet,
+			path:           "/admin/overrides",
+			admin:          &adminMock{overrides: overrides},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "get override",
+			method:         http.MethodGet,
+			path:           "/admin/overrides/1",
+			admin:          &adminMock{overrides: overrides},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "get unknown override",
+			method:         http.MethodGet,
+			path:           "/admin/overrides/2",
+			admin:          &adminMock{overrides: overrides},
+			wantStatusCode: http.StatusNotFound,
+			wantCode:       server.CodeOverrideNotFound,
+		},
+		{
+			name:           "wrong override id",
+			method:         http.MethodGet,
+			path:           "/admin/overrides/one",
+			admin:          &adminMock{overrides: overrides},
+			wantStatusCode: http.StatusNotFound,
+			wantCode:       server.CodeOverrideNotFound,
+		},
+		{
+			name:           "create override",
+			method:         http.MethodPost,
+			path:           "/admin/overrides",
+			body:           `{"network": "198.51.100.0/24", "country": "NZ"}`,
+			admin:          &adminMock{overrides: overrides},
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name:           "create override with unknown field",
+			method:         http.MethodPost,
+			path:           "/admin/overrides",
+			body:           `{"network": "198.51.100.0/24", "town": "Auckland"}`,
+			admin:          &adminMock{overrides: overrides},
+			wantStatusCode: http.StatusBadRequest,
+			wantCode:       server.CodeInvalidOverride,
+		},
+		{
+			name:           "create override with wrong network",
+			method:         http.MethodPost,
+			path:           "/admin/overrides",
+			body:           `{"network": "198.51.100.0/33", "country": "NZ"}`,
+			admin:          &adminMock{overrides: overrides},
+			wantStatusCode: http.StatusBadRequest,
+			wantCode:       server.CodeInvalidOverride,
+		},
+		{
+			name:           "create existing override",
+			method:         http.MethodPost,
+			path:           "/admin/overrides",
+			body:           `{"network": "203.0.113.0/24", "country": "NZ"}`,
+			admin:          &adminMock{err: domain.ErrOverrideExists},
+			wantStatusCode: http.StatusConflict,
+			wantCode:       server.CodeOverrideExists,
+		},
+		{
+			name:           "update override",
+			method:         http.MethodPut,
+			path:           "/admin/overrides/1",
+			body:           `{"network": "203.0.113.0/24", "city": "Wellington"}`,
+			admin:          &adminMock{overrides: overrides},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "delete override",
+			method:         http.MethodDelete,
+			path:           "/admin/overrides/1",
+			admin:          &adminMock{overrides: overrides},
+			wantStatusCode: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{l: log.New(io.Discard, "", log.LstdFlags)}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			r.Header.Set("Authorization", "Bearer "+testAdminToken)
+
+			s.WithAdmin(tt.admin).WithAdminToken(testAdminToken).initRouter().ServeHTTP(w, r)
+
+			res := w.Result()
+			t.Cleanup(func() { _ = res.Body.Close() })
+
+			if res.StatusCode != tt.wantStatusCode {
+				t.Errorf("overrides() = %d, want %d", res.StatusCode, tt.wantStatusCode)
+			}
+
+			if tt.wantCode != "" {
+				problem := domain.Problem{}
+				if err := json.NewDecoder(res.Body).Decode(&problem); err != nil {
+					t.Fatalf("decode body: expected no error, got: %v", err)
+				}
+				if problem.Code != tt.wantCode {
+					t.Errorf("overrides() code = %s, want %s", problem.Code, tt.wantCode)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_openApi(t *testing.T) {
+	handler := (&Server{}).openApi()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+
+	handler(w, r)
+
+	res := w.Result()
+	t.Cleanup(func() { _ = res.Body.Close() })
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("openApi() = %d, want %d", res.StatusCode, http.StatusOK)
+	}
+
+	spec := struct {
+		Paths map[string]any `json:"paths"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&spec); err != nil {
+		t.Fatalf("decode body: expected no error, got: %v", err)
+	}
+
+	for _, path := range []string{"/v1/ip-info", "/v1/client-ip", "/v1/app/version"} {
+		if _, ok := spec.Paths[path]; !ok {
+			t.Errorf("openApi() spec doesn't describe %s", path)
+		}
+	}
+}
+
+type adminMock struct {
+	diff      *domain.ReleaseDiff
+	overrides []*domain.Override
+	mmdb      *domain.MmdbExport
+	err       error
+}
+
+func (a *adminMock) ReleaseDiff(_ context.Context, version string) (*domain.ReleaseDiff, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	if a.diff.Version != version {
+		return nil, domain.ErrNoReleaseDiff
+	}
+
+	return a.diff, nil
+}
+
+func (a *adminMock) ListOverrides(_ context.Context) ([]*domain.Override, error) {
+	return a.overrides, a.err
+}
+
+func (a *adminMock) GetOverride(_ context.Context, id int64) (*domain.Override, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	for _, o := range a.overrides {
+		if o.Id == id {
+			return o, nil
+		}
+	}
+
+	return nil, domain.ErrNoOverride
+}
+
+func (a *adminMock) CreateOverride(_ context.Context, o *domain.Override) (*domain.Override, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	o.Id = int64(len(a.overrides) + 1)
+
+	return o, nil
+}
+
+func (a *adminMock) UpdateOverride(ctx context.Context, o *domain.Override) (*domain.Override, error) {
+	if _, err := a.GetOverride(ctx, o.Id); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+func (a *adminMock) DeleteOverride(ctx context.Context, id int64) error {
+	_, err := a.GetOverride(ctx, id)
+
+	return err
+}
+
+func (a *adminMock) LatestMmdb(_ context.Context) (*domain.MmdbExport, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	if a.mmdb == nil {
+		return nil, domain.ErrNoRelease
+	}
+
+	return a.mmdb, nil
+}
+
+type poolStaterMock struct {
+	stats []*domain.PoolStats
+}
+
+func (p *poolStaterMock) PoolStats() []*domain.PoolStats {
+	return p.stats
+}
+
+type updaterStaterMock struct {
+	status *domain.UpdaterStatus
+}
+
+func (u *updaterStaterMock) UpdaterStatus() *domain.UpdaterStatus {
+	return u.status
+}
+
 type mockLocator struct {
-	ipInfo *domain.IpInfo
-	err    error
+	ipInfo      *domain.IpInfo
+	networkInfo *domain.NetworkInfo
+	releases    []*domain.Release
+	providers   []*domain.Provider
+	err         error
 }
 
-func (ml *mockLocator) GetIpInfo(_ context.Context, _ string) (*domain.IpInfo, error) {
+func (ml *mockLocator) GetIpInfo(_ context.Context, _ string, _ *domain.LookupOptions) (*domain.IpInfo, error) {
 	if ml.err != nil {
 		return nil, ml.err
 	}
 
 	return ml.ipInfo, nil
 }
+
+func (ml *mockLocator) GetNetworkInfo(_ context.Context, _ string, _ int, _ string) (*domain.NetworkInfo, error) {
+	if ml.err != nil {
+		return nil, ml.err
+	}
+
+	return ml.networkInfo, nil
+}
+
+func (ml *mockLocator) GetReleases(_ context.Context) ([]*domain.Release, error) {
+	if ml.err != nil {
+		return nil, ml.err
+	}
+
+	return ml.releases, nil
+}
+
+func (ml *mockLocator) GetProviders(_ context.Context) ([]*domain.Provider, error) {
+	if ml.err != nil {
+		return nil, ml.err
+	}
+
+	return ml.providers, nil
+}
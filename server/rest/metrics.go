@@ -0,0 +1,109 @@
+package rest
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/streamdp/ip-info/domain"
+)
+
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type poolMetric struct {
+	name  string
+	help  string
+	kind  string
+	value func(p *domain.PoolStats) float64
+}
+
+var poolMetrics = []poolMetric{
+	{
+		name:  "ip_info_db_pool_max_open_connections",
+		help:  "Maximum number of open connections to the database.",
+		kind:  "gauge",
+		value: func(p *domain.PoolStats) float64 { return float64(p.MaxOpenConnections) },
+	},
+	{
+		name:  "ip_info_db_pool_open_connections",
+		help:  "The number of established connections both in use and idle.",
+		kind:  "gauge",
+		value: func(p *domain.PoolStats) float64 { return float64(p.OpenConnections) },
+	},
+	{
+		name:  "ip_info_db_pool_in_use_connections",
+		help:  "The number of connections currently in use.",
+		kind:  "gauge",
+		value: func(p *domain.PoolStats) float64 { return float64(p.InUse) },
+	},
+	{
+		name:  "ip_info_db_pool_idle_connections",
+		help:  "The number of idle connections.",
+		kind:  "gauge",
+		value: func(p *domain.PoolStats) float64 { return float64(p.Idle) },
+	},
+	{
+		name:  "ip_info_db_pool_wait_count_total",
+		help:  "The total number of connections waited for.",
+		kind:  "counter",
+		value: func(p *domain.PoolStats) float64 { return float64(p.WaitCount) },
+	},
+	{
+		name:  "ip_info_db_pool_wait_duration_seconds_total",
+		help:  "The total time blocked waiting for a new connection.",
+		kind:  "counter",
+		value: func(p *domain.PoolStats) float64 { return p.WaitDuration.Seconds() },
+	},
+	{
+		name:  "ip_info_db_pool_max_idle_closed_total",
+		help:  "The total number of connections closed due to the max idle connections limit.",
+		kind:  "counter",
+		value: func(p *domain.PoolStats) float64 { return float64(p.MaxIdleClosed) },
+	},
+	{
+		name:  "ip_info_db_pool_max_idle_time_closed_total",
+		help:  "The total number of connections closed due to the max idle time.",
+		kind:  "counter",
+		value: func(p *domain.PoolStats) float64 { return float64(p.MaxIdleTimeClosed) },
+	},
+	{
+		name:  "ip_info_db_pool_max_lifetime_closed_total",
+		help:  "The total number of connections closed due to the max connection lifetime.",
+		kind:  "counter",
+		value: func(p *domain.PoolStats) float64 { return float64(p.MaxLifetimeClosed) },
+	},
+	{
+		name: "ip_info_db_pool_healthy",
+		help: "Whether lookups are sent to the pool, unhealthy replicas fall back to the primary.",
+		kind: "gauge",
+		value: func(p *domain.PoolStats) float64 {
+			if p.Healthy {
+				return 1
+			}
+
+			return 0
+		},
+	},
+}
+
+func (s *Server) metrics() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentTypeHeader, metricsContentType)
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(writePoolMetrics(s.pools.PoolStats())); err != nil {
+			s.l.Println(err)
+		}
+	}
+}
+
+func writePoolMetrics(pools []*domain.PoolStats) []byte {
+	var b bytes.Buffer
+	for _, m := range poolMetrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+		for _, p := range pools {
+			fmt.Fprintf(&b, "%s{pool=%q} %g\n", m.name, p.Pool, m.value(p))
+		}
+	}
+
+	return b.Bytes()
+}
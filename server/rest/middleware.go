@@ -1,19 +1,28 @@
 package rest
 
 import (
+	"crypto/subtle"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"slices"
+	"strings"
 
 	"github.com/streamdp/ip-info/domain"
+	"github.com/streamdp/ip-info/pkg/grpcweb"
 	"github.com/streamdp/ip-info/server"
 )
 
 func rateLimiterMW(limiter server.Limiter, l *log.Logger, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if err := limiter.Limit(r.Context(), httpClientIp(r)); err != nil {
-			if err = writeJsonResponse(w, getHttpStatus(err), domain.NewResponse(err, nil)); err != nil {
+			if isVersionedPath(r.URL.Path) {
+				err = writeProblemResponse(w, r, getHttpStatus(err), err)
+			} else {
+				err = writeJsonResponse(w, getHttpStatus(err), domain.NewResponse(err, nil))
+			}
+			if err != nil {
 				l.Println(err)
 			}
 
@@ -24,6 +33,50 @@ func rateLimiterMW(limiter server.Limiter, l *log.Logger, next http.Handler) htt
 	})
 }
 
+// grpcMW routes the gRPC and gRPC-Web calls to the gRPC server, they are rate limited by its interceptor rather than
+// by rateLimiterMW.
+func grpcMW(grpc http.Handler, allowedOrigins []string, next http.Handler) http.Handler {
+	grpcWeb := grpcweb.New(grpc, allowedOrigins)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case grpcweb.IsGrpcWebRequest(r):
+			grpcWeb.ServeHTTP(w, r)
+		case r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get(contentTypeHeader), grpcContentType):
+			grpc.ServeHTTP(w, r)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// adminAuthMW lets through the requests bearing the admin token in the Authorization header.
+func adminAuthMW(l *log.Logger, token string, f http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1 {
+			f.ServeHTTP(w, r)
+
+			return
+		}
+
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		if err := writeProblemResponse(w, r, getHttpStatus(server.ErrUnauthorized), server.ErrUnauthorized); err != nil {
+			l.Println(err)
+		}
+	}
+}
+
+// deprecationMW marks legacy unversioned routes as deprecated and points clients to their /v1 successor.
+func deprecationMW(successor string, f http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", fmt.Sprintf("@%d", legacyRoutesDeprecatedAt.Unix()))
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+
+		f.ServeHTTP(w, r)
+	}
+}
+
 var errWrongContentType = errors.New("content type not implemented")
 
 func contentTypeRestrictionMW(l *log.Logger, f http.HandlerFunc, allowedTypes ...string) http.HandlerFunc {
@@ -22,6 +22,7 @@ func Test_rateLimiterMW(t *testing.T) {
 		limiter        server.Limiter
 		wantStatusCode int
 		wantError      bool
+		wantProblem    bool
 	}{
 		{
 			name:           "client has reached its limits",
@@ -30,6 +31,14 @@ func Test_rateLimiterMW(t *testing.T) {
 			wantStatusCode: http.StatusTooManyRequests,
 			wantError:      true,
 		},
+		{
+			name:           "client has reached its limits on versioned route",
+			request:        httptest.NewRequest(http.MethodGet, "/v1/client-ip", nil),
+			limiter:        &mockLimiter{err: server.ErrRateLimitExceeded},
+			wantStatusCode: http.StatusTooManyRequests,
+			wantError:      true,
+			wantProblem:    true,
+		},
 		{
 			name:           "client not limited",
 			request:        httptest.NewRequest(http.MethodGet, "/ip-info", nil),
@@ -59,6 +68,24 @@ func Test_rateLimiterMW(t *testing.T) {
 				t.Errorf("rateLimiterMW() = %d, want %d", res.StatusCode, tt.wantStatusCode)
 			}
 
+			if tt.wantProblem {
+				problem := domain.Problem{}
+				if err := json.NewDecoder(res.Body).Decode(&problem); err != nil {
+					t.Fatalf("decode body: expected no error, got: %v", err)
+				}
+
+				if res.Header.Get(contentTypeHeader) != domain.ProblemContentType {
+					t.Errorf("rateLimiterMW() content type = %s, want %s",
+						res.Header.Get(contentTypeHeader), domain.ProblemContentType,
+					)
+				}
+				if problem.Code != server.CodeRateLimitExceeded {
+					t.Errorf("rateLimiterMW() code = %s, want %s", problem.Code, server.CodeRateLimitExceeded)
+				}
+
+				return
+			}
+
 			if tt.wantError {
 				body, err := io.ReadAll(res.Body)
 				if err != nil {
@@ -76,6 +103,57 @@ func Test_rateLimiterMW(t *testing.T) {
 	}
 }
 
+func Test_adminAuthMW(t *testing.T) {
+	mw := adminAuthMW(log.New(io.Discard, "", log.LstdFlags), testAdminToken,
+		func(w http.ResponseWriter, r *http.Request) {},
+	)
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{name: "bearer token", authorization: "Bearer " + testAdminToken, want: http.StatusOK},
+		{name: "no token", want: http.StatusUnauthorized},
+		{name: "wrong token", authorization: "Bearer secret", want: http.StatusUnauthorized},
+		{name: "basic auth", authorization: "Basic " + testAdminToken, want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodDelete, "/admin/overrides/1", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			mw.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Errorf("adminAuthMW() = %d, want %d", w.Code, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Errorf("adminAuthMW() WWW-Authenticate = %q, want Bearer", w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func Test_deprecationMW(t *testing.T) {
+	mw := deprecationMW("/v1/ip-info", func(w http.ResponseWriter, r *http.Request) {})
+
+	w := httptest.NewRecorder()
+	mw.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ip-info", nil))
+
+	res := w.Result()
+	t.Cleanup(func() { _ = res.Body.Close() })
+
+	if got := res.Header.Get("Deprecation"); got != "@1792368000" {
+		t.Errorf("deprecationMW() Deprecation = %s, want @1792368000", got)
+	}
+	if got := res.Header.Get("Link"); got != "</v1/ip-info>; rel=\"successor-version\"" {
+		t.Errorf("deprecationMW() Link = %s", got)
+	}
+}
+
 func Test_contentTypeRestrictionMW(t *testing.T) {
 	t.Parallel()
 
@@ -143,6 +221,63 @@ func Test_contentTypeRestrictionMW(t *testing.T) {
 	}
 }
 
+func Test_grpcMW(t *testing.T) {
+	t.Parallel()
+
+	newRequest := func(protoMajor int, contentType string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/IpInfo/GetIpInfo", nil)
+		r.ProtoMajor = protoMajor
+		r.Header.Set(contentTypeHeader, contentType)
+
+		return r
+	}
+
+	tests := []struct {
+		name        string
+		request     *http.Request
+		wantHandler string
+	}{
+		{
+			name:        "grpc request",
+			request:     newRequest(2, "application/grpc"),
+			wantHandler: "grpc",
+		},
+		{
+			name:        "grpc-web request",
+			request:     newRequest(1, "application/grpc-web+proto"),
+			wantHandler: "grpc",
+		},
+		{
+			name:        "grpc content type over http/1.1",
+			request:     newRequest(1, "application/grpc"),
+			wantHandler: "rest",
+		},
+		{
+			name:        "rest request",
+			request:     httptest.NewRequest(http.MethodGet, "/ip-info", nil),
+			wantHandler: "rest",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got string
+			mw := grpcMW(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { got = "grpc" }),
+				nil,
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { got = "rest" }),
+			)
+
+			mw.ServeHTTP(httptest.NewRecorder(), tt.request.WithContext(t.Context()))
+
+			if got != tt.wantHandler {
+				t.Errorf("grpcMW() routed to %s, want %s", got, tt.wantHandler)
+			}
+		})
+	}
+}
+
 func Test_isAllowedContentType(t *testing.T) {
 	type args struct {
 		c            string
@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/streamdp/ip-info/config"
 	"github.com/streamdp/ip-info/server"
@@ -15,16 +16,28 @@ import (
 const (
 	jsonContentType      = "application/json"
 	textPlainContentType = "text/plain"
+	grpcContentType      = "application/grpc"
 
 	contentTypeHeader = "Content-Type"
 )
 
+// legacyRoutesDeprecatedAt is announced in the Deprecation header of the unversioned routes, they are kept
+// working until clients migrate to the /v1 API.
+var legacyRoutesDeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
 type Server struct {
 	srv     *http.Server
 	locator server.Locator
 	limiter server.Limiter
 	cfg     *config.Http
 	l       *log.Logger
+	grpc    http.Handler
+	gateway http.Handler
+	pools   server.PoolStater
+	admin   server.Admin
+	updater server.UpdaterStater
+
+	adminToken string
 
 	appVersion string
 }
@@ -51,6 +64,48 @@ func NewServer(
 	}
 }
 
+// WithGrpc multiplexes gRPC (over h2c) and gRPC-Web calls to the given handler on the http port.
+func (s *Server) WithGrpc(grpc http.Handler) *Server {
+	s.grpc = grpc
+
+	return s
+}
+
+// WithGateway serves the REST surface generated from the proto definitions under /v1/ along with its OpenAPI spec.
+func (s *Server) WithGateway(gateway http.Handler) *Server {
+	s.gateway = gateway
+
+	return s
+}
+
+// WithMetrics exposes the database connection pool stats on /metrics in the Prometheus text format.
+func (s *Server) WithMetrics(pools server.PoolStater) *Server {
+	s.pools = pools
+
+	return s
+}
+
+// WithAdmin serves the operator endpoints under /admin/ once the admin token is set.
+func (s *Server) WithAdmin(admin server.Admin) *Server {
+	s.admin = admin
+
+	return s
+}
+
+// WithAdminToken is the bearer token of the /admin/ endpoints, they aren't served without one.
+func (s *Server) WithAdminToken(token string) *Server {
+	s.adminToken = token
+
+	return s
+}
+
+// WithUpdater serves the state of the database update runs on /admin/status.
+func (s *Server) WithUpdater(updater server.UpdaterStater) *Server {
+	s.updater = updater
+
+	return s
+}
+
 func (s *Server) Run() {
 	s.srv.Handler = s.initRouter()
 
@@ -58,6 +113,14 @@ func (s *Server) Run() {
 		s.srv.Handler = rateLimiterMW(s.limiter, s.l, s.srv.Handler)
 	}
 
+	if s.grpc != nil {
+		s.srv.Handler = grpcMW(s.grpc, s.cfg.CorsOrigins(), s.srv.Handler)
+		s.srv.Protocols = new(http.Protocols)
+		s.srv.Protocols.SetHTTP1(true)
+		s.srv.Protocols.SetHTTP2(true)
+		s.srv.Protocols.SetUnencryptedHTTP2(true)
+	}
+
 	s.l.Printf("HTTP server listening at %s", s.srv.Addr)
 	s.l.Fatal(s.srv.ListenAndServe())
 }
@@ -72,10 +135,48 @@ func (s *Server) Close(ctx context.Context) error {
 
 func (s *Server) initRouter() http.Handler {
 	mux := http.NewServeMux()
-	mux.HandleFunc("GET /ip-info", contentTypeRestrictionMW(s.l, s.ipInfo(false), jsonContentType))
-	mux.HandleFunc("GET /client-ip", contentTypeRestrictionMW(s.l, s.ipInfo(true), jsonContentType))
+	// instances of the updater role don't serve lookups
+	if s.locator != nil {
+		mux.HandleFunc("GET /ip-info", deprecationMW("/v1/ip-info",
+			contentTypeRestrictionMW(s.l, s.ipInfo(false), jsonContentType)),
+		)
+		mux.HandleFunc("GET /client-ip", deprecationMW("/v1/client-ip",
+			contentTypeRestrictionMW(s.l, s.ipInfo(true), jsonContentType)),
+		)
+	}
 	mux.HandleFunc("GET /healthz", contentTypeRestrictionMW(s.l, s.healthz(), textPlainContentType))
-	mux.HandleFunc("GET /app/version", contentTypeRestrictionMW(s.l, s.version(), jsonContentType))
+	mux.HandleFunc("GET /app/version", deprecationMW("/v1/app/version",
+		contentTypeRestrictionMW(s.l, s.version(), jsonContentType)),
+	)
+
+	if s.pools != nil {
+		mux.HandleFunc("GET /metrics", s.metrics())
+	}
+
+	if s.adminToken != "" {
+		s.adminRoutes(mux)
+	}
+
+	if s.gateway != nil {
+		mux.Handle("/v1/", s.gateway)
+		mux.HandleFunc("GET /openapi.json", s.openApi())
+	}
 
 	return mux
 }
+
+// adminRoutes registers the operator endpoints, all of them require the admin token.
+func (s *Server) adminRoutes(mux *http.ServeMux) {
+	if s.updater != nil {
+		mux.HandleFunc("GET /admin/status", adminAuthMW(s.l, s.adminToken, s.status()))
+	}
+	if s.admin != nil {
+		mux.HandleFunc("GET /admin/releases/{version}/diff", adminAuthMW(s.l, s.adminToken, s.releaseDiff()))
+		mux.HandleFunc("GET /admin/overrides", adminAuthMW(s.l, s.adminToken, s.listOverrides()))
+		mux.HandleFunc("POST /admin/overrides", adminAuthMW(s.l, s.adminToken, s.createOverride()))
+		mux.HandleFunc("GET /admin/overrides/{id}", adminAuthMW(s.l, s.adminToken, s.getOverride()))
+		mux.HandleFunc("PUT /admin/overrides/{id}", adminAuthMW(s.l, s.adminToken, s.updateOverride()))
+		mux.HandleFunc("DELETE /admin/overrides/{id}", adminAuthMW(s.l, s.adminToken, s.deleteOverride()))
+		mux.HandleFunc("GET /admin/export/latest.mmdb", adminAuthMW(s.l, s.adminToken, s.latestMmdb()))
+	}
+}
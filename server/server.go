@@ -3,8 +3,11 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
 	"strings"
-	"unicode"
+	"time"
 
 	"github.com/streamdp/ip-info/domain"
 )
@@ -12,23 +15,91 @@ import (
 var (
 	ErrRateLimitExceeded = errors.New("rate limit exceeded")
 	ErrWrongIpAddress    = errors.New("could not parse the IP address")
+	ErrWrongNetwork      = errors.New("could not parse the network prefix")
+	ErrWrongPageToken    = errors.New("invalid page token")
+	ErrWrongExpand       = errors.New("unknown expand field")
+	ErrWrongAsOf         = errors.New("could not parse the as_of date")
+	ErrWrongOverride     = errors.New("invalid override")
+	ErrUnauthorized      = errors.New("missing or wrong admin token")
 )
 
+var expandFields = []string{domain.ExpandCountry}
+
 type Locator interface {
-	GetIpInfo(ctx context.Context, ipString string) (ipInfo *domain.IpInfo, err error)
+	GetIpInfo(
+		ctx context.Context, ipString string, opts *domain.LookupOptions,
+	) (ipInfo *domain.IpInfo, err error)
+	GetNetworkInfo(
+		ctx context.Context, cidr string, pageSize int, pageToken string,
+	) (networkInfo *domain.NetworkInfo, err error)
+	GetReleases(ctx context.Context) (releases []*domain.Release, err error)
+	GetProviders(ctx context.Context) (providers []*domain.Provider, err error)
 }
 
 type Limiter interface {
 	Limit(ctx context.Context, ip string) error
 }
 
+type PoolStater interface {
+	PoolStats() []*domain.PoolStats
+}
+
+// UpdaterStater reports the state of the database update runs of the instance.
+type UpdaterStater interface {
+	UpdaterStatus() *domain.UpdaterStatus
+}
+
+// Admin provides the release reports and the overrides management served on the operator endpoints.
+type Admin interface {
+	ReleaseDiff(ctx context.Context, version string) (*domain.ReleaseDiff, error)
+
+	ListOverrides(ctx context.Context) ([]*domain.Override, error)
+	GetOverride(ctx context.Context, id int64) (*domain.Override, error)
+	CreateOverride(ctx context.Context, o *domain.Override) (*domain.Override, error)
+	UpdateOverride(ctx context.Context, o *domain.Override) (*domain.Override, error)
+	DeleteOverride(ctx context.Context, id int64) error
+
+	LatestMmdb(ctx context.Context) (*domain.MmdbExport, error)
+}
+
+// ExtractIpAddress returns the canonical form of the address in a host or host:port string, without a zone and with
+// IPv4-mapped IPv6 addresses unmapped. Strings that aren't addresses are returned as is.
 func ExtractIpAddress(ip string) string {
-	if strings.ContainsAny(ip, "[]") {
-		return strings.Trim(
-			strings.TrimRightFunc(ip, func(r rune) bool { return unicode.IsDigit(r) || r == ':' }),
-			"[]",
-		)
+	if addrPort, err := netip.ParseAddrPort(ip); err == nil {
+		return addrPort.Addr().WithZone("").Unmap().String()
+	}
+	if addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(ip, "["), "]")); err == nil {
+		return addr.WithZone("").Unmap().String()
+	}
+
+	return ip
+}
+
+// NewLookupOptions parses the comma-separated list of fields the client wants to expand in the lookup response, lang
+// is passed as is since unsupported languages fall back to English, the negotiated one is reported by every lookup.
+// asOf is a date or an RFC 3339 time, dates are resolved at their start in UTC.
+func NewLookupOptions(expand, lang, asOf string) (*domain.LookupOptions, error) {
+	opts := &domain.LookupOptions{Language: lang}
+	for _, f := range strings.Split(expand, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" || opts.Expands(f) {
+			continue
+		}
+		if !slices.Contains(expandFields, f) {
+			return nil, fmt.Errorf("%w: %s", ErrWrongExpand, f)
+		}
+		opts.Expand = append(opts.Expand, f)
+	}
+
+	if asOf = strings.TrimSpace(asOf); asOf != "" {
+		t, err := time.Parse(time.DateOnly, asOf)
+		if err != nil {
+			if t, err = time.Parse(time.RFC3339, asOf); err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrWrongAsOf, asOf)
+			}
+		}
+		opts.AsOf = t.UTC()
 	}
 
-	return strings.Split(ip, ":")[0]
+	return opts, nil
 }
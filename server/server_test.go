@@ -1,7 +1,12 @@
 package server
 
 import (
+	"errors"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/streamdp/ip-info/domain"
 )
 
 func TestExtractIpAddress(t *testing.T) {
@@ -30,6 +35,31 @@ func TestExtractIpAddress(t *testing.T) {
 			ip:   "[::1]",
 			want: "::1",
 		},
+		{
+			name: "ipv6 without brackets",
+			ip:   "2001:db8::1",
+			want: "2001:db8::1",
+		},
+		{
+			name: "ipv6 with zone and port",
+			ip:   "[fe80::1%eth0]:8080",
+			want: "fe80::1",
+		},
+		{
+			name: "ipv6 with zone",
+			ip:   "fe80::1%eth0",
+			want: "fe80::1",
+		},
+		{
+			name: "ipv4-mapped ipv6 with port",
+			ip:   "[::ffff:8.8.8.8]:443",
+			want: "8.8.8.8",
+		},
+		{
+			name: "not an address",
+			ip:   "localhost:8080",
+			want: "localhost:8080",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -39,3 +69,69 @@ func TestExtractIpAddress(t *testing.T) {
 		})
 	}
 }
+
+func TestNewLookupOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		expand  string
+		lang    string
+		asOf    string
+		want    *domain.LookupOptions
+		wantErr error
+	}{
+		{
+			name:   "nothing to expand",
+			expand: "",
+			want:   &domain.LookupOptions{},
+		},
+		{
+			name:   "expand country",
+			expand: "country",
+			want:   &domain.LookupOptions{Expand: []string{domain.ExpandCountry}},
+		},
+		{
+			name:   "duplicates, spaces and case are ignored",
+			expand: " Country,,country ",
+			want:   &domain.LookupOptions{Expand: []string{domain.ExpandCountry}},
+		},
+		{
+			name:   "expand country in german",
+			expand: "country",
+			lang:   "de-DE,de;q=0.9",
+			want:   &domain.LookupOptions{Expand: []string{domain.ExpandCountry}, Language: "de-DE,de;q=0.9"},
+		},
+		{
+			name:    "unknown field",
+			expand:  "country,weather",
+			wantErr: ErrWrongExpand,
+		},
+		{
+			name: "as of date",
+			asOf: "2026-06-15",
+			want: &domain.LookupOptions{AsOf: time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "as of time",
+			asOf: "2026-06-15T10:30:00+02:00",
+			want: &domain.LookupOptions{AsOf: time.Date(2026, 6, 15, 8, 30, 0, 0, time.UTC)},
+		},
+		{
+			name:    "wrong as of date",
+			asOf:    "15.06.2026",
+			wantErr: ErrWrongAsOf,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewLookupOptions(tt.expand, tt.lang, tt.asOf)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("NewLookupOptions() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewLookupOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
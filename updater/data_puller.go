@@ -4,31 +4,93 @@ import (
 	"context"
 	"errors"
 	"log"
+	"math/rand/v2"
+	"net/url"
+	"os"
+	"regexp"
+	"sync"
 	"time"
 
+	"github.com/streamdp/ip-info/config"
 	"github.com/streamdp/ip-info/database"
+	"github.com/streamdp/ip-info/domain"
 )
 
-const repeatIntervalOnError = 1 * time.Minute
+// urlRegexp matches the urls the import errors may echo, e.g. the download command of a failed copy.
+var urlRegexp = regexp.MustCompile(`[a-z][a-z0-9+.-]*://[^\s"'\\]+`)
+
+const (
+	firstRunDelay = time.Second
+	// minRunInterval keeps the runs from looping when the release of the month is due but isn't published yet
+	minRunInterval = time.Minute
+)
 
 type DatabaseUpdater interface {
-	UpdateIpDatabase(ctx context.Context) (duration time.Duration, err error)
+	UpdateIpDatabase(ctx context.Context, onStarted func()) (*domain.UpdateReport, error)
+	ReloadReleases(ctx context.Context) (bool, error)
+}
+
+// Notifier is told about the lifecycle of the update runs, e.g. to alert the on-call team of the failed imports.
+type Notifier interface {
+	Notify(ctx context.Context, event *domain.UpdateEvent)
 }
 
 type puller struct {
-	d DatabaseUpdater
-	l *log.Logger
+	d        DatabaseUpdater
+	l        *log.Logger
+	cfg      *config.Updater
+	notifier Notifier
+	onSwap   func(ctx context.Context) error
+	instance string
+
+	status *domain.UpdaterStatus
+	mu     sync.RWMutex
+
+	now    func() time.Time
+	jitter func(time.Duration) time.Duration
 }
 
-func New(d DatabaseUpdater, l *log.Logger) *puller {
+func New(d DatabaseUpdater, l *log.Logger, cfg *config.Updater) *puller {
+	status := &domain.UpdaterStatus{Mode: domain.UpdateModeImport, Schedule: cfg.Schedule()}
+	if cfg.Disabled() {
+		status = &domain.UpdaterStatus{Mode: domain.UpdateModeReload}
+	}
+
+	instance, err := os.Hostname()
+	if err != nil {
+		instance = "ip-info"
+	}
+
 	return &puller{
-		d: d,
-		l: l,
+		d:        d,
+		l:        l,
+		cfg:      cfg,
+		instance: instance,
+		status:   status,
+		now:      time.Now,
+		jitter:   randomJitter,
 	}
 }
 
+// WithNotifier sends the events of the update runs to the notifier, the reloads aren't reported.
+func (p *puller) WithNotifier(n Notifier) *puller {
+	p.notifier = n
+
+	return p
+}
+
+// WithSwapHandler calls onSwap whenever a reload picks up other active tables, e.g. to drop the cached lookups when
+// the notification of the swap was missed.
+func (p *puller) WithSwapHandler(onSwap func(ctx context.Context) error) *puller {
+	p.onSwap = onSwap
+
+	return p
+}
+
 func (p *puller) PullUpdates(ctx context.Context) {
-	t := time.NewTimer(time.Second)
+	p.setNextRun(p.now().Add(firstRunDelay))
+
+	t := time.NewTimer(firstRunDelay)
 	defer t.Stop()
 
 	for {
@@ -36,22 +98,209 @@ func (p *puller) PullUpdates(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-t.C:
-			p.l.Println("ip database update started")
-			nextUpdate, err := p.d.UpdateIpDatabase(ctx)
-			if err != nil {
-				p.l.Printf("failed to update ip database: %v", err)
-
-				if !errors.Is(err, database.ErrNoUpdateRequired) {
-					p.l.Printf("ip database update interrupted, retry after %0.1fs",
-						repeatIntervalOnError.Seconds())
-					t.Reset(repeatIntervalOnError)
-
-					continue
-				}
-			}
-
-			p.l.Printf("ip database update completed, next update through %0.1fh", nextUpdate.Hours())
-			t.Reset(nextUpdate)
+			t.Reset(p.run(ctx))
+		}
+	}
+}
+
+// UpdaterStatus returns the state of the update runs served on the status endpoint.
+func (p *puller) UpdaterStatus() *domain.UpdaterStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	status := *p.status
+
+	return &status
+}
+
+// run imports the outdated releases, or reloads the active ones in the reload mode, and returns the delay before
+// the next run. Failed imports are retried with an exponential backoff until the attempts are exhausted, then they
+// wait for the next scheduled run. Runs with no release due or with another instance importing them are skipped.
+func (p *puller) run(ctx context.Context) time.Duration {
+	now := p.now()
+
+	if p.cfg.Disabled() {
+		return p.reload(ctx, now)
+	}
+
+	report, err := p.d.UpdateIpDatabase(ctx, func() {
+		p.l.Println("ip database update started")
+		p.notify(ctx, &domain.UpdateEvent{Event: domain.UpdateEventStarted, Time: p.now()})
+	})
+	if err != nil && !skipped(err) {
+		p.l.Printf("failed to update ip database: %v", err)
+
+		attempts, delay := p.retry(now, err)
+		p.notify(ctx, &domain.UpdateEvent{
+			Event:      domain.UpdateEventFailed,
+			Time:       p.now(),
+			DurationMs: p.now().Sub(now).Milliseconds(),
+			Error:      redactError(err),
+			Attempts:   attempts,
+			NextRun:    now.Add(delay).UTC(),
+		})
+
+		return delay
+	}
+
+	event := &domain.UpdateEvent{Event: domain.UpdateEventSucceeded, Releases: report.Releases}
+	if err != nil {
+		p.l.Printf("ip database update skipped: %v", err)
+		event.Event = domain.UpdateEventSkipped
+	}
+
+	p.succeeded(now)
+
+	delay := p.scheduleNext(now, report.NextUpdate)
+	p.l.Printf("ip database update completed, next update through %0.1fh", delay.Hours())
+
+	event.Time, event.DurationMs, event.NextRun = p.now(), p.now().Sub(now).Milliseconds(), now.Add(delay).UTC()
+	p.notify(ctx, event)
+
+	return delay
+}
+
+// retry counts the failed attempt and returns the attempts so far with the delay before the next one, the
+// exponential backoff until the attempts are exhausted and the next scheduled run after that.
+func (p *puller) retry(now time.Time, err error) (int, time.Duration) {
+	attempts := p.failed(now, err)
+	if attempts < p.cfg.RetryAttempts() {
+		delay := p.backoff(attempts)
+		p.l.Printf("ip database update interrupted, retry %d of %d after %0.1fs", attempts,
+			p.cfg.RetryAttempts()-1, delay.Seconds())
+		p.setNextRun(now.Add(delay))
+
+		return attempts, delay
+	}
+
+	p.l.Printf("ip database update failed %d times, waiting for the next scheduled run", attempts)
+	p.resetAttempts()
+
+	return attempts, p.scheduleNext(now, nextMonthlyRelease(now))
+}
+
+func (p *puller) notify(ctx context.Context, event *domain.UpdateEvent) {
+	if p.notifier == nil {
+		return
+	}
+	event.Instance = p.instance
+	event.Time = event.Time.UTC()
+
+	p.notifier.Notify(ctx, event)
+}
+
+// reload picks up the releases activated by the importing instance, whatever its schedule is, so the config is
+// polled and a failed reload is simply retried on the next poll.
+func (p *puller) reload(ctx context.Context, now time.Time) time.Duration {
+	changed, err := p.d.ReloadReleases(ctx)
+	if err != nil {
+		p.l.Printf("failed to reload ip database releases: %v", err)
+		p.failed(now, err)
+	} else {
+		p.succeeded(now)
+	}
+	if changed && p.onSwap != nil {
+		if err = p.onSwap(ctx); err != nil {
+			p.l.Printf("failed to handle releases swap: %v", err)
+		}
+	}
+
+	delay := p.cfg.PollInterval()
+	p.setNextRun(now.Add(delay))
+
+	return delay
+}
+
+// scheduleNext returns the delay until the next run of the cron schedule, or the given one when the runs follow the
+// monthly releases, with the jitter added.
+func (p *puller) scheduleNext(now time.Time, nextUpdate time.Duration) time.Duration {
+	delay := nextUpdate
+	if s := p.cfg.Cron(); s != nil {
+		if next := s.Next(now); !next.IsZero() {
+			delay = next.Sub(now)
 		}
 	}
+	delay = max(delay, minRunInterval) + p.jitter(p.cfg.Jitter())
+
+	p.setNextRun(now.Add(delay))
+
+	return delay
+}
+
+// backoff doubles the retry backoff with every failed attempt up to the max retry backoff.
+func (p *puller) backoff(attempts int) time.Duration {
+	delay := p.cfg.RetryBackoff()
+	for i := 1; i < attempts && delay < p.cfg.RetryMaxBackoff(); i++ {
+		delay *= 2
+	}
+
+	return min(delay, p.cfg.RetryMaxBackoff())
+}
+
+func (p *puller) failed(now time.Time, err error) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.status.LastRun = now.UTC()
+	p.status.LastError = redactError(err)
+	p.status.Attempts++
+
+	return p.status.Attempts
+}
+
+func (p *puller) succeeded(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.status.LastRun = now.UTC()
+	p.status.LastError = ""
+	p.status.Attempts = 0
+}
+
+func (p *puller) resetAttempts() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.status.Attempts = 0
+}
+
+func (p *puller) setNextRun(t time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.status.NextRun = t.UTC().Truncate(time.Second)
+}
+
+// skipped tells whether the run was skipped, when no release is due or another instance holds the update lock.
+func skipped(err error) bool {
+	return errors.Is(err, database.ErrNoUpdateRequired) || errors.Is(err, database.ErrLockAcquired)
+}
+
+func randomJitter(jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return 0
+	}
+
+	return rand.N(jitter)
+}
+
+// redactError keeps only the scheme and the host of the urls in the error, since the user info, the path or the query
+// of a provider url may carry its license key and the error is served on the status endpoint and sent to the webhooks.
+func redactError(err error) string {
+	return urlRegexp.ReplaceAllStringFunc(err.Error(), func(s string) string {
+		u, errParse := url.Parse(s)
+		if errParse != nil || u.Host == "" {
+			return "[redacted url]"
+		}
+
+		return u.Scheme + "://" + u.Host + "/[redacted]"
+	})
+}
+
+// nextMonthlyRelease returns the time left until the release of the next month is due, it's where a run giving up
+// waits when the runs follow the monthly releases.
+func nextMonthlyRelease(now time.Time) time.Duration {
+	year, month, _ := now.UTC().Date()
+
+	return time.Date(year, month+1, 2, 0, 0, 0, 0, time.UTC).Sub(now)
 }